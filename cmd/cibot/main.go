@@ -1,13 +1,28 @@
 package main
 
 import (
+	"os"
+
 	"github.com/spf13/pflag"
 
 	"gitee.com/openeuler/ci-bot/pkg/cibot"
 )
 
 func main() {
+	// cibot cla backfill --org X [--repo glob] [--skip-comment]
+	if len(os.Args) > 2 && os.Args[1] == "cla" && os.Args[2] == "backfill" {
+		bf := cibot.NewCLABackfill()
+		bf.AddFlags(pflag.CommandLine)
+		os.Exit(bf.Run())
+	}
+	// cibot cla report [--type individual|corporation] [--corporation X] [--from date] [--to date] [--counts] [--pii]
+	if len(os.Args) > 2 && os.Args[1] == "cla" && os.Args[2] == "report" {
+		rp := cibot.NewCLAReport()
+		rp.AddFlags(pflag.CommandLine)
+		os.Exit(rp.Run())
+	}
+
 	wh := cibot.NewWebHook()
 	wh.AddFlags(pflag.CommandLine)
-	wh.Run()
+	os.Exit(wh.Run())
 }
@@ -1,23 +1,11 @@
 package cibot
 
 import (
-	"fmt"
-
 	"gitee.com/openeuler/go-gitee/gitee"
 	"github.com/antihax/optional"
 	"github.com/golang/glog"
 )
 
-const (
-	approvedAddedMessage           = `***approved*** was added to this pull request by: ***%s***. :wave: 
-**NOTE:**: If you find this pull request unmerged while all conditions meets, you are encouraged use command: "/check-pr" to try it again. :smile: `
-	approvedRemovedMessage         = `***approved*** was removed in this pull request by: ***%s***. :flushed: `
-	approvedAddNoPermissionMessage = `***%s*** has no permission to add ***approved*** in this pull request. :astonished:
-please contact to the collaborators in this repository.`
-	approvedRemoveNoPermissionMessage = `***%s*** has no permission to remove ***approved*** in this pull request. :astonished:
-please contact to the collaborators in this repository.`
-)
-
 // AddApprove adds approved label
 func (s *Server) AddApprove(event *gitee.NoteEvent) error {
 	// handle PullRequest
@@ -57,19 +45,24 @@ func (s *Server) AddApprove(event *gitee.NoteEvent) error {
 
 			// permission: admin, write, read, none
 			if permission.Permission == "admin" || permission.Permission == "write" || isOwner {
+				// record approve on current head
+				err = s.recordVoteOnHead(owner, repo, prNumber, commentAuthor, VoteKindApprove)
+				if err != nil {
+					return err
+				}
 				// add approved label
 				addlabel := &gitee.NoteEvent{}
 				addlabel.PullRequest = event.PullRequest
 				addlabel.Repository = event.Repository
 				addlabel.Comment = &gitee.NoteHook{}
-				err = s.AddSpecifyLabelsInPulRequest(addlabel, []string{LabelNameApproved}, false)
+				_, err = s.syncVoteLabels(addlabel)
 				if err != nil {
 					return err
 				}
 				// add comment
 				body := gitee.PullRequestCommentPostParam{}
 				body.AccessToken = s.Config.GiteeToken
-				body.Body = fmt.Sprintf(approvedAddedMessage, commentAuthor)
+				body.Body = s.message(owner, repo, approvedAddedMessage, MessageData{"Commenter": commentAuthor})
 				owner := event.Repository.Namespace
 				repo := event.Repository.Name
 				number := event.PullRequest.Number
@@ -87,7 +80,7 @@ func (s *Server) AddApprove(event *gitee.NoteEvent) error {
 				// add comment
 				body := gitee.PullRequestCommentPostParam{}
 				body.AccessToken = s.Config.GiteeToken
-				body.Body = fmt.Sprintf(approvedAddNoPermissionMessage, commentAuthor)
+				body.Body = s.message(owner, repo, approvedAddNoPermissionMessage, MessageData{"Commenter": commentAuthor})
 				owner := event.Repository.Namespace
 				repo := event.Repository.Path
 				number := event.PullRequest.Number
@@ -141,21 +134,28 @@ func (s *Server) RemoveApprove(event *gitee.NoteEvent) error {
 
 			// permission: admin, write, read, none
 			if permission.Permission == "admin" || permission.Permission == "write" || isOwner {
+				// retract all of approve
+				err = s.backfillVotesOnHead(owner, repo, prNumber)
+				if err != nil {
+					return err
+				}
+				err = retractVotes(owner, repo, prNumber, "", VoteKindApprove)
+				if err != nil {
+					return err
+				}
 				// remove approved label
 				removelabel := &gitee.NoteEvent{}
 				removelabel.PullRequest = event.PullRequest
 				removelabel.Repository = event.Repository
 				removelabel.Comment = &gitee.NoteHook{}
-				mapOfRemoveLabels := map[string]string{}
-				mapOfRemoveLabels[LabelNameApproved] = LabelNameApproved
-				err = s.RemoveSpecifyLabelsInPulRequest(removelabel, mapOfRemoveLabels)
+				_, err = s.syncVoteLabels(removelabel)
 				if err != nil {
 					return err
 				}
 				// add comment
 				body := gitee.PullRequestCommentPostParam{}
 				body.AccessToken = s.Config.GiteeToken
-				body.Body = fmt.Sprintf(approvedRemovedMessage, commentAuthor)
+				body.Body = s.message(owner, repo, approvedRemovedMessage, MessageData{"Commenter": commentAuthor})
 				owner := event.Repository.Namespace
 				repo := event.Repository.Path
 				number := event.PullRequest.Number
@@ -168,7 +168,7 @@ func (s *Server) RemoveApprove(event *gitee.NoteEvent) error {
 				// add comment
 				body := gitee.PullRequestCommentPostParam{}
 				body.AccessToken = s.Config.GiteeToken
-				body.Body = fmt.Sprintf(approvedRemoveNoPermissionMessage, commentAuthor)
+				body.Body = s.message(owner, repo, approvedRemoveNoPermissionMessage, MessageData{"Commenter": commentAuthor})
 				owner := event.Repository.Namespace
 				repo := event.Repository.Path
 				number := event.PullRequest.Number
@@ -1,21 +1,12 @@
 package cibot
 
 import (
-	"fmt"
 	"strings"
 
 	"gitee.com/openeuler/go-gitee/gitee"
 	"github.com/golang/glog"
 )
 
-const (
-	issueAssignMessage       = `this issue is assigned to: ***@%s***.`
-	issueCanNotAssignMessage = `this issue can not be assigned to: ***%s***.
-please try to assign to the repository collaborators.`
-	issueNoNeedAssignMessage = `this issue is already assigned to: ***%s***.
-please do not assign repeatedly.`
-)
-
 // Assign a collaborator for issue
 func (s *Server) Assign(event *gitee.NoteEvent) error {
 	if *event.NoteableType == "Issue" {
@@ -71,7 +62,7 @@ func (s *Server) Assign(event *gitee.NoteEvent) error {
 						// add comment
 						body := gitee.IssueCommentPostParam{}
 						body.AccessToken = s.Config.GiteeToken
-						body.Body = fmt.Sprintf(issueCanNotAssignMessage, assignee)
+						body.Body = s.message(owner, repo, issueCanNotAssignMessage, MessageData{"Assignee": assignee})
 						_, _, err := s.GiteeClient.IssuesApi.PostV5ReposOwnerRepoIssuesNumberComments(s.Context, owner, repo, issueNumber, body)
 						if err != nil {
 							glog.Errorf("unable to add comment in issue: %v", err)
@@ -85,7 +76,7 @@ func (s *Server) Assign(event *gitee.NoteEvent) error {
 					// add comment
 					body := gitee.IssueCommentPostParam{}
 					body.AccessToken = s.Config.GiteeToken
-					body.Body = fmt.Sprintf(issueAssignMessage, assignee)
+					body.Body = s.message(owner, repo, issueAssignMessage, MessageData{"Assignee": assignee})
 					_, _, err := s.GiteeClient.IssuesApi.PostV5ReposOwnerRepoIssuesNumberComments(s.Context, owner, repo, issueNumber, body)
 					if err != nil {
 						glog.Errorf("unable to add comment in issue: %v", err)
@@ -96,7 +87,7 @@ func (s *Server) Assign(event *gitee.NoteEvent) error {
 				// add comment
 				body := gitee.IssueCommentPostParam{}
 				body.AccessToken = s.Config.GiteeToken
-				body.Body = fmt.Sprintf(issueNoNeedAssignMessage, assignee)
+				body.Body = s.message(owner, repo, issueNoNeedAssignMessage, MessageData{"Assignee": assignee})
 				_, _, err := s.GiteeClient.IssuesApi.PostV5ReposOwnerRepoIssuesNumberComments(s.Context, owner, repo, issueNumber, body)
 				if err != nil {
 					glog.Errorf("unable to add comment in issue: %v", err)
@@ -0,0 +1,149 @@
+package cibot
+
+import (
+	"strings"
+
+	"gitee.com/openeuler/go-gitee/gitee"
+	"github.com/golang/glog"
+)
+
+const (
+	BranchStateDevelopment  = "development"
+	BranchStateMaintenance  = "maintenance"
+	BranchStateSecurityOnly = "security-only"
+	BranchStateEOL          = "eol"
+
+	// the pull request is labelled with the state of its target branch, e.g. branch/maintenance
+	branchStateLabelPrefix = "branch/"
+
+	labelKindSecurity = "kind/security"
+	labelKindBugfix   = "kind/bugfix"
+)
+
+// branchStates are the lifecycle states of branches in release management
+var branchStates map[frozenBranch]string
+
+// writeBranchStates records the lifecycle states of branches, guarded by the lock of frozen list
+func writeBranchStates(release []FrozenBranchYaml) {
+	states := make(map[frozenBranch]string)
+	for _, v := range release {
+		if v.State == "" {
+			continue
+		}
+		state := strings.ToLower(v.State)
+		switch state {
+		case BranchStateDevelopment, BranchStateMaintenance, BranchStateSecurityOnly, BranchStateEOL:
+		default:
+			glog.Errorf("unknown state %s of branch %s", v.State, v.Branch)
+			continue
+		}
+		for _, c := range v.Communtiy {
+			states[frozenBranch{community: c, branch: v.Branch}] = state
+		}
+	}
+	lock.Lock()
+	defer lock.Unlock()
+	branchStates = states
+	glog.Infof("branch states after write: %v", branchStates)
+}
+
+// BranchState gets the lifecycle state of branch in community, empty if it is not set
+func BranchState(branch, community string) string {
+	lock.RLock()
+	defer lock.RUnlock()
+	return branchStates[frozenBranch{community: community, branch: branch}]
+}
+
+// branchStateRequiredLabels gets the labels one of which is required to merge to the branch in state
+func branchStateRequiredLabels(state string) []string {
+	switch state {
+	case BranchStateMaintenance:
+		return []string{labelKindSecurity, labelKindBugfix}
+	case BranchStateSecurityOnly:
+		return []string{labelKindSecurity}
+	}
+	return nil
+}
+
+// branchStateAllowsMerge checks whether the pull request with labels can be merged to the branch in state
+func branchStateAllowsMerge(state string, labels []gitee.Label) bool {
+	if state == BranchStateEOL {
+		return false
+	}
+	required := branchStateRequiredLabels(state)
+	if len(required) == 0 {
+		return true
+	}
+	for _, l := range labels {
+		for _, r := range required {
+			if l.Name == r {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// HandleBranchStateByPullRequestOpen labels the pull request with the state of its target branch,
+// and closes it if the branch is end of life
+func (s *Server) HandleBranchStateByPullRequestOpen(event *gitee.PullRequestEvent) error {
+	owner := event.Repository.Namespace
+	repo := event.Repository.Path
+	number := event.PullRequest.Number
+	branch := event.PullRequest.Base.Ref
+	state := BranchState(branch, owner)
+	if state == "" {
+		return nil
+	}
+	glog.Infof("pull request %s/%s/%d targets %s branch %s", owner, repo, number, state, branch)
+
+	noteEvent := &gitee.NoteEvent{}
+	noteEvent.Repository = event.Repository
+	noteEvent.PullRequest = event.PullRequest
+	noteEvent.Comment = &gitee.NoteHook{}
+	err := s.AddSpecifyLabelsInPulRequest(noteEvent, []string{branchStateLabelPrefix + state}, true)
+	if err != nil {
+		glog.Errorf("unable to add branch state label: %v", err)
+	}
+
+	author := ""
+	if event.PullRequest.User != nil {
+		author = event.PullRequest.User.Login
+	}
+	if state != BranchStateEOL {
+		required := branchStateRequiredLabels(state)
+		if len(required) == 0 {
+			return nil
+		}
+		return s.addCommentToPullRequest(owner, repo, s.message(owner, repo, branchStateRequiresLabelsMessage,
+			MessageData{"Author": author, "Branch": branch, "State": state, "Labels": required}), number)
+	}
+
+	body := gitee.PullRequestUpdateParam{}
+	body.AccessToken = s.Config.GiteeToken
+	body.State = "closed"
+	_, _, err = s.GiteeClient.PullRequestsApi.PatchV5ReposOwnerRepoPullsNumber(s.Context, owner, repo, number, body)
+	if err != nil {
+		glog.Errorf("unable to close pull request to eol branch: %d err: %v", number, err)
+		return err
+	}
+	return s.addCommentToPullRequest(owner, repo,
+		s.message(owner, repo, branchEOLClosedMessage, MessageData{"Author": author, "Branch": branch}), number)
+}
+
+// checkBranchStateForMerge checks whether the state of target branch allows merging the pull request,
+// and gets the reason if not
+func (s *Server) checkBranchStateForMerge(owner, repo string, pr gitee.PullRequest) (string, bool) {
+	if pr.Base == nil {
+		return "", true
+	}
+	state := BranchState(pr.Base.Ref, owner)
+	if branchStateAllowsMerge(state, pr.Labels) {
+		return "", true
+	}
+	if state == BranchStateEOL {
+		return s.message(owner, repo, branchEOLMergeMessage, MessageData{"Branch": pr.Base.Ref}), false
+	}
+	return s.message(owner, repo, branchStateMergeMessage, MessageData{
+		"Branch": pr.Base.Ref, "State": state, "Labels": branchStateRequiredLabels(state)}), false
+}
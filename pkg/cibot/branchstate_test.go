@@ -0,0 +1,88 @@
+package cibot
+
+import (
+	"encoding/base64"
+	"testing"
+
+	"gitee.com/openeuler/go-gitee/gitee"
+)
+
+func TestBranchState(t *testing.T) {
+	defer writeBranchStates(nil)
+	writeBranchStates([]FrozenBranchYaml{
+		{Branch: "master", Communtiy: []string{"openeuler"}, State: "development"},
+		{Branch: "openEuler-20.03-LTS", Communtiy: []string{"openeuler", "src-openeuler"}, State: "Maintenance"},
+		{Branch: "openEuler-1.0", Communtiy: []string{"src-openeuler"}, State: "EOL"},
+		{Branch: "openEuler-21.03", Communtiy: []string{"src-openeuler"}, State: "unknown"},
+	})
+	tests := []struct {
+		branch, community string
+		want              string
+	}{
+		{"master", "openeuler", BranchStateDevelopment},
+		{"master", "src-openeuler", ""},
+		{"openEuler-20.03-LTS", "src-openeuler", BranchStateMaintenance},
+		{"openEuler-1.0", "src-openeuler", BranchStateEOL},
+		{"openEuler-21.03", "src-openeuler", ""},
+	}
+	for _, tt := range tests {
+		if got := BranchState(tt.branch, tt.community); got != tt.want {
+			t.Errorf("BranchState(%s, %s) = %s, want %s", tt.branch, tt.community, got, tt.want)
+		}
+	}
+}
+
+func TestBranchStateOfFrozenFile(t *testing.T) {
+	defer extractFrozenBranch(nil)
+	defer writeBranchStates(nil)
+	content := base64.StdEncoding.EncodeToString([]byte(`release:
+- branch: openEuler-1.0
+  community: [src-openeuler]
+  state: eol
+`))
+	if err := handleContent([]string{content}); err != nil {
+		t.Fatal(err)
+	}
+	// no branch is frozen in the file but the states are kept
+	if got := BranchState("openEuler-1.0", "src-openeuler"); got != BranchStateEOL {
+		t.Errorf("BranchState() = %s, want %s", got, BranchStateEOL)
+	}
+
+	// the states are kept if the file can not be parsed
+	if err := handleContent([]string{base64.StdEncoding.EncodeToString([]byte("release: {"))}); err == nil {
+		t.Errorf("handleContent() of invalid file error = nil")
+	}
+	if got := BranchState("openEuler-1.0", "src-openeuler"); got != BranchStateEOL {
+		t.Errorf("BranchState() after invalid file = %s, want %s", got, BranchStateEOL)
+	}
+}
+
+func TestBranchStateAllowsMerge(t *testing.T) {
+	labels := func(names ...string) []gitee.Label {
+		ls := make([]gitee.Label, 0, len(names))
+		for _, n := range names {
+			ls = append(ls, gitee.Label{Name: n})
+		}
+		return ls
+	}
+	tests := []struct {
+		name   string
+		state  string
+		labels []gitee.Label
+		want   bool
+	}{
+		{"no state", "", labels(), true},
+		{"development", BranchStateDevelopment, labels("kind/feature"), true},
+		{"maintenance bugfix", BranchStateMaintenance, labels("kind/bugfix"), true},
+		{"maintenance security", BranchStateMaintenance, labels("kind/security"), true},
+		{"maintenance feature", BranchStateMaintenance, labels("kind/feature"), false},
+		{"security-only bugfix", BranchStateSecurityOnly, labels("kind/bugfix"), false},
+		{"security-only security", BranchStateSecurityOnly, labels("kind/security"), true},
+		{"eol", BranchStateEOL, labels("kind/security"), false},
+	}
+	for _, tt := range tests {
+		if got := branchStateAllowsMerge(tt.state, tt.labels); got != tt.want {
+			t.Errorf("%s: branchStateAllowsMerge() = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
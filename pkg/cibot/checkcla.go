@@ -1,113 +1,167 @@
 package cibot
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
+	"regexp"
+	"sort"
 	"strings"
 
 	"gitee.com/openeuler/ci-bot/pkg/cibot/database"
 	"gitee.com/openeuler/go-gitee/gitee"
+	"github.com/antihax/optional"
 	"github.com/golang/glog"
 )
 
-const (
-	claNotFoundMessage = `Thanks for your pull request.
-**Before we can look at your pull request, you'll need to sign a Contributor License Agreement (CLA).**
-**Please follow instructions at <%s> to sign the CLA.**
-It may take a couple minutes for the CLA signature to be fully registered;
-after that, please reply here with a new comment **/check-cla** and we'll verify.
-- If you've already signed a CLA, it's possible we don't have your Gitee username or you're using a different email address.
-  Check your existing CLA data and verify that your email at <https://gitee.com/profile/emails>.
-- If you have done the above and are still having issues with the CLA being reported as unsigned,
-  send a message to the backup e-mail support address at: %s
-`
-	claFoundMessage = `Thanks for your pull request. you've already signed %s CLA successfully. :wave: `
-)
+// regCoAuthoredBy matches the Co-authored-by trailers of commit message
+var regCoAuthoredBy = regexp.MustCompile(`(?mi)^Co-authored-by:\s*(.*?)\s*<([^>]*)>\s*$`)
 
-// CheckCLAByNoteEvent check cla by NoteEvent
-func (s *Server) CheckCLAByNoteEvent(event *gitee.NoteEvent) error {
-	if *event.NoteableType == "PullRequest" && s.Config.AutoDetectCla {
-		// PullRequest
-		email := event.PullRequest.User.Email
-		// check the email from sender
-		var lenEmail int
-		err := database.DBConnection.Model(&database.CLADetails{}).
-			Where("email = ?", email).Count(&lenEmail).Error
-		if err != nil {
-			glog.Errorf("failed to check user email: %v", err)
-			return err
-		}
-		if lenEmail > 0 {
-			// add label openeuler-cla/yes
-			addlabel := &gitee.NoteEvent{}
-			addlabel.PullRequest = event.PullRequest
-			addlabel.Repository = event.Repository
-			addlabel.Comment = &gitee.NoteHook{}
-			addlabel.Comment.Body = fmt.Sprintf(AddClaYes, strings.ToLower(s.Config.CommunityName))
-			err = s.AddLabelInPulRequest(addlabel)
-			if err != nil {
-				return err
-			}
+// claIdentity is the author, committer or co-author of commit to check with cla
+type claIdentity struct {
+	Role   string
+	Name   string
+	Email  string
+	Signed bool
+	// signed, outdated, revoked or unsigned
+	Status string
+}
 
-			// remove label openeuler-cla/no
-			removelabel := &gitee.NoteEvent{}
-			removelabel.PullRequest = event.PullRequest
-			removelabel.Repository = event.Repository
-			removelabel.Comment = &gitee.NoteHook{}
-			removelabel.Comment.Body = fmt.Sprintf(RemoveClaNo, strings.ToLower(s.Config.CommunityName))
-			err = s.RemoveLabelInPullRequest(removelabel)
-			if err != nil {
-				return err
-			}
+// MaskedEmail gets the email of identity to show in comments
+func (id claIdentity) MaskedEmail() string {
+	return maskEmail(id.Email)
+}
 
-			// add comment
-			body := gitee.PullRequestCommentPostParam{}
-			body.AccessToken = s.Config.GiteeToken
-			body.Body = fmt.Sprintf(claFoundMessage, s.Config.CommunityName)
-			owner := event.Repository.Namespace
-			repo := event.Repository.Path
-			number := event.PullRequest.Number
-			_, _, err = s.GiteeClient.PullRequestsApi.PostV5ReposOwnerRepoPullsNumberComments(s.Context, owner, repo, number, body)
-			if err != nil {
-				glog.Errorf("unable to add comment in pull request: %v", err)
-				return err
-			}
-		} else {
-			// add label openeuler-cla/no
-			addlabel := &gitee.NoteEvent{}
-			addlabel.PullRequest = event.PullRequest
-			addlabel.Repository = event.Repository
-			addlabel.Comment = &gitee.NoteHook{}
-			addlabel.Comment.Body = fmt.Sprintf(AddClaNo, strings.ToLower(s.Config.CommunityName))
-			err = s.AddLabelInPulRequest(addlabel)
-			if err != nil {
-				return err
+// maskEmail keeps the first letter of the local part and the domain of email, such as a***@example.com
+func maskEmail(email string) string {
+	if email == "" {
+		return ""
+	}
+	i := strings.LastIndex(email, "@")
+	if i < 1 {
+		return "***"
+	}
+	return string([]rune(email[:i])[0]) + "***" + email[i:]
+}
+
+// claCommit is the commit with its identities
+type claCommit struct {
+	Sha        string
+	Subject    string
+	Identities []claIdentity
+}
+
+// claCommitsOf collects the authors, committers and co-authors of commits,
+// the committers with exempt emails such as the web committer of gitee are skipped
+func claCommitsOf(commits []gitee.PullRequestCommits, exemptEmails []string) []claCommit {
+	exempt := make(map[string]bool, len(exemptEmails))
+	for _, e := range exemptEmails {
+		exempt[strings.ToLower(e)] = true
+	}
+	result := make([]claCommit, 0, len(commits))
+	for _, c := range commits {
+		if c.Commit == nil {
+			continue
+		}
+		cc := claCommit{
+			Sha:     c.Sha,
+			Subject: strings.SplitN(strings.TrimSpace(c.Commit.Message), "\n", 2)[0],
+		}
+		existing := make(map[string]bool)
+		add := func(role, name, email string) {
+			email = strings.ToLower(strings.TrimSpace(email))
+			if (role == "committer" && exempt[email]) || (email != "" && existing[email]) {
+				return
 			}
+			existing[email] = true
+			cc.Identities = append(cc.Identities, claIdentity{Role: role, Name: name, Email: email})
+		}
+		if c.Commit.Author != nil {
+			add("author", c.Commit.Author.Name, c.Commit.Author.Email)
+		}
+		if c.Commit.Committer != nil {
+			add("committer", c.Commit.Committer.Name, c.Commit.Committer.Email)
+		}
+		for _, m := range regCoAuthoredBy.FindAllStringSubmatch(c.Commit.Message, -1) {
+			add("co-author", m[1], m[2])
+		}
+		result = append(result, cc)
+	}
+	return result
+}
 
-			// remove label openeuler-cla/yes
-			removelabel := &gitee.NoteEvent{}
-			removelabel.PullRequest = event.PullRequest
-			removelabel.Repository = event.Repository
-			removelabel.Comment = &gitee.NoteHook{}
-			removelabel.Comment.Body = fmt.Sprintf(RemoveClaYes, strings.ToLower(s.Config.CommunityName))
-			err = s.RemoveLabelInPullRequest(removelabel)
-			if err != nil {
-				return err
+// markCLASigned marks the cla status of identities, and checks whether all of them signed,
+// the commit without identities is unsigned
+func markCLASigned(commits []claCommit, statuses map[string]string) bool {
+	all := len(commits) > 0
+	for i := range commits {
+		if len(commits[i].Identities) == 0 {
+			all = false
+		}
+		for j := range commits[i].Identities {
+			status := claStatusUnsigned
+			if email := commits[i].Identities[j].Email; email != "" && statuses[email] != "" {
+				status = statuses[email]
 			}
+			signed := status == claStatusSigned
+			commits[i].Identities[j].Signed = signed
+			commits[i].Identities[j].Status = status
+			if !signed {
+				all = false
+			}
+		}
+	}
+	return all
+}
 
-			// add comment
-			body := gitee.PullRequestCommentPostParam{}
-			body.AccessToken = s.Config.GiteeToken
-			body.Body = fmt.Sprintf(claNotFoundMessage, s.Config.ClaLink, s.Config.ContactEmail)
-			owner := event.Repository.Namespace
-			repo := event.Repository.Path
-			number := event.PullRequest.Number
-			_, _, err = s.GiteeClient.PullRequestsApi.PostV5ReposOwnerRepoPullsNumberComments(s.Context, owner, repo, number, body)
-			if err != nil {
-				glog.Errorf("unable to add comment in pull request: %v", err)
-				return err
+// claStatusesOf gets the cla status of emails which signed cla
+func claStatusesOf(commits []claCommit) (map[string]string, error) {
+	emails := make([]string, 0)
+	for _, c := range commits {
+		for _, id := range c.Identities {
+			if id.Email != "" {
+				emails = append(emails, id.Email)
 			}
 		}
 	}
+	statuses := make(map[string]string)
+	if len(emails) == 0 {
+		return statuses, nil
+	}
+	versions, err := claVersions()
+	if err != nil {
+		return nil, err
+	}
+	cds, err := findCLADetailsByEmails(emails)
+	if err != nil {
+		glog.Errorf("failed to check user email: %v", err)
+		return nil, err
+	}
+	for _, cd := range cds {
+		statuses[strings.ToLower(cd.Email)] = claSignatureStatus(cd, versions)
+	}
+	// the emails without individual cla may be covered by corporate agreements
+	unsigned := make([]string, 0)
+	for _, e := range emails {
+		if statuses[e] != claStatusSigned {
+			unsigned = append(unsigned, e)
+		}
+	}
+	covered, err := corporationCoveredEmails(unsigned)
+	if err != nil {
+		return nil, err
+	}
+	for e := range covered {
+		statuses[e] = claStatusSigned
+	}
+	return statuses, nil
+}
+
+// CheckCLAByNoteEvent check cla by NoteEvent
+func (s *Server) CheckCLAByNoteEvent(event *gitee.NoteEvent) error {
+	if *event.NoteableType == "PullRequest" && s.Config.AutoDetectCla {
+		return s.checkCLAOfPullRequest(event.Repository, event.PullRequest)
+	}
 	return nil
 }
 
@@ -116,84 +170,171 @@ func (s *Server) CheckCLAByPullRequestEvent(event *gitee.PullRequestEvent) error
 	if !s.Config.AutoDetectCla {
 		return nil
 	}
-	// check the email from sender
-	var lenEmail int
-	err := database.DBConnection.Model(&database.CLADetails{}).
-		Where("email = ?", event.Sender.Email).Count(&lenEmail).Error
+	return s.checkCLAOfPullRequest(event.Repository, event.PullRequest)
+}
+
+// CheckCLAByPullRequestUpdate checks cla of the new commits of pull request with labels before the check,
+// the result is commented only if the result or the identities of commits changed
+func (s *Server) CheckCLAByPullRequestUpdate(event *gitee.PullRequestEvent, labels []gitee.Label) error {
+	if !s.Config.AutoDetectCla {
+		return nil
+	}
+	owner := event.Repository.Namespace
+	repo := event.Repository.Path
+	number := event.PullRequest.Number
+
+	claCommits, signed, err := s.claOfPullRequest(owner, repo, number)
 	if err != nil {
-		glog.Errorf("failed to check user email: %v", err)
 		return err
 	}
-	if lenEmail > 0 {
-		// add label openeuler-cla/yes
-		addlabel := &gitee.NoteEvent{}
-		addlabel.PullRequest = event.PullRequest
-		addlabel.Repository = event.Repository
-		addlabel.Comment = &gitee.NoteHook{}
-		addlabel.Comment.Body = fmt.Sprintf(AddClaYes, strings.ToLower(s.Config.CommunityName))
-		err = s.AddLabelInPulRequest(addlabel)
-		if err != nil {
-			return err
-		}
+	err = s.setCLALabels(event.Repository, event.PullRequest, signed)
+	if err != nil {
+		return err
+	}
+	community := strings.ToLower(s.Config.CommunityName)
+	if claLabelsUpToDate(labels, fmt.Sprintf(LabelClaYes, community), fmt.Sprintf(LabelClaNo, community), signed) &&
+		lastCLAIdentities(owner, repo, number) == claIdentitiesFingerprint(claCommits) {
+		glog.Infof("cla of %s/%s/%d is not changed", owner, repo, number)
+		return nil
+	}
+	return s.commentCLA(owner, repo, number, claCommits, signed)
+}
 
-		// remove label openeuler-cla/no
-		removelabel := &gitee.NoteEvent{}
-		removelabel.PullRequest = event.PullRequest
-		removelabel.Repository = event.Repository
-		removelabel.Comment = &gitee.NoteHook{}
-		removelabel.Comment.Body = fmt.Sprintf(RemoveClaNo, strings.ToLower(s.Config.CommunityName))
-		err = s.RemoveLabelInPullRequest(removelabel)
-		if err != nil {
-			return err
-		}
+// checkCLAOfPullRequest checks cla of every identity of pull request commits,
+// and labels the pull request with cla yes only if all of them signed
+func (s *Server) checkCLAOfPullRequest(repository *gitee.ProjectHook, pullRequest *gitee.PullRequestHook) error {
+	owner := repository.Namespace
+	repo := repository.Path
+	number := pullRequest.Number
+
+	claCommits, signed, err := s.claOfPullRequest(owner, repo, number)
+	if err != nil {
+		return err
+	}
+	err = s.setCLALabels(repository, pullRequest, signed)
+	if err != nil {
+		return err
+	}
+	return s.commentCLA(owner, repo, number, claCommits, signed)
+}
+
+// claOfPullRequest gets the cla status of pull request commits, and checks whether all of them signed
+func (s *Server) claOfPullRequest(owner, repo string, number int32) ([]claCommit, bool, error) {
+	commitPullRequestOpts := &gitee.GetV5ReposOwnerRepoPullsNumberCommitsOpts{}
+	commitPullRequestOpts.AccessToken = optional.NewString(s.Config.GiteeToken)
+	commits, _, err := s.GiteeClient.PullRequestsApi.GetV5ReposOwnerRepoPullsNumberCommits(
+		s.Context, owner, repo, number, commitPullRequestOpts)
+	if err != nil {
+		glog.Errorf("failed to get pull request commits detail : %v", err)
+		return nil, false, err
+	}
+	claCommits := claCommitsOf(commits, s.Config.ClaExemptEmails)
+	statuses, err := claStatusesOf(claCommits)
+	if err != nil {
+		return nil, false, err
+	}
+	signed := markCLASigned(claCommits, statuses)
+	glog.Infof("cla of %s/%s/%d: %d commits, all signed: %v", owner, repo, number, len(claCommits), signed)
+	return claCommits, signed, nil
+}
+
+// setCLALabels labels the pull request with cla yes or no, and removes the opposite one
+func (s *Server) setCLALabels(repository *gitee.ProjectHook, pullRequest *gitee.PullRequestHook, signed bool) error {
+	addLabel, removeLabel := AddClaNo, RemoveClaYes
+	if signed {
+		addLabel, removeLabel = AddClaYes, RemoveClaNo
+	}
+	// add label openeuler-cla/yes or openeuler-cla/no
+	addlabel := &gitee.NoteEvent{}
+	addlabel.PullRequest = pullRequest
+	addlabel.Repository = repository
+	addlabel.Comment = &gitee.NoteHook{}
+	addlabel.Comment.Body = fmt.Sprintf(addLabel, strings.ToLower(s.Config.CommunityName))
+	err := s.AddLabelInPulRequest(addlabel)
+	if err != nil {
+		return err
+	}
+
+	// remove the opposite label
+	removelabel := &gitee.NoteEvent{}
+	removelabel.PullRequest = pullRequest
+	removelabel.Repository = repository
+	removelabel.Comment = &gitee.NoteHook{}
+	removelabel.Comment.Body = fmt.Sprintf(removeLabel, strings.ToLower(s.Config.CommunityName))
+	return s.RemoveLabelInPullRequest(removelabel)
+}
+
+// commentCLA adds comment with the result of every commit
+func (s *Server) commentCLA(owner, repo string, number int32, claCommits []claCommit, signed bool) error {
+	message := claNotFoundMessage
+	if signed {
+		message = claFoundMessage
+	}
+	err := s.addCommentToPullRequest(owner, repo, s.message(owner, repo, message, MessageData{"Commits": claCommits}), number)
+	if err != nil {
+		return err
+	}
+	recordCLAIdentities(owner, repo, number, claIdentitiesFingerprint(claCommits))
+	return nil
+}
 
-		// add comment
-		body := gitee.PullRequestCommentPostParam{}
-		body.AccessToken = s.Config.GiteeToken
-		body.Body = fmt.Sprintf(claFoundMessage, s.Config.CommunityName)
-		owner := event.Repository.Namespace
-		repo := event.Repository.Path
-		number := event.PullRequest.Number
-		_, _, err = s.GiteeClient.PullRequestsApi.PostV5ReposOwnerRepoPullsNumberComments(s.Context, owner, repo, number, body)
-		if err != nil {
-			glog.Errorf("unable to add comment in pull request: %v", err)
-			return err
+// claIdentitiesFingerprint computes the fingerprint of the identities with their cla status,
+// the shas and subjects are ignored so that it only changes with the identities
+func claIdentitiesFingerprint(commits []claCommit) string {
+	ids := make([]string, 0)
+	for _, c := range commits {
+		if len(c.Identities) == 0 {
+			ids = append(ids, "")
 		}
-	} else {
-		// add label openeuler-cla/no
-		addlabel := &gitee.NoteEvent{}
-		addlabel.PullRequest = event.PullRequest
-		addlabel.Repository = event.Repository
-		addlabel.Comment = &gitee.NoteHook{}
-		addlabel.Comment.Body = fmt.Sprintf(AddClaNo, strings.ToLower(s.Config.CommunityName))
-		err = s.AddLabelInPulRequest(addlabel)
-		if err != nil {
-			return err
+		for _, id := range c.Identities {
+			ids = append(ids, strings.Join([]string{id.Role, id.Name, id.Email, id.Status}, "\x00"))
 		}
-
-		// remove label openeuler-cla/yes
-		removelabel := &gitee.NoteEvent{}
-		removelabel.PullRequest = event.PullRequest
-		removelabel.Repository = event.Repository
-		removelabel.Comment = &gitee.NoteHook{}
-		removelabel.Comment.Body = fmt.Sprintf(RemoveClaYes, strings.ToLower(s.Config.CommunityName))
-		err = s.RemoveLabelInPullRequest(removelabel)
-		if err != nil {
-			return err
+	}
+	sort.Strings(ids)
+	h := sha256.New()
+	for i, id := range ids {
+		if i > 0 && id == ids[i-1] {
+			continue
 		}
+		h.Write([]byte(id))
+		h.Write([]byte{0})
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
 
-		// add comment
-		body := gitee.PullRequestCommentPostParam{}
-		body.AccessToken = s.Config.GiteeToken
-		body.Body = fmt.Sprintf(claNotFoundMessage, s.Config.ClaLink, s.Config.ContactEmail)
-		owner := event.Repository.Namespace
-		repo := event.Repository.Path
-		number := event.PullRequest.Number
-		_, _, err = s.GiteeClient.PullRequestsApi.PostV5ReposOwnerRepoPullsNumberComments(s.Context, owner, repo, number, body)
-		if err != nil {
-			glog.Errorf("unable to add comment in pull request: %v", err)
-			return err
+// lastCLAIdentities gets the fingerprint of cla identities in the last cla comment of pull request
+func lastCLAIdentities(owner, repo string, number int32) string {
+	var fps []database.PrFingerprints
+	err := database.DBConnection.Model(&database.PrFingerprints{}).
+		Where("owner = ? and repo = ? and number = ?", owner, repo, number).Find(&fps).Error
+	if err != nil {
+		glog.Errorf("unable to get pr fingerprint: %v", err)
+		return ""
+	}
+	if len(fps) == 0 {
+		return ""
+	}
+	return fps[0].CLAIdentities
+}
+
+// recordCLAIdentities records the fingerprint of cla identities in the cla comment of pull request
+func recordCLAIdentities(owner, repo string, number int32, identities string) {
+	var fps []database.PrFingerprints
+	err := database.DBConnection.Model(&database.PrFingerprints{}).
+		Where("owner = ? and repo = ? and number = ?", owner, repo, number).Find(&fps).Error
+	if err == nil {
+		if len(fps) > 0 {
+			err = database.DBConnection.Model(&fps[0]).Update("cla_identities", identities).Error
+		} else {
+			err = database.DBConnection.Create(&database.PrFingerprints{
+				Owner:         owner,
+				Repo:          repo,
+				Number:        int(number),
+				CLAIdentities: identities,
+			}).Error
 		}
 	}
-	return nil
+	if err != nil {
+		glog.Errorf("unable to record cla identities of pull request: %v", err)
+	}
 }
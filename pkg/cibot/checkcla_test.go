@@ -0,0 +1,136 @@
+package cibot
+
+import (
+	"reflect"
+	"testing"
+
+	"gitee.com/openeuler/go-gitee/gitee"
+)
+
+func TestClaCommitsOf(t *testing.T) {
+	commits := []gitee.PullRequestCommits{
+		{
+			Sha: "a1",
+			Commit: &gitee.GitCommit{
+				Author:    &gitee.GitUser{Name: "Alice", Email: "Alice@example.com"},
+				Committer: &gitee.GitUser{Name: "Alice", Email: "alice@example.com"},
+				Message:   "fix crash\n\nCo-authored-by: Bob <bob@example.com>\nCo-authored-by: Alice <alice@example.com>",
+			},
+		},
+		{
+			Sha: "b2",
+			Commit: &gitee.GitCommit{
+				Author:    &gitee.GitUser{Name: "Carol", Email: ""},
+				Committer: &gitee.GitUser{Name: "Gitee", Email: "noreply@gitee.com"},
+				Message:   "update readme",
+			},
+		},
+		{
+			Sha: "c3",
+			Commit: &gitee.GitCommit{
+				Author:    &gitee.GitUser{Name: "Gitee", Email: "noreply@gitee.com"},
+				Committer: &gitee.GitUser{Name: "Gitee", Email: "noreply@gitee.com"},
+				Message:   "add license",
+			},
+		},
+	}
+	got := claCommitsOf(commits, []string{"NoReply@gitee.com"})
+	want := []claCommit{
+		{Sha: "a1", Subject: "fix crash", Identities: []claIdentity{
+			{Role: "author", Name: "Alice", Email: "alice@example.com"},
+			{Role: "co-author", Name: "Bob", Email: "bob@example.com"},
+		}},
+		{Sha: "b2", Subject: "update readme", Identities: []claIdentity{
+			{Role: "author", Name: "Carol", Email: ""},
+		}},
+		{Sha: "c3", Subject: "add license", Identities: []claIdentity{
+			{Role: "author", Name: "Gitee", Email: "noreply@gitee.com"},
+		}},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("claCommitsOf() = %+v, want %+v", got, want)
+	}
+
+	if !markCLASigned(got[:1], map[string]string{"alice@example.com": claStatusSigned, "bob@example.com": claStatusSigned}) {
+		t.Errorf("commit signed by all identities is not signed")
+	}
+	if markCLASigned(got[:1], map[string]string{"alice@example.com": claStatusSigned}) || got[0].Identities[1].Signed ||
+		got[0].Identities[1].Status != claStatusUnsigned {
+		t.Errorf("commit with co-author not signed is signed")
+	}
+	if markCLASigned(got[:1], map[string]string{"alice@example.com": claStatusSigned, "bob@example.com": claStatusOutdated}) ||
+		got[0].Identities[1].Status != claStatusOutdated {
+		t.Errorf("commit with co-author signed outdated cla is signed")
+	}
+	if markCLASigned(got[1:], map[string]string{"": claStatusSigned}) {
+		t.Errorf("commit with empty author email is signed")
+	}
+	if markCLASigned([]claCommit{{Sha: "d4"}}, map[string]string{}) {
+		t.Errorf("commit without identities is signed")
+	}
+}
+
+func TestClaCommitsHaveEmail(t *testing.T) {
+	commits := []claCommit{{Sha: "a1", Identities: []claIdentity{
+		{Role: "author", Email: "alice@example.com"}, {Role: "co-author", Email: ""}}}}
+	if !claCommitsHaveEmail(commits, "Alice@Example.com") {
+		t.Errorf("email of author is not found")
+	}
+	if claCommitsHaveEmail(commits, "") || claCommitsHaveEmail(commits, "bob@example.com") {
+		t.Errorf("email not in commits is found")
+	}
+}
+
+func TestMaskEmail(t *testing.T) {
+	tests := map[string]string{
+		"alice@example.com": "a***@example.com",
+		"张三@example.com":    "张***@example.com",
+		"@example.com":      "***",
+		"alice":             "***",
+		"":                  "",
+	}
+	for email, want := range tests {
+		if got := maskEmail(email); got != want {
+			t.Errorf("maskEmail(%q) = %q, want %q", email, got, want)
+		}
+	}
+}
+
+func TestClaIdentitiesFingerprint(t *testing.T) {
+	alice := claIdentity{Role: "author", Name: "Alice", Email: "alice@example.com", Status: claStatusSigned}
+	bob := claIdentity{Role: "co-author", Name: "Bob", Email: "bob@example.com", Status: claStatusUnsigned}
+	before := claIdentitiesFingerprint([]claCommit{{Sha: "a1", Subject: "fix", Identities: []claIdentity{alice}}})
+	tests := []struct {
+		name    string
+		commits []claCommit
+		changed bool
+	}{
+		{"new commit of same author", []claCommit{
+			{Sha: "b1", Subject: "fix", Identities: []claIdentity{alice}},
+			{Sha: "b2", Subject: "fix again", Identities: []claIdentity{alice}}}, false},
+		{"new co-author", []claCommit{{Sha: "b1", Identities: []claIdentity{alice, bob}}}, true},
+		{"status changed", []claCommit{{Sha: "b1", Identities: []claIdentity{
+			{Role: "author", Name: "Alice", Email: "alice@example.com", Status: claStatusOutdated}}}}, true},
+		{"commit without identities", []claCommit{{Sha: "b1", Identities: []claIdentity{alice}}, {Sha: "b2"}}, true},
+	}
+	for _, tt := range tests {
+		if got := claIdentitiesFingerprint(tt.commits) != before; got != tt.changed {
+			t.Errorf("%s: fingerprint changed = %v, want %v", tt.name, got, tt.changed)
+		}
+	}
+}
+
+func TestRecordCLAIdentities(t *testing.T) {
+	defer useFakeDB(t)()
+	if got := lastCLAIdentities("openeuler", "community", 1); got != "" {
+		t.Errorf("lastCLAIdentities() before comment = %q, want empty", got)
+	}
+	recordCLAIdentities("openeuler", "community", 1, "fp1")
+	recordCLAIdentities("openeuler", "community", 1, "fp2")
+	if got := lastCLAIdentities("openeuler", "community", 1); got != "fp2" {
+		t.Errorf("lastCLAIdentities() = %q, want fp2", got)
+	}
+	if got := lastCLAIdentities("openeuler", "community", 2); got != "" {
+		t.Errorf("lastCLAIdentities() of another pull request = %q, want empty", got)
+	}
+}
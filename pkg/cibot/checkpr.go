@@ -5,8 +5,6 @@ import (
 	"github.com/golang/glog"
 )
 
-var checkPrComment = "Cannot use \"/check-pr\", because this command is only used to detect open pull requests"
-
 //CheckPr Check whether the pull request can be merged
 func (s *Server) CheckPr(event *gitee.NoteEvent) (err error) {
 	if *event.NoteableType == "PullRequest" && event.PullRequest.State == "open" {
@@ -18,7 +16,7 @@ func (s *Server) CheckPr(event *gitee.NoteEvent) (err error) {
 		}
 	} else {
 		return s.addCommentToPullRequest(event.Repository.Namespace, event.Repository.Name,
-			checkPrComment, event.PullRequest.Number)
+			s.message(event.Repository.Namespace, event.Repository.Path, checkPrComment, nil), event.PullRequest.Number)
 	}
 	return nil
 }
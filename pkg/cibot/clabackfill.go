@@ -0,0 +1,180 @@
+package cibot
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"path"
+	"strings"
+
+	"gitee.com/openeuler/ci-bot/pkg/cibot/database"
+	"gitee.com/openeuler/go-gitee/gitee"
+	"github.com/antihax/optional"
+	"github.com/golang/glog"
+	"github.com/spf13/pflag"
+)
+
+// CLABackfill checks cla of the open pull requests in org, and fixes their cla labels
+type CLABackfill struct {
+	ConfigFile  string
+	Org         string
+	Repo        string
+	SkipComment bool
+}
+
+// claBackfillSummary is the result of backfill
+type claBackfillSummary struct {
+	Repos    int
+	Signed   int
+	Unsigned int
+	Changed  int
+	Failed   int
+}
+
+func NewCLABackfill() *CLABackfill {
+	return &CLABackfill{
+		ConfigFile: "config.yaml",
+		Repo:       "*",
+	}
+}
+
+func (b *CLABackfill) AddFlags(fs *pflag.FlagSet) {
+	fs.StringVar(&b.ConfigFile, "configfile", b.ConfigFile, "config file.")
+	fs.StringVar(&b.Org, "org", b.Org, "org of the pull requests to check.")
+	fs.StringVar(&b.Repo, "repo", b.Repo, "glob of the repositories to check, all by default.")
+	fs.BoolVar(&b.SkipComment, "skip-comment", b.SkipComment, "fix the labels without commenting.")
+
+	// See https://github.com/spf13/pflag#supporting-go-flags-when-using-pflag
+	fs.AddGoFlagSet(flag.CommandLine)
+	pflag.Parse()
+}
+
+// Run checks the open pull requests, prints the summary and returns the exit code
+func (b *CLABackfill) Run() int {
+	// Flush flushes all pending log I/O.
+	defer glog.Flush()
+
+	if b.Org == "" {
+		fmt.Println("--org is required")
+		return 2
+	}
+	if _, err := path.Match(b.Repo, ""); err != nil {
+		fmt.Printf("invalid --repo %s: %v\n", b.Repo, err)
+		return 2
+	}
+
+	config := loadConfig(b.ConfigFile)
+	ctx := context.Background()
+	s := &Server{
+		Config:      config,
+		Context:     ctx,
+		GiteeClient: newGiteeClient(ctx, config.GiteeToken),
+	}
+	err := database.New(config)
+	if err != nil {
+		fmt.Printf("init back database error: %v\n", err)
+		return 1
+	}
+	err = LoadCLACrypto()
+	if err != nil {
+		fmt.Printf("load cla encryption key error: %v\n", err)
+		return 1
+	}
+
+	var summary claBackfillSummary
+	lvos := &gitee.GetV5OrgsOrgReposOpts{}
+	lvos.AccessToken = optional.NewString(config.GiteeToken)
+	lvos.PerPage = optional.NewInt32(100)
+	for page := int32(1); ; page++ {
+		lvos.Page = optional.NewInt32(page)
+		repos, _, err := s.GiteeClient.RepositoriesApi.GetV5OrgsOrgRepos(ctx, b.Org, lvos)
+		if err != nil {
+			fmt.Printf("unable to list repositories of %s: %v\n", b.Org, err)
+			return 1
+		}
+		for _, r := range repos {
+			if matched, _ := path.Match(b.Repo, r.Path); !matched {
+				continue
+			}
+			summary.Repos++
+			b.backfillRepo(s, r.Path, &summary)
+		}
+		if len(repos) < 100 {
+			break
+		}
+	}
+
+	fmt.Printf("repositories: %d, signed: %d, unsigned: %d, labels changed: %d, failed: %d\n",
+		summary.Repos, summary.Signed, summary.Unsigned, summary.Changed, summary.Failed)
+	if summary.Failed > 0 {
+		return 1
+	}
+	return 0
+}
+
+// backfillRepo checks cla of the open pull requests in repository
+func (b *CLABackfill) backfillRepo(s *Server, repo string, summary *claBackfillSummary) {
+	owner := b.Org
+	community := strings.ToLower(s.Config.CommunityName)
+	yesLabel := fmt.Sprintf(LabelClaYes, community)
+	noLabel := fmt.Sprintf(LabelClaNo, community)
+
+	lvos := &gitee.GetV5ReposOwnerRepoPullsOpts{}
+	lvos.AccessToken = optional.NewString(s.Config.GiteeToken)
+	lvos.State = optional.NewString("open")
+	lvos.PerPage = optional.NewInt32(100)
+	for page := int32(1); ; page++ {
+		lvos.Page = optional.NewInt32(page)
+		prs, _, err := s.GiteeClient.PullRequestsApi.GetV5ReposOwnerRepoPulls(s.Context, owner, repo, lvos)
+		if err != nil {
+			fmt.Printf("%s/%s: unable to list pull requests: %v\n", owner, repo, err)
+			summary.Failed++
+			return
+		}
+		for _, pr := range prs {
+			claCommits, signed, err := s.claOfPullRequest(owner, repo, pr.Number)
+			if err != nil {
+				fmt.Printf("%s/%s/%d: unable to check cla: %v\n", owner, repo, pr.Number, err)
+				summary.Failed++
+				continue
+			}
+			if signed {
+				summary.Signed++
+			} else {
+				summary.Unsigned++
+			}
+			if claLabelsUpToDate(pr.Labels, yesLabel, noLabel, signed) {
+				continue
+			}
+			repository := &gitee.ProjectHook{Namespace: owner, Path: repo, Name: repo}
+			err = s.setCLALabels(repository, pullRequestHookOf(pr), signed)
+			if err == nil && !b.SkipComment {
+				err = s.commentCLA(owner, repo, pr.Number, claCommits, signed)
+			}
+			if err != nil {
+				fmt.Printf("%s/%s/%d: unable to fix cla labels: %v\n", owner, repo, pr.Number, err)
+				summary.Failed++
+				continue
+			}
+			summary.Changed++
+			fmt.Printf("%s/%s/%d: signed: %v\n", owner, repo, pr.Number, signed)
+		}
+		if len(prs) < 100 {
+			break
+		}
+	}
+}
+
+// claLabelsUpToDate checks whether the pull request has only the cla label of result
+func claLabelsUpToDate(labels []gitee.Label, yesLabel, noLabel string, signed bool) bool {
+	hasYes, hasNo := false, false
+	for _, l := range labels {
+		switch l.Name {
+		case yesLabel:
+			hasYes = true
+		case noLabel:
+			hasNo = true
+		}
+	}
+	return hasYes == signed && hasNo == !signed
+}
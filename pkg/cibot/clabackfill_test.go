@@ -0,0 +1,29 @@
+package cibot
+
+import (
+	"testing"
+
+	"gitee.com/openeuler/go-gitee/gitee"
+)
+
+func TestClaLabelsUpToDate(t *testing.T) {
+	yes := gitee.Label{Name: "openeuler-cla/yes"}
+	no := gitee.Label{Name: "openeuler-cla/no"}
+	tests := []struct {
+		name   string
+		labels []gitee.Label
+		signed bool
+		want   bool
+	}{
+		{"signed with yes", []gitee.Label{yes}, true, true},
+		{"signed without label", nil, true, false},
+		{"signed with both", []gitee.Label{yes, no}, true, false},
+		{"unsigned with yes", []gitee.Label{yes}, false, false},
+		{"unsigned with no", []gitee.Label{no}, false, true},
+	}
+	for _, tt := range tests {
+		if got := claLabelsUpToDate(tt.labels, "openeuler-cla/yes", "openeuler-cla/no", tt.signed); got != tt.want {
+			t.Errorf("%s: claLabelsUpToDate() = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,285 @@
+package cibot
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"gitee.com/openeuler/ci-bot/pkg/cibot/database"
+	"github.com/golang/glog"
+)
+
+const (
+	// claEncryptionKeyEnv is the environment variable of key to encrypt the personal data of cla
+	claEncryptionKeyEnv = "CLA_ENCRYPTION_KEY"
+	// claEncryptedPrefix marks the encrypted values, the values without it are plain text before encryption
+	claEncryptedPrefix = "enc:v1:"
+	claKeyMinLength    = 32
+)
+
+// claCrypto encrypts the personal data of cla, and hashes the emails for lookup
+type claCrypto struct {
+	aead    cipher.AEAD
+	hashKey []byte
+}
+
+// claCipher is loaded from environment variable on start
+var claCipher *claCrypto
+
+// newCLACrypto derives the keys of encryption and hash from the key
+func newCLACrypto(key string) (*claCrypto, error) {
+	if len(key) < claKeyMinLength {
+		return nil, fmt.Errorf("%s must have at least %d characters", claEncryptionKeyEnv, claKeyMinLength)
+	}
+	derive := func(purpose string) []byte {
+		mac := hmac.New(sha256.New, []byte(key))
+		mac.Write([]byte(purpose))
+		return mac.Sum(nil)
+	}
+	block, err := aes.NewCipher(derive("encrypt"))
+	if err != nil {
+		return nil, err
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	return &claCrypto{aead: aead, hashKey: derive("hash")}, nil
+}
+
+// LoadCLACrypto loads the key of cla encryption from environment variable
+func LoadCLACrypto() error {
+	c, err := newCLACrypto(os.Getenv(claEncryptionKeyEnv))
+	if err != nil {
+		return err
+	}
+	claCipher = c
+	return nil
+}
+
+// currentCLACrypto gets the loaded cla crypto, the personal data of cla is not accessible without it
+func currentCLACrypto() (*claCrypto, error) {
+	if claCipher == nil {
+		return nil, fmt.Errorf("%s is not set", claEncryptionKeyEnv)
+	}
+	return claCipher, nil
+}
+
+// encrypt encrypts the value with random nonce, the empty value is kept
+func (c *claCrypto) encrypt(value string) (string, error) {
+	if value == "" {
+		return "", nil
+	}
+	nonce := make([]byte, c.aead.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return "", err
+	}
+	sealed := c.aead.Seal(nonce, nonce, []byte(value), nil)
+	return claEncryptedPrefix + base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+// decrypt decrypts the value, the plain text before encryption is kept
+func (c *claCrypto) decrypt(value string) (string, error) {
+	if !strings.HasPrefix(value, claEncryptedPrefix) {
+		return value, nil
+	}
+	sealed, err := base64.StdEncoding.DecodeString(strings.TrimPrefix(value, claEncryptedPrefix))
+	if err != nil {
+		return "", err
+	}
+	if len(sealed) < c.aead.NonceSize() {
+		return "", fmt.Errorf("invalid encrypted value")
+	}
+	nonce, data := sealed[:c.aead.NonceSize()], sealed[c.aead.NonceSize():]
+	plain, err := c.aead.Open(nil, nonce, data, nil)
+	if err != nil {
+		return "", err
+	}
+	return string(plain), nil
+}
+
+// hashEmail gets the deterministic hash of email regardless of case
+func (c *claCrypto) hashEmail(email string) string {
+	mac := hmac.New(sha256.New, c.hashKey)
+	mac.Write([]byte(strings.ToLower(strings.TrimSpace(email))))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// hashEmails gets the hashes of emails
+func (c *claCrypto) hashEmails(emails []string) []string {
+	hashes := make([]string, 0, len(emails))
+	for _, e := range emails {
+		hashes = append(hashes, c.hashEmail(e))
+	}
+	return hashes
+}
+
+// hasEncryptedCLAValue checks whether one of the personal data looks like an encrypted value
+func hasEncryptedCLAValue(cd database.CLADetails) bool {
+	for _, f := range []string{cd.Name, cd.Title, cd.Address, cd.Email, cd.Telephone, cd.Fax} {
+		if strings.HasPrefix(f, claEncryptedPrefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// encryptCLADetails gets the copy of cla details with the personal data encrypted and the email hashed,
+// the values already encrypted are kept, so the values from request must be checked by hasEncryptedCLAValue
+func (c *claCrypto) encryptCLADetails(cd database.CLADetails) (database.CLADetails, error) {
+	fields := []*string{&cd.Name, &cd.Title, &cd.Address, &cd.Email, &cd.Telephone, &cd.Fax}
+	if cd.Email != "" {
+		cd.EmailHash = c.hashEmail(cd.Email)
+	}
+	for _, f := range fields {
+		if strings.HasPrefix(*f, claEncryptedPrefix) {
+			continue
+		}
+		encrypted, err := c.encrypt(*f)
+		if err != nil {
+			return cd, err
+		}
+		*f = encrypted
+	}
+	return cd, nil
+}
+
+// decryptCLADetails gets the copy of cla details with the personal data decrypted
+func (c *claCrypto) decryptCLADetails(cd database.CLADetails) (database.CLADetails, error) {
+	fields := []*string{&cd.Name, &cd.Title, &cd.Address, &cd.Email, &cd.Telephone, &cd.Fax}
+	for _, f := range fields {
+		plain, err := c.decrypt(*f)
+		if err != nil {
+			return cd, err
+		}
+		*f = plain
+	}
+	return cd, nil
+}
+
+// findCLADetailsByEmails finds the cla details of emails by their hashes,
+// the ones which can not be decrypted are skipped
+func findCLADetailsByEmails(emails []string) ([]database.CLADetails, error) {
+	c, err := currentCLACrypto()
+	if err != nil {
+		return nil, err
+	}
+	hashes := c.hashEmails(emails)
+	var cds []database.CLADetails
+	err = database.DBConnection.Where("email_hash in (?)", hashes).Find(&cds).Error
+	if err != nil {
+		return nil, err
+	}
+	result := make([]database.CLADetails, 0, len(cds))
+	for _, cd := range cds {
+		decrypted, err := c.decryptCLADetails(cd)
+		if err != nil {
+			glog.Errorf("unable to decrypt cla details %d: %v", cd.ID, err)
+			continue
+		}
+		result = append(result, decrypted)
+	}
+	return result, nil
+}
+
+// findCorporationEmployeesByEmails finds the corporation employees of emails by their hashes with the emails decrypted,
+// the ones which can not be decrypted are skipped
+func findCorporationEmployeesByEmails(emails []string) ([]database.CorporationEmployees, error) {
+	c, err := currentCLACrypto()
+	if err != nil {
+		return nil, err
+	}
+	var ces []database.CorporationEmployees
+	err = database.DBConnection.Where("email_hash in (?)", c.hashEmails(emails)).Order("corporation").Find(&ces).Error
+	if err != nil {
+		return nil, err
+	}
+	result := make([]database.CorporationEmployees, 0, len(ces))
+	for _, ce := range ces {
+		if ce.Email, err = c.decrypt(ce.Email); err != nil {
+			glog.Errorf("unable to decrypt corporation employee %d: %v", ce.ID, err)
+			continue
+		}
+		result = append(result, ce)
+	}
+	return result, nil
+}
+
+// encryptCorporationEmployee gets the copy of employee with the email encrypted and hashed
+func (c *claCrypto) encryptCorporationEmployee(ce database.CorporationEmployees) (database.CorporationEmployees, error) {
+	if ce.Email == "" || strings.HasPrefix(ce.Email, claEncryptedPrefix) {
+		return ce, nil
+	}
+	ce.EmailHash = c.hashEmail(ce.Email)
+	encrypted, err := c.encrypt(ce.Email)
+	if err != nil {
+		return ce, err
+	}
+	ce.Email = encrypted
+	return ce, nil
+}
+
+// decryptEmployeeEmails gets the decrypted emails of employees, the ones which can not be decrypted are skipped
+func (c *claCrypto) decryptEmployeeEmails(ces []database.CorporationEmployees) []string {
+	emails := make([]string, 0, len(ces))
+	for _, ce := range ces {
+		email, err := c.decrypt(ce.Email)
+		if err != nil {
+			glog.Errorf("unable to decrypt corporation employee %d: %v", ce.ID, err)
+			continue
+		}
+		emails = append(emails, email)
+	}
+	return emails
+}
+
+// EncryptPlainCLADetails encrypts the cla details and the corporation employees stored before encryption
+func EncryptPlainCLADetails() error {
+	c, err := currentCLACrypto()
+	if err != nil {
+		return err
+	}
+	var cds []database.CLADetails
+	err = database.DBConnection.Where("(email_hash IS NULL OR email_hash = '') AND erased_at IS NULL").Find(&cds).Error
+	if err != nil {
+		return err
+	}
+	for _, cd := range cds {
+		encrypted, err := c.encryptCLADetails(cd)
+		if err != nil {
+			return err
+		}
+		err = database.DBConnection.Save(&encrypted).Error
+		if err != nil {
+			return err
+		}
+	}
+	glog.Infof("%d cla details are encrypted", len(cds))
+
+	// the emails of corporation employees are personal data too
+	var ces []database.CorporationEmployees
+	err = database.DBConnection.Unscoped().Where("email_hash IS NULL OR email_hash = ''").Find(&ces).Error
+	if err != nil {
+		return err
+	}
+	for _, ce := range ces {
+		encrypted, err := c.encryptCorporationEmployee(ce)
+		if err != nil {
+			return err
+		}
+		err = database.DBConnection.Unscoped().Save(&encrypted).Error
+		if err != nil {
+			return err
+		}
+	}
+	glog.Infof("%d corporation employees are encrypted", len(ces))
+	return nil
+}
@@ -0,0 +1,64 @@
+package cibot
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"gitee.com/openeuler/ci-bot/pkg/cibot/database"
+)
+
+func TestCLACrypto(t *testing.T) {
+	if _, err := newCLACrypto("short"); err == nil {
+		t.Errorf("short key is accepted")
+	}
+	c, err := newCLACrypto(strings.Repeat("k", claKeyMinLength))
+	if err != nil {
+		t.Fatal(err)
+	}
+	cd := database.CLADetails{Name: "Alice", Email: "Alice@example.com", Telephone: "123", Corporation: "Example"}
+	encrypted, err := c.encryptCLADetails(cd)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(encrypted.Name+encrypted.Email+encrypted.Telephone, "Alice") ||
+		!strings.HasPrefix(encrypted.Email, claEncryptedPrefix) || encrypted.Corporation != "Example" {
+		t.Errorf("personal data is not encrypted: %+v", encrypted)
+	}
+	if encrypted.EmailHash != c.hashEmail("alice@EXAMPLE.com ") {
+		t.Errorf("email hash is not deterministic regardless of case")
+	}
+	again, _ := c.encryptCLADetails(encrypted)
+	if again.Email != encrypted.Email {
+		t.Errorf("encrypted value is encrypted again")
+	}
+	if hasEncryptedCLAValue(cd) || !hasEncryptedCLAValue(database.CLADetails{Fax: claEncryptedPrefix + "x"}) {
+		t.Errorf("values like the encrypted ones are not detected")
+	}
+	decrypted, err := c.decryptCLADetails(encrypted)
+	if err != nil {
+		t.Fatal(err)
+	}
+	decrypted.EmailHash = ""
+	if decrypted != cd {
+		t.Errorf("decryptCLADetails() = %+v, want %+v", decrypted, cd)
+	}
+	if plain, _ := c.decrypt("plain"); plain != "plain" {
+		t.Errorf("plain text before encryption is not kept")
+	}
+}
+
+func TestAnonymizeCLADetails(t *testing.T) {
+	now := time.Now()
+	cd := database.CLADetails{Type: CLATypeCorporation, Name: "Alice", Email: "alice@example.com", EmailHash: "h",
+		Corporation: "Example", Date: "2020-01-01", SignedVersion: "v1"}
+	got := anonymizeCLADetails(cd, now)
+	want := database.CLADetails{Type: CLATypeCorporation, Corporation: "Example", Date: "2020-01-01", SignedVersion: "v1"}
+	if got.ErasedAt == nil || !got.ErasedAt.Equal(now) {
+		t.Errorf("erased time is not set")
+	}
+	got.ErasedAt = nil
+	if got != want {
+		t.Errorf("anonymizeCLADetails() = %+v, want %+v", got, want)
+	}
+}
@@ -7,7 +7,10 @@ import (
 	"io/ioutil"
 	"net/http"
 	"os"
+	"strconv"
+	"time"
 
+	"gitee.com/openeuler/ci-bot/pkg/cibot/config"
 	"gitee.com/openeuler/ci-bot/pkg/cibot/database"
 	"gitee.com/openeuler/go-gitee/gitee"
 	"github.com/golang/glog"
@@ -15,7 +18,9 @@ import (
 )
 
 type CLAHandler struct {
-	Context context.Context
+	Config      config.Config
+	Context     context.Context
+	GiteeClient *gitee.APIClient
 }
 
 type CLARequest struct {
@@ -31,9 +36,10 @@ type CLARequest struct {
 }
 
 type CLAResult struct {
-	IsSuccess   bool   `json:"isSuccess"`
-	ErrorCode   int    `json:"errorCode"`
-	Description string `json:"description,omitempty"`
+	IsSuccess   bool        `json:"isSuccess"`
+	ErrorCode   int         `json:"errorCode"`
+	Description string      `json:"description,omitempty"`
+	Data        interface{} `json:"data,omitempty"`
 }
 
 const (
@@ -42,6 +48,14 @@ const (
 	ErrorCode_EmailError
 	ErrorCode_TelephoneError
 	ErrorCode_EmailNotTheSameError
+	ErrorCode_AuthenticationError
+	ErrorCode_PermissionError
+	ErrorCode_RequestError
+)
+
+const (
+	CLATypeIndividual = iota
+	CLATypeCorporation
 )
 
 const COOKIE_KEY string = "cla-info"
@@ -50,8 +64,7 @@ const COOKIE_KEY string = "cla-info"
 func (s *CLAHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	glog.Info("received a cla request")
 	if r.Method == "POST" {
-		// add logs
-		glog.Infof("CLA Request Header: %v", r.Header)
+		// add logs, the header is not logged for it has the session cookie
 		glog.Infof("CLA Request Host: %v", r.Host)
 		glog.Infof("CLA Request RemoteAddr: %v", r.RemoteAddr)
 
@@ -78,13 +91,20 @@ func (s *CLAHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 			return
 		}
 
-		cookie, err := r.Cookie(COOKIE_KEY)
+		// the session is required, and the csrf token is checked for the signing
+		accesskey, err := s.sessionToken(r)
 		if err != nil {
-			glog.Infof("Get cookie err: %v", err)
+			s.HandleResult(w, CLAResult{
+				IsSuccess:   false,
+				Description: fmt.Sprintf("session error: %v", err),
+				ErrorCode:   ErrorCode_AuthenticationError,
+			})
+			return
 		}
 
-		glog.Infof("cla request content: %v", clarequest)
-		s.HandleRequest(w, clarequest, cookie.Value)
+		// the personal data of request is not logged
+		glog.Infof("cla request of type %d", clarequest.Type)
+		s.HandleRequest(w, clarequest, accesskey)
 	} else if r.Method == "GET" {
 		codes, ok := r.URL.Query()["code"]
 		if !ok || len(codes[0]) <= 0 {
@@ -100,42 +120,43 @@ func (s *CLAHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 
 		s.HandleClaCheck(w, r, code)
 
-	} else if r.Method == "OPTIONS" {
-		// CORS
-		w.Header().Set("Access-Control-Allow-Origin", "*")
-		w.Header().Set("Access-Control-Allow-Headers", "Content-Type")
-		glog.Infof("finish request method: %s", r.Method)
 	} else {
 		glog.Infof("unsupport request method: %s", r.Method)
 	}
 }
 
 func (s *CLAHandler) HandleClaCheck(w http.ResponseWriter, r *http.Request, code string) {
-	accesskey := ""
-	cookie, err := r.Cookie(COOKIE_KEY)
-	if err == nil {
-		accesskey = cookie.Value
-	} else {
-		glog.Infof("Get cookie err: %v", err)
+	// the new code may be authorized by another gitee account, so a new session is always created
+	token, err := GetToken(code)
+	if err != nil {
+		s.HandleResult(w, CLAResult{
+			IsSuccess:   false,
+			Description: fmt.Sprintf("request gitee user error: %v", err),
+			ErrorCode:   ErrorCode_ServerHandleError,
+		})
+		return
 	}
+	accesskey := token.AccessToken
+	glog.Infof("access key get successfully.")
 
-	if accesskey == "" {
-
-		token, err := GetToken(code)
-
+	// the previous session is replaced
+	if session, err := currentSession(r); err == nil {
+		err = database.DBConnection.Unscoped().Delete(&session).Error
 		if err != nil {
-			s.HandleResult(w, CLAResult{
-				IsSuccess:   false,
-				Description: fmt.Sprintf("request gitee user error: %v", err),
-				ErrorCode:   ErrorCode_ServerHandleError,
-			})
-			return
+			glog.Errorf("remove previous cla session error: %v", err)
 		}
-		accesskey = token.AccessToken
-		glog.Infof("access key get successfully.")
 	}
 
-	setCookie(w, COOKIE_KEY, accesskey)
+	// the access token is kept in the server side session
+	err = s.createSession(w, accesskey)
+	if err != nil {
+		s.HandleResult(w, CLAResult{
+			IsSuccess:   false,
+			Description: fmt.Sprintf("create session error: %v", err),
+			ErrorCode:   ErrorCode_ServerHandleError,
+		})
+		return
+	}
 
 	emails, err := GetEmails(accesskey)
 	if err != nil {
@@ -147,54 +168,36 @@ func (s *CLAHandler) HandleClaCheck(w http.ResponseWriter, r *http.Request, code
 		return
 	}
 
-	primaryEmail := ""
-
-	for _, email := range emails {
-		if email.State == "confirmed" {
-			for _, t := range email.Scope {
-				if t == "primary" {
-					primaryEmail = email.Email
-					break
-				}
-			}
-		}
-	}
+	primaryEmail := primaryEmailOf(emails)
 
 	redirectUrl := os.Getenv("CLA_REDIRECT_URL")
 	if len(redirectUrl) == 0 {
 		redirectUrl = "/en/cla.html"
 	}
 
+	// the personal data is not in cookies, the page gets it by GET /cla/signature
 	if primaryEmail != "" {
-
-		setCookie(w, "email", primaryEmail)
-
-		var clainfo database.CLADetails
-		err = database.DBConnection.Model(&database.CLADetails{}).
-			Where("email = ?", primaryEmail).First(&clainfo).Error
-		if err == nil && clainfo.Email != "" {
-
-			setCookie(w, "name", clainfo.Name)
-			setCookie(w, "type", fmt.Sprintf("%d", clainfo.Type))
-			setCookie(w, "title", clainfo.Title)
-			setCookie(w, "corporation", clainfo.Corporation)
-			setCookie(w, "address", clainfo.Address)
-			setCookie(w, "date", clainfo.Date)
-			setCookie(w, "email", clainfo.Email)
-			setCookie(w, "telephone", clainfo.Telephone)
-			setCookie(w, "fax", clainfo.Fax)
-
-			http.Redirect(w, r, redirectUrl, http.StatusFound)
-			return
+		cds, err := findCLADetailsByEmails([]string{primaryEmail})
+		if err != nil {
+			glog.Errorf("find cla details error: %v", err)
 		}
+		s.setCookie(w, "signed", strconv.FormatBool(err == nil && len(cds) > 0), false)
 	}
 
 	http.Redirect(w, r, redirectUrl, http.StatusFound)
 
 }
 
-func setCookie(w http.ResponseWriter, key string, value string) {
-	cookie := http.Cookie{Name: key, Value: value, Path: "/", MaxAge: 86400}
+func (s *CLAHandler) setCookie(w http.ResponseWriter, key string, value string, httpOnly bool) {
+	cookie := http.Cookie{
+		Name:     key,
+		Value:    value,
+		Path:     "/",
+		MaxAge:   s.sessionHours() * 3600,
+		HttpOnly: httpOnly,
+		Secure:   !s.Config.ClaSecurity.InsecureCookie,
+		SameSite: sameSiteOf(s.Config.ClaSecurity.SameSite),
+	}
 	http.SetCookie(w, &cookie)
 }
 
@@ -213,14 +216,18 @@ func (s *CLAHandler) HandleResult(w http.ResponseWriter, r CLAResult) {
 		return
 	}
 
-	// CORS
-	w.Header().Set("Access-Control-Allow-Origin", "*")
-	w.Header().Set("Access-Control-Allow-Headers", "Content-Type")
 	// Content type
 	w.Header().Set("Content-Type", "application/json; charset=utf-8")
-	if r.IsSuccess {
+	switch {
+	case r.IsSuccess:
 		w.WriteHeader(http.StatusOK)
-	} else {
+	case r.ErrorCode == ErrorCode_AuthenticationError:
+		w.WriteHeader(http.StatusUnauthorized)
+	case r.ErrorCode == ErrorCode_PermissionError:
+		w.WriteHeader(http.StatusForbidden)
+	case r.ErrorCode == ErrorCode_RequestError:
+		w.WriteHeader(http.StatusBadRequest)
+	default:
 		w.WriteHeader(http.StatusInternalServerError)
 	}
 	w.Write([]byte(result))
@@ -229,8 +236,6 @@ func (s *CLAHandler) HandleResult(w http.ResponseWriter, r CLAResult) {
 // HandleRequest handles the cla request
 func (s *CLAHandler) HandleRequest(w http.ResponseWriter, request CLARequest, accesskey string) {
 	// build model object
-	setCookie(w, COOKIE_KEY, accesskey)
-
 	emails, err := GetEmails(accesskey)
 	if err != nil {
 		s.HandleResult(w, CLAResult{
@@ -241,18 +246,7 @@ func (s *CLAHandler) HandleRequest(w http.ResponseWriter, request CLARequest, ac
 		return
 	}
 
-	primaryEmail := ""
-
-	for _, email := range emails {
-		if email.State == "confirmed" {
-			for _, t := range email.Scope {
-				if t == "primary" {
-					primaryEmail = email.Email
-					break
-				}
-			}
-		}
-	}
+	primaryEmail := primaryEmailOf(emails)
 
 	if primaryEmail == "" || primaryEmail != *request.Email {
 		s.HandleResult(w, CLAResult{
@@ -291,6 +285,16 @@ func (s *CLAHandler) HandleRequest(w http.ResponseWriter, request CLARequest, ac
 		cds.Fax = *request.Fax
 	}
 
+	// the values like the encrypted ones would be stored as they are
+	if hasEncryptedCLAValue(cds) {
+		s.HandleResult(w, CLAResult{
+			IsSuccess:   false,
+			Description: fmt.Sprintf("request parameter error: the values can not start with %s", claEncryptedPrefix),
+			ErrorCode:   ErrorCode_RequestError,
+		})
+		return
+	}
+
 	// tostring
 	_, err = cds.ToString()
 	if err != nil {
@@ -304,25 +308,39 @@ func (s *CLAHandler) HandleRequest(w http.ResponseWriter, request CLARequest, ac
 
 
 	// Check email in database
-	var lenEmail int
-	err = database.DBConnection.Model(&database.CLADetails{}).
-		Where("email = ?", cds.Email).Count(&lenEmail).Error
+	versions, err := claVersions()
 	if err != nil {
 		s.HandleResult(w, CLAResult{
 			IsSuccess:   false,
-			Description: fmt.Sprintf("check email exitency error: %v", err),
+			Description: fmt.Sprintf("get cla versions error: %v", err),
 			ErrorCode:   ErrorCode_ServerHandleError,
 		})
 		return
 	}
-	if lenEmail > 0 {
+	cds.SignedVersion = currentCLAVersion(versions)
+	existing, err := findCLADetailsByEmails([]string{cds.Email})
+	if err != nil {
 		s.HandleResult(w, CLAResult{
 			IsSuccess:   false,
-			Description: "email is already registered",
-			ErrorCode:   ErrorCode_EmailError,
+			Description: fmt.Sprintf("check email exitency error: %v", err),
+			ErrorCode:   ErrorCode_ServerHandleError,
 		})
 		return
 	}
+	if len(existing) > 0 {
+		// the outdated or revoked signature is signed again with the current version
+		if claSignatureStatus(existing[0], versions) == claStatusSigned {
+			s.HandleResult(w, CLAResult{
+				IsSuccess:   false,
+				Description: "email is already registered",
+				ErrorCode:   ErrorCode_EmailError,
+			})
+			return
+		}
+		cds.Model = existing[0].Model
+		// the signature is signed at now instead of the first time
+		cds.CreatedAt = time.Now()
+	}
 
 	/* Check telephone in database
 	var lenTelephone int
@@ -345,8 +363,15 @@ func (s *CLAHandler) HandleRequest(w http.ResponseWriter, request CLARequest, ac
 		return
 	}*/
 
-	// add cla in database
-	err = database.DBConnection.Create(&cds).Error
+	// add cla in database with the personal data encrypted
+	c, err := currentCLACrypto()
+	if err == nil {
+		var encrypted database.CLADetails
+		encrypted, err = c.encryptCLADetails(cds)
+		if err == nil {
+			err = database.DBConnection.Save(&encrypted).Error
+		}
+	}
 	if err != nil {
 		s.HandleResult(w, CLAResult{
 			IsSuccess:   false,
@@ -356,6 +381,23 @@ func (s *CLAHandler) HandleRequest(w http.ResponseWriter, request CLARequest, ac
 		return
 	}
 
+	// the corporate agreement covers the employees once cla admins activate it
+	if cds.Type == CLATypeCorporation && cds.Corporation != "" {
+		err = ensureCorporationCLA(cds.Corporation)
+		if err != nil {
+			glog.Errorf("add corporation cla of %s error: %v", cds.Corporation, err)
+		}
+	}
+
+	// the open pull requests of signer need not be checked again by hand
+	login := ""
+	if user, err := GetUser(accesskey); err == nil {
+		login = user.Login
+	} else {
+		glog.Errorf("get gitee user of signer error: %v", err)
+	}
+	go s.recheckPullRequestsOfSigner(login, cds.Email)
+
 	// constuct result
 	s.HandleResult(w, CLAResult{
 		IsSuccess: true,
@@ -363,6 +405,37 @@ func (s *CLAHandler) HandleRequest(w http.ResponseWriter, request CLARequest, ac
 	})
 }
 
+// primaryEmailOf gets the confirmed primary email of gitee user
+func primaryEmailOf(emails []gitee.Email) string {
+	for _, email := range emails {
+		if email.State == "confirmed" {
+			for _, t := range email.Scope {
+				if t == "primary" {
+					return email.Email
+				}
+			}
+		}
+	}
+	return ""
+}
+
+// authenticatedEmail gets the primary email of gitee user authorized by the cla page
+func (s *CLAHandler) authenticatedEmail(r *http.Request) (string, error) {
+	accesskey, err := s.sessionToken(r)
+	if err != nil {
+		return "", err
+	}
+	emails, err := GetEmails(accesskey)
+	if err != nil {
+		return "", err
+	}
+	primaryEmail := primaryEmailOf(emails)
+	if primaryEmail == "" {
+		return "", fmt.Errorf("no confirmed primary email")
+	}
+	return primaryEmail, nil
+}
+
 func GetUser(ak string) (gitee.User, error) {
 
 	ctx2 := context.Background()
@@ -0,0 +1,92 @@
+package cibot
+
+import (
+	"fmt"
+	"strings"
+
+	"gitee.com/openeuler/ci-bot/pkg/cibot/database"
+	"gitee.com/openeuler/go-gitee/gitee"
+	"github.com/antihax/optional"
+	"github.com/golang/glog"
+)
+
+func (s *CLAHandler) server() *Server {
+	return &Server{
+		Config:      s.Config,
+		Context:     s.Context,
+		GiteeClient: s.GiteeClient,
+	}
+}
+
+// claCommitsHaveEmail checks whether the email is one of the identities of commits
+func claCommitsHaveEmail(commits []claCommit, email string) bool {
+	email = strings.ToLower(email)
+	for _, c := range commits {
+		for _, id := range c.Identities {
+			if id.Email != "" && id.Email == email {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// recheckPullRequestsOfSigner checks cla again for the open pull requests labelled cla no in the repositories,
+// whose author is the signer or whose commits are authored, committed or co-authored by the email of signer
+func (s *CLAHandler) recheckPullRequestsOfSigner(login, email string) {
+	if !s.Config.AutoDetectCla || s.GiteeClient == nil {
+		return
+	}
+	server := s.server()
+	var rs []database.Repositories
+	err := database.DBConnection.Find(&rs).Error
+	if err != nil {
+		glog.Errorf("unable to get repositories: %v", err)
+		return
+	}
+
+	lvos := &gitee.GetV5ReposOwnerRepoPullsOpts{}
+	lvos.AccessToken = optional.NewString(s.Config.GiteeToken)
+	lvos.State = optional.NewString("open")
+	lvos.Labels = optional.NewString(fmt.Sprintf(LabelClaNo, strings.ToLower(s.Config.CommunityName)))
+	lvos.PerPage = optional.NewInt32(100)
+	rechecked := 0
+	for _, r := range rs {
+		for page := int32(1); ; page++ {
+			lvos.Page = optional.NewInt32(page)
+			prs, _, err := s.GiteeClient.PullRequestsApi.GetV5ReposOwnerRepoPulls(s.Context, r.Owner, r.Repo, lvos)
+			if err != nil {
+				glog.Errorf("unable to list pull requests of %s/%s: %v", r.Owner, r.Repo, err)
+				break
+			}
+			for _, pr := range prs {
+				matched := login != "" && pr.User != nil && strings.EqualFold(pr.User.Login, login)
+				if !matched {
+					commitPullRequestOpts := &gitee.GetV5ReposOwnerRepoPullsNumberCommitsOpts{}
+					commitPullRequestOpts.AccessToken = optional.NewString(s.Config.GiteeToken)
+					commits, _, err := s.GiteeClient.PullRequestsApi.GetV5ReposOwnerRepoPullsNumberCommits(
+						s.Context, r.Owner, r.Repo, pr.Number, commitPullRequestOpts)
+					if err != nil {
+						glog.Errorf("unable to get commits of %s/%s/%d: %v", r.Owner, r.Repo, pr.Number, err)
+						continue
+					}
+					matched = claCommitsHaveEmail(claCommitsOf(commits, nil), email)
+				}
+				if !matched {
+					continue
+				}
+				repository := &gitee.ProjectHook{Namespace: r.Owner, Path: r.Repo, Name: r.Repo}
+				err = server.checkCLAOfPullRequest(repository, pullRequestHookOf(pr))
+				if err != nil {
+					glog.Errorf("unable to check cla of %s/%s/%d: %v", r.Owner, r.Repo, pr.Number, err)
+					continue
+				}
+				rechecked++
+			}
+			if len(prs) < 100 {
+				break
+			}
+		}
+	}
+	glog.Infof("cla of %d pull requests are checked again for %s", rechecked, email)
+}
@@ -0,0 +1,362 @@
+package cibot
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"gitee.com/openeuler/ci-bot/pkg/cibot/database"
+	"github.com/golang/glog"
+	"github.com/spf13/pflag"
+)
+
+const (
+	claTypeNameIndividual  = "individual"
+	claTypeNameCorporation = "corporation"
+
+	claReportDateLayout = "2006-01-02"
+	claReportFormatCSV  = "csv"
+)
+
+// claReportFilter filters the signatures by type, corporation and signing date
+type claReportFilter struct {
+	Type        int
+	HasType     bool
+	Corporation string
+	From        time.Time
+	To          time.Time
+}
+
+// claMonthCount is the number of signatures of a month
+type claMonthCount struct {
+	Month       string `json:"month"`
+	Individual  int    `json:"individual"`
+	Corporation int    `json:"corporation"`
+}
+
+// claCorporationCount is the number of signatures of a corporation
+type claCorporationCount struct {
+	Corporation string `json:"corporation"`
+	Count       int    `json:"count"`
+}
+
+// claReportCounts is the aggregate counts of signatures
+type claReportCounts struct {
+	Months       []claMonthCount       `json:"months"`
+	Corporations []claCorporationCount `json:"corporations"`
+}
+
+// claTypeName gets the name of cla type
+func claTypeName(t int) string {
+	if t == CLATypeCorporation {
+		return claTypeNameCorporation
+	}
+	return claTypeNameIndividual
+}
+
+// parseCLAReportFilter parses the type, corporation and date range, the date range includes to
+func parseCLAReportFilter(typ, corporation, from, to string) (claReportFilter, error) {
+	f := claReportFilter{Corporation: strings.TrimSpace(corporation)}
+	switch strings.ToLower(typ) {
+	case "":
+	case claTypeNameIndividual:
+		f.Type, f.HasType = CLATypeIndividual, true
+	case claTypeNameCorporation:
+		f.Type, f.HasType = CLATypeCorporation, true
+	default:
+		return f, fmt.Errorf("invalid type %s, individual or corporation", typ)
+	}
+	var err error
+	if from != "" {
+		if f.From, err = time.ParseInLocation(claReportDateLayout, from, time.Local); err != nil {
+			return f, fmt.Errorf("invalid from %s: %v", from, err)
+		}
+	}
+	if to != "" {
+		if f.To, err = time.ParseInLocation(claReportDateLayout, to, time.Local); err != nil {
+			return f, fmt.Errorf("invalid to %s: %v", to, err)
+		}
+		f.To = f.To.AddDate(0, 0, 1)
+	}
+	return f, nil
+}
+
+// redactCLASignature removes the personal data from signature
+func redactCLASignature(sig CLASignature) CLASignature {
+	sig.Name = ""
+	sig.Title = ""
+	sig.Address = ""
+	sig.Email = ""
+	sig.Telephone = ""
+	sig.Fax = ""
+	return sig
+}
+
+// listCLASignatures lists the signatures filtered, the personal data is decrypted only if pii is true
+func listCLASignatures(f claReportFilter, pii bool) ([]CLASignature, error) {
+	db := database.DBConnection.Model(&database.CLADetails{})
+	if f.HasType {
+		db = db.Where("type = ?", f.Type)
+	}
+	if f.Corporation != "" {
+		db = db.Where("corporation = ?", f.Corporation)
+	}
+	if !f.From.IsZero() {
+		db = db.Where("created_at >= ?", f.From)
+	}
+	if !f.To.IsZero() {
+		db = db.Where("created_at < ?", f.To)
+	}
+	var cds []database.CLADetails
+	err := db.Order("created_at").Find(&cds).Error
+	if err != nil {
+		return nil, err
+	}
+	var c *claCrypto
+	if pii {
+		if c, err = currentCLACrypto(); err != nil {
+			return nil, err
+		}
+	}
+	signatures := make([]CLASignature, 0, len(cds))
+	for _, cd := range cds {
+		if !pii {
+			signatures = append(signatures, redactCLASignature(claSignatureOf(cd)))
+			continue
+		}
+		decrypted, err := c.decryptCLADetails(cd)
+		if err != nil {
+			// the signature is still reported without the personal data
+			glog.Errorf("unable to decrypt cla details %d: %v", cd.ID, err)
+			signatures = append(signatures, redactCLASignature(claSignatureOf(cd)))
+			continue
+		}
+		signatures = append(signatures, claSignatureOf(decrypted))
+	}
+	return signatures, nil
+}
+
+// countCLASignatures counts the signatures per month and type, and per corporation
+func countCLASignatures(signatures []CLASignature) claReportCounts {
+	months := make(map[string]*claMonthCount)
+	corporations := make(map[string]int)
+	for _, sig := range signatures {
+		month := sig.SignedAt.Local().Format("2006-01")
+		if months[month] == nil {
+			months[month] = &claMonthCount{Month: month}
+		}
+		if sig.Type == CLATypeCorporation {
+			months[month].Corporation++
+			corporations[sig.Corporation]++
+		} else {
+			months[month].Individual++
+		}
+	}
+	counts := claReportCounts{Months: make([]claMonthCount, 0, len(months)), Corporations: make([]claCorporationCount, 0, len(corporations))}
+	for _, m := range months {
+		counts.Months = append(counts.Months, *m)
+	}
+	sort.Slice(counts.Months, func(i, j int) bool { return counts.Months[i].Month < counts.Months[j].Month })
+	for c, n := range corporations {
+		counts.Corporations = append(counts.Corporations, claCorporationCount{Corporation: c, Count: n})
+	}
+	sort.Slice(counts.Corporations, func(i, j int) bool {
+		return counts.Corporations[i].Corporation < counts.Corporations[j].Corporation
+	})
+	return counts
+}
+
+// csvSafeCell prefixes the cell starting like a formula with ', which spreadsheets would evaluate
+func csvSafeCell(cell string) string {
+	if cell != "" && strings.ContainsAny(cell[:1], "=+-@\t\r") {
+		return "'" + cell
+	}
+	return cell
+}
+
+// writeCLASignaturesCSV writes the signatures in csv, with the columns of personal data only if pii is true
+func writeCLASignaturesCSV(w io.Writer, signatures []CLASignature, pii bool) error {
+	formatTime := func(t *time.Time) string {
+		if t == nil {
+			return ""
+		}
+		return t.Format(time.RFC3339)
+	}
+	header := []string{"type", "corporation", "date", "signed_version", "signed_at", "revoked_at", "erased_at"}
+	if pii {
+		header = append(header, "name", "title", "address", "email", "telephone", "fax")
+	}
+	cw := csv.NewWriter(w)
+	if err := cw.Write(header); err != nil {
+		return err
+	}
+	for _, sig := range signatures {
+		record := []string{claTypeName(sig.Type), sig.Corporation, sig.Date, sig.SignedVersion,
+			formatTime(&sig.SignedAt), formatTime(sig.RevokedAt), formatTime(sig.ErasedAt)}
+		if pii {
+			record = append(record, sig.Name, sig.Title, sig.Address, sig.Email, sig.Telephone, sig.Fax)
+		}
+		for i := range record {
+			record[i] = csvSafeCell(record[i])
+		}
+		if err := cw.Write(record); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// authenticateReporter gets the email of cla admin or viewer, only the admins can see the personal data
+func (s *CLAHandler) authenticateReporter(w http.ResponseWriter, r *http.Request) (string, bool, bool) {
+	email, err := s.authenticatedEmail(r)
+	if err != nil {
+		s.HandleResult(w, CLAResult{
+			Description: fmt.Sprintf("authenticate error: %v", err),
+			ErrorCode:   ErrorCode_AuthenticationError,
+		})
+		return "", false, false
+	}
+	if s.isCLAAdmin(email) {
+		return email, true, true
+	}
+	if containsUser(s.Config.ClaViewers, email) {
+		return email, false, true
+	}
+	s.HandleResult(w, CLAResult{
+		Description: fmt.Sprintf("%s is not cla admin or viewer", email),
+		ErrorCode:   ErrorCode_PermissionError,
+	})
+	return "", false, false
+}
+
+// reportSignatures lists the signatures filtered by query
+func (s *CLAHandler) reportSignatures(w http.ResponseWriter, r *http.Request) ([]CLASignature, bool, bool) {
+	email, pii, ok := s.authenticateReporter(w, r)
+	if !ok {
+		return nil, false, false
+	}
+	query := r.URL.Query()
+	f, err := parseCLAReportFilter(query.Get("type"), query.Get("corporation"), query.Get("from"), query.Get("to"))
+	if err != nil {
+		s.HandleResult(w, CLAResult{
+			Description: err.Error(),
+			ErrorCode:   ErrorCode_RequestError,
+		})
+		return nil, false, false
+	}
+	signatures, err := listCLASignatures(f, pii)
+	if err != nil {
+		s.HandleResult(w, CLAResult{
+			Description: fmt.Sprintf("list cla signatures error: %v", err),
+			ErrorCode:   ErrorCode_ServerHandleError,
+		})
+		return nil, false, false
+	}
+	glog.Infof("%d cla signatures are reported to %s, personal data: %v", len(signatures), email, pii)
+	return signatures, pii, true
+}
+
+// ServeCLAReportSignatures lists the signatures in json or csv for cla admins and viewers
+func (s *CLAHandler) ServeCLAReportSignatures(w http.ResponseWriter, r *http.Request) {
+	signatures, pii, ok := s.reportSignatures(w, r)
+	if !ok {
+		return
+	}
+	if r.URL.Query().Get("format") != claReportFormatCSV {
+		s.HandleResult(w, CLAResult{IsSuccess: true, ErrorCode: ErrorCode_OK, Data: signatures})
+		return
+	}
+	w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+	w.Header().Set("Content-Disposition", `attachment; filename="cla-signatures.csv"`)
+	if err := writeCLASignaturesCSV(w, signatures, pii); err != nil {
+		glog.Errorf("write cla signatures csv error: %v", err)
+	}
+}
+
+// ServeCLAReportCounts counts the signatures per month and corporation for cla admins and viewers
+func (s *CLAHandler) ServeCLAReportCounts(w http.ResponseWriter, r *http.Request) {
+	signatures, _, ok := s.reportSignatures(w, r)
+	if !ok {
+		return
+	}
+	s.HandleResult(w, CLAResult{IsSuccess: true, ErrorCode: ErrorCode_OK, Data: countCLASignatures(signatures)})
+}
+
+// CLAReport prints the signatures or their counts
+type CLAReport struct {
+	ConfigFile  string
+	Type        string
+	Corporation string
+	From        string
+	To          string
+	Counts      bool
+	PII         bool
+}
+
+func NewCLAReport() *CLAReport {
+	return &CLAReport{
+		ConfigFile: "config.yaml",
+	}
+}
+
+func (c *CLAReport) AddFlags(fs *pflag.FlagSet) {
+	fs.StringVar(&c.ConfigFile, "configfile", c.ConfigFile, "config file.")
+	fs.StringVar(&c.Type, "type", c.Type, "individual or corporation, all by default.")
+	fs.StringVar(&c.Corporation, "corporation", c.Corporation, "corporation of the signatures.")
+	fs.StringVar(&c.From, "from", c.From, "signed on or after the date, e.g. 2020-01-01.")
+	fs.StringVar(&c.To, "to", c.To, "signed on or before the date, e.g. 2020-12-31.")
+	fs.BoolVar(&c.Counts, "counts", c.Counts, "print the counts per month and corporation in json instead of csv.")
+	fs.BoolVar(&c.PII, "pii", c.PII, "include the personal data in csv.")
+
+	// See https://github.com/spf13/pflag#supporting-go-flags-when-using-pflag
+	fs.AddGoFlagSet(flag.CommandLine)
+	pflag.Parse()
+}
+
+// Run prints the report and returns the exit code
+func (c *CLAReport) Run() int {
+	// Flush flushes all pending log I/O.
+	defer glog.Flush()
+
+	f, err := parseCLAReportFilter(c.Type, c.Corporation, c.From, c.To)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 2
+	}
+	config := loadConfig(c.ConfigFile)
+	if err = database.New(config); err != nil {
+		fmt.Fprintf(os.Stderr, "init back database error: %v\n", err)
+		return 1
+	}
+	if c.PII {
+		if err = LoadCLACrypto(); err != nil {
+			fmt.Fprintf(os.Stderr, "load cla encryption key error: %v\n", err)
+			return 1
+		}
+	}
+	signatures, err := listCLASignatures(f, c.PII)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "list cla signatures error: %v\n", err)
+		return 1
+	}
+	if c.Counts {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		err = enc.Encode(countCLASignatures(signatures))
+	} else {
+		err = writeCLASignaturesCSV(os.Stdout, signatures, c.PII)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "write report error: %v\n", err)
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,94 @@
+package cibot
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseCLAReportFilter(t *testing.T) {
+	f, err := parseCLAReportFilter("Corporation", " Example ", "2020-01-01", "2020-01-31")
+	if err != nil {
+		t.Fatalf("parseCLAReportFilter error: %v", err)
+	}
+	if !f.HasType || f.Type != CLATypeCorporation || f.Corporation != "Example" {
+		t.Errorf("parseCLAReportFilter type and corporation = %+v", f)
+	}
+	if want := time.Date(2020, 2, 1, 0, 0, 0, 0, time.Local); !f.To.Equal(want) {
+		t.Errorf("parseCLAReportFilter to = %v, want %v", f.To, want)
+	}
+
+	f, err = parseCLAReportFilter("", "", "", "")
+	if err != nil || f.HasType || !f.From.IsZero() || !f.To.IsZero() {
+		t.Errorf("parseCLAReportFilter empty = %+v, %v", f, err)
+	}
+	for _, args := range [][4]string{{"team", "", "", ""}, {"", "", "2020/01/01", ""}, {"", "", "", "31-01-2020"}} {
+		if _, err = parseCLAReportFilter(args[0], args[1], args[2], args[3]); err == nil {
+			t.Errorf("parseCLAReportFilter(%q) should fail", args)
+		}
+	}
+}
+
+func TestCountCLASignatures(t *testing.T) {
+	jan := time.Date(2020, 1, 10, 0, 0, 0, 0, time.Local)
+	feb := time.Date(2020, 2, 10, 0, 0, 0, 0, time.Local)
+	signatures := []CLASignature{
+		{Type: CLATypeIndividual, SignedAt: jan},
+		{Type: CLATypeCorporation, Corporation: "B", SignedAt: jan},
+		{Type: CLATypeCorporation, Corporation: "A", SignedAt: feb},
+		{Type: CLATypeCorporation, Corporation: "B", SignedAt: feb},
+	}
+	want := claReportCounts{
+		Months: []claMonthCount{
+			{Month: "2020-01", Individual: 1, Corporation: 1},
+			{Month: "2020-02", Corporation: 2},
+		},
+		Corporations: []claCorporationCount{{"A", 1}, {"B", 2}},
+	}
+	if got := countCLASignatures(signatures); !reflect.DeepEqual(got, want) {
+		t.Errorf("countCLASignatures = %+v, want %+v", got, want)
+	}
+}
+
+func TestWriteCLASignaturesCSV(t *testing.T) {
+	signedAt := time.Date(2020, 1, 10, 0, 0, 0, 0, time.UTC)
+	sig := CLASignature{Type: CLATypeCorporation, Name: "Alice", Corporation: "Example", Email: "alice@example.com", SignedAt: signedAt}
+
+	var buf bytes.Buffer
+	if err := writeCLASignaturesCSV(&buf, []CLASignature{redactCLASignature(sig)}, false); err != nil {
+		t.Fatalf("writeCLASignaturesCSV error: %v", err)
+	}
+	want := "type,corporation,date,signed_version,signed_at,revoked_at,erased_at\n" +
+		"corporation,Example,,,2020-01-10T00:00:00Z,,\n"
+	if buf.String() != want {
+		t.Errorf("writeCLASignaturesCSV without pii = %q, want %q", buf.String(), want)
+	}
+
+	buf.Reset()
+	if err := writeCLASignaturesCSV(&buf, []CLASignature{sig}, true); err != nil {
+		t.Fatalf("writeCLASignaturesCSV error: %v", err)
+	}
+	if !strings.Contains(buf.String(), ",email,") || !strings.Contains(buf.String(), "alice@example.com") {
+		t.Errorf("writeCLASignaturesCSV with pii = %q", buf.String())
+	}
+}
+
+func TestCSVSafeCell(t *testing.T) {
+	tests := map[string]string{
+		"":                  "",
+		"Example":           "Example",
+		"=HYPERLINK(\"x\")": "'=HYPERLINK(\"x\")",
+		"+1 555":            "'+1 555",
+		"-2":                "'-2",
+		"@SUM(A1)":          "'@SUM(A1)",
+		"\tcmd":             "'\tcmd",
+		"a=b":               "a=b",
+	}
+	for cell, want := range tests {
+		if got := csvSafeCell(cell); got != want {
+			t.Errorf("csvSafeCell(%q) = %q, want %q", cell, got, want)
+		}
+	}
+}
@@ -0,0 +1,187 @@
+package cibot
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"gitee.com/openeuler/ci-bot/pkg/cibot/database"
+	"github.com/golang/glog"
+)
+
+const (
+	// CSRF_COOKIE_KEY is readable by the cla page on the same site, which sends it back in CSRF_HEADER_KEY,
+	// the pages on other allowed origins get it by GET /cla/session
+	CSRF_COOKIE_KEY string = "cla-csrf"
+	CSRF_HEADER_KEY string = "X-CSRF-Token"
+
+	defaultSessionHours = 24
+)
+
+// randomToken gets the random hex token
+func randomToken() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// hashSessionID gets the hash of session id stored in database
+func hashSessionID(id string) string {
+	sum := sha256.Sum256([]byte(id))
+	return hex.EncodeToString(sum[:])
+}
+
+// sameSiteOf converts the config to SameSite of cookie, lax by default
+func sameSiteOf(sameSite string) http.SameSite {
+	switch strings.ToLower(sameSite) {
+	case "strict":
+		return http.SameSiteStrictMode
+	case "none":
+		return http.SameSiteNoneMode
+	default:
+		return http.SameSiteLaxMode
+	}
+}
+
+// allowedOrigin checks whether the origin is in the allowlist
+func allowedOrigin(allowed []string, origin string) bool {
+	if origin == "" {
+		return false
+	}
+	for _, a := range allowed {
+		if strings.EqualFold(strings.TrimRight(a, "/"), origin) {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *CLAHandler) sessionHours() int {
+	if s.Config.ClaSecurity.SessionHours > 0 {
+		return s.Config.ClaSecurity.SessionHours
+	}
+	return defaultSessionHours
+}
+
+// CORS sets the CORS headers for the allowed origins, and responses the preflight request
+func (s *CLAHandler) CORS(handler http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		origin := r.Header.Get("Origin")
+		if allowedOrigin(s.Config.ClaSecurity.AllowedOrigins, origin) {
+			w.Header().Set("Access-Control-Allow-Origin", origin)
+			w.Header().Set("Access-Control-Allow-Credentials", "true")
+			w.Header().Set("Access-Control-Allow-Headers", "Content-Type, "+CSRF_HEADER_KEY)
+			w.Header().Set("Access-Control-Allow-Methods", "GET, POST, DELETE, OPTIONS")
+		}
+		w.Header().Add("Vary", "Origin")
+		if r.Method == "OPTIONS" {
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+		handler(w, r)
+	}
+}
+
+// createSession keeps the access token in a new session, and sets the session and csrf cookies
+func (s *CLAHandler) createSession(w http.ResponseWriter, accessToken string) error {
+	c, err := currentCLACrypto()
+	if err != nil {
+		return err
+	}
+	id, err := randomToken()
+	if err != nil {
+		return err
+	}
+	csrf, err := randomToken()
+	if err != nil {
+		return err
+	}
+	encrypted, err := c.encrypt(accessToken)
+	if err != nil {
+		return err
+	}
+	// the expired sessions are removed by the way
+	err = database.DBConnection.Unscoped().Where("expires_at < ?", time.Now()).Delete(&database.CLASessions{}).Error
+	if err != nil {
+		glog.Errorf("remove expired cla sessions error: %v", err)
+	}
+	err = database.DBConnection.Create(&database.CLASessions{
+		SessionHash: hashSessionID(id),
+		AccessToken: encrypted,
+		CsrfToken:   csrf,
+		ExpiresAt:   time.Now().Add(time.Duration(s.sessionHours()) * time.Hour),
+	}).Error
+	if err != nil {
+		return err
+	}
+	s.setCookie(w, COOKIE_KEY, id, true)
+	s.setCookie(w, CSRF_COOKIE_KEY, csrf, false)
+	return nil
+}
+
+// CLASessionInfo is the session of cla page without the access token
+type CLASessionInfo struct {
+	CsrfToken string    `json:"csrfToken"`
+	ExpiresAt time.Time `json:"expiresAt"`
+}
+
+// currentSession gets the unexpired session in cookie
+func currentSession(r *http.Request) (database.CLASessions, error) {
+	var session database.CLASessions
+	cookie, err := r.Cookie(COOKIE_KEY)
+	if err != nil || cookie.Value == "" {
+		return session, fmt.Errorf("not authorized")
+	}
+	err = database.DBConnection.Where("session_hash = ? AND expires_at > ?", hashSessionID(cookie.Value), time.Now()).
+		First(&session).Error
+	if err != nil {
+		return session, fmt.Errorf("session is expired or not found")
+	}
+	return session, nil
+}
+
+// ServeCLASession outputs the csrf token of session, which the cla page on another origin can not read in cookie
+func (s *CLAHandler) ServeCLASession(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "GET" {
+		glog.Infof("unsupport request method: %s", r.Method)
+		return
+	}
+	session, err := currentSession(r)
+	if err != nil {
+		s.HandleResult(w, CLAResult{
+			Description: fmt.Sprintf("session error: %v", err),
+			ErrorCode:   ErrorCode_AuthenticationError,
+		})
+		return
+	}
+	w.Header().Set("Cache-Control", "no-store")
+	s.HandleResult(w, CLAResult{IsSuccess: true, ErrorCode: ErrorCode_OK,
+		Data: CLASessionInfo{CsrfToken: session.CsrfToken, ExpiresAt: session.ExpiresAt}})
+}
+
+// sessionToken gets the access token of the unexpired session in cookie,
+// and checks the csrf token for the requests changing data
+func (s *CLAHandler) sessionToken(r *http.Request) (string, error) {
+	session, err := currentSession(r)
+	if err != nil {
+		return "", err
+	}
+	if r.Method != "GET" && r.Method != "HEAD" {
+		csrf := r.Header.Get(CSRF_HEADER_KEY)
+		if csrf == "" || subtle.ConstantTimeCompare([]byte(csrf), []byte(session.CsrfToken)) != 1 {
+			return "", fmt.Errorf("invalid csrf token")
+		}
+	}
+	c, err := currentCLACrypto()
+	if err != nil {
+		return "", err
+	}
+	return c.decrypt(session.AccessToken)
+}
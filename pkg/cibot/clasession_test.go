@@ -0,0 +1,52 @@
+package cibot
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"gitee.com/openeuler/ci-bot/pkg/cibot/config"
+)
+
+func TestCLACORS(t *testing.T) {
+	handler := &CLAHandler{Config: config.Config{ClaSecurity: config.ClaSecurity{
+		AllowedOrigins: []string{"https://www.openeuler.org/"}}}}
+	served := false
+	h := handler.CORS(func(w http.ResponseWriter, r *http.Request) { served = true })
+	tests := []struct {
+		name, method, origin string
+		wantOrigin           string
+		wantServed           bool
+	}{
+		{"allowed", "POST", "https://www.openeuler.org", "https://www.openeuler.org", true},
+		{"not allowed", "POST", "https://evil.example.com", "", true},
+		{"preflight", "OPTIONS", "https://www.openeuler.org", "https://www.openeuler.org", false},
+	}
+	for _, tt := range tests {
+		served = false
+		r := httptest.NewRequest(tt.method, "/cla", nil)
+		r.Header.Set("Origin", tt.origin)
+		w := httptest.NewRecorder()
+		h(w, r)
+		if got := w.Header().Get("Access-Control-Allow-Origin"); got != tt.wantOrigin || served != tt.wantServed {
+			t.Errorf("%s: allowed origin = %q served = %v, want %q %v", tt.name, got, served, tt.wantOrigin, tt.wantServed)
+		}
+	}
+}
+
+func TestSameSiteOf(t *testing.T) {
+	if sameSiteOf("") != http.SameSiteLaxMode || sameSiteOf("Strict") != http.SameSiteStrictMode ||
+		sameSiteOf("none") != http.SameSiteNoneMode {
+		t.Errorf("sameSiteOf() converts wrongly")
+	}
+}
+
+func TestSetCookie(t *testing.T) {
+	handler := &CLAHandler{}
+	w := httptest.NewRecorder()
+	handler.setCookie(w, COOKIE_KEY, "id", true)
+	cookies := w.Result().Cookies()
+	if len(cookies) != 1 || !cookies[0].HttpOnly || !cookies[0].Secure || cookies[0].MaxAge != defaultSessionHours*3600 {
+		t.Errorf("session cookie is not secure: %+v", cookies)
+	}
+}
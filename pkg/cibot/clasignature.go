@@ -0,0 +1,188 @@
+package cibot
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"gitee.com/openeuler/ci-bot/pkg/cibot/database"
+	"github.com/golang/glog"
+)
+
+// CLASignature is the decrypted cla details of signer
+type CLASignature struct {
+	Type          int        `json:"type"`
+	Name          string     `json:"name,omitempty"`
+	Title         string     `json:"title,omitempty"`
+	Corporation   string     `json:"corporation,omitempty"`
+	Address       string     `json:"address,omitempty"`
+	Date          string     `json:"date,omitempty"`
+	Email         string     `json:"email,omitempty"`
+	Telephone     string     `json:"telephone,omitempty"`
+	Fax           string     `json:"fax,omitempty"`
+	SignedVersion string     `json:"signedVersion,omitempty"`
+	SignedAt      time.Time  `json:"signedAt"`
+	RevokedAt     *time.Time `json:"revokedAt,omitempty"`
+	ErasedAt      *time.Time `json:"erasedAt,omitempty"`
+}
+
+// CLAEmployment is the corporation listing the email as its employee
+type CLAEmployment struct {
+	Corporation string    `json:"corporation"`
+	Email       string    `json:"email"`
+	AddedBy     string    `json:"addedBy,omitempty"`
+	AddedAt     time.Time `json:"addedAt"`
+}
+
+// CLAExport is the personal data of emails exported for cla admins
+type CLAExport struct {
+	Signatures  []CLASignature  `json:"signatures"`
+	Employments []CLAEmployment `json:"employments"`
+}
+
+// CLAErasureRequest is the signatures whose personal data is erased by cla admins
+type CLAErasureRequest struct {
+	Emails []string `json:"emails"`
+}
+
+// claSignatureOf converts the decrypted cla details to signature
+func claSignatureOf(cd database.CLADetails) CLASignature {
+	return CLASignature{
+		Type:          cd.Type,
+		Name:          cd.Name,
+		Title:         cd.Title,
+		Corporation:   cd.Corporation,
+		Address:       cd.Address,
+		Date:          cd.Date,
+		Email:         cd.Email,
+		Telephone:     cd.Telephone,
+		Fax:           cd.Fax,
+		SignedVersion: cd.SignedVersion,
+		SignedAt:      cd.CreatedAt,
+		RevokedAt:     cd.RevokedAt,
+		ErasedAt:      cd.ErasedAt,
+	}
+}
+
+// anonymizeCLADetails erases the personal data, and keeps the record that a signature existed
+func anonymizeCLADetails(cd database.CLADetails, now time.Time) database.CLADetails {
+	cd.Name = ""
+	cd.Title = ""
+	cd.Address = ""
+	cd.Email = ""
+	cd.Telephone = ""
+	cd.Fax = ""
+	cd.EmailHash = ""
+	cd.ErasedAt = &now
+	return cd
+}
+
+// ServeOwnCLASignature gets the signature of the authorized gitee user
+func (s *CLAHandler) ServeOwnCLASignature(w http.ResponseWriter, r *http.Request) {
+	email, err := s.authenticatedEmail(r)
+	if err != nil {
+		s.HandleResult(w, CLAResult{
+			Description: fmt.Sprintf("authenticate error: %v", err),
+			ErrorCode:   ErrorCode_AuthenticationError,
+		})
+		return
+	}
+	s.handleSignatures(w, []string{email})
+}
+
+// ServeCLAExport exports the signatures of emails for cla admins
+func (s *CLAHandler) ServeCLAExport(w http.ResponseWriter, r *http.Request) {
+	if _, ok := s.authenticateAdmin(w, r); !ok {
+		return
+	}
+	emails := splitEmails(strings.Join(r.URL.Query()["email"], ","))
+	cds, err := findCLADetailsByEmails(emails)
+	var ces []database.CorporationEmployees
+	if err == nil {
+		ces, err = findCorporationEmployeesByEmails(emails)
+	}
+	if err != nil {
+		s.HandleResult(w, CLAResult{
+			Description: fmt.Sprintf("find personal data error: %v", err),
+			ErrorCode:   ErrorCode_ServerHandleError,
+		})
+		return
+	}
+	export := CLAExport{
+		Signatures:  make([]CLASignature, 0, len(cds)),
+		Employments: make([]CLAEmployment, 0, len(ces)),
+	}
+	for _, cd := range cds {
+		export.Signatures = append(export.Signatures, claSignatureOf(cd))
+	}
+	for _, ce := range ces {
+		export.Employments = append(export.Employments, CLAEmployment{
+			Corporation: ce.Corporation, Email: ce.Email, AddedBy: ce.AddedBy, AddedAt: ce.CreatedAt})
+	}
+	s.HandleResult(w, CLAResult{IsSuccess: true, ErrorCode: ErrorCode_OK, Data: export})
+}
+
+// handleSignatures outputs the signatures of emails
+func (s *CLAHandler) handleSignatures(w http.ResponseWriter, emails []string) {
+	cds, err := findCLADetailsByEmails(emails)
+	if err != nil {
+		s.HandleResult(w, CLAResult{
+			Description: fmt.Sprintf("find cla details error: %v", err),
+			ErrorCode:   ErrorCode_ServerHandleError,
+		})
+		return
+	}
+	signatures := make([]CLASignature, 0, len(cds))
+	for _, cd := range cds {
+		signatures = append(signatures, claSignatureOf(cd))
+	}
+	s.HandleResult(w, CLAResult{IsSuccess: true, ErrorCode: ErrorCode_OK, Data: signatures})
+}
+
+// ServeCLAErasure erases the personal data of signatures for cla admins
+func (s *CLAHandler) ServeCLAErasure(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "POST" {
+		glog.Infof("unsupport request method: %s", r.Method)
+		return
+	}
+	admin, ok := s.authenticateAdmin(w, r)
+	if !ok {
+		return
+	}
+	var request CLAErasureRequest
+	if !s.readRequest(w, r, &request) {
+		return
+	}
+	emails := splitEmails(strings.Join(request.Emails, ","))
+	cds, err := findCLADetailsByEmails(emails)
+	if err == nil {
+		now := time.Now()
+		for _, cd := range cds {
+			erased := anonymizeCLADetails(cd, now)
+			if err = database.DBConnection.Save(&erased).Error; err != nil {
+				break
+			}
+		}
+	}
+	// the employees are removed from the lists of corporations, including the ones removed before
+	var employees int64
+	if err == nil {
+		var c *claCrypto
+		if c, err = currentCLACrypto(); err == nil {
+			result := database.DBConnection.Unscoped().Where("email_hash in (?)", c.hashEmails(emails)).
+				Delete(&database.CorporationEmployees{})
+			err, employees = result.Error, result.RowsAffected
+		}
+	}
+	if err != nil {
+		s.HandleResult(w, CLAResult{
+			Description: fmt.Sprintf("erase cla details error: %v", err),
+			ErrorCode:   ErrorCode_ServerHandleError,
+		})
+		return
+	}
+	// the emails are not logged, they are personal data too
+	glog.Infof("personal data of %d cla signatures and %d corporation employees is erased by %s", len(cds), employees, admin)
+	s.HandleResult(w, CLAResult{IsSuccess: true, ErrorCode: ErrorCode_OK})
+}
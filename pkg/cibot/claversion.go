@@ -0,0 +1,153 @@
+package cibot
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"gitee.com/openeuler/ci-bot/pkg/cibot/database"
+	"github.com/golang/glog"
+)
+
+const (
+	claStatusSigned   = "signed"
+	claStatusOutdated = "outdated"
+	claStatusRevoked  = "revoked"
+	claStatusUnsigned = "unsigned"
+)
+
+// CLAVersionRequest is the new version of cla text added by cla admins
+type CLAVersionRequest struct {
+	Version        string `json:"version"`
+	Link           string `json:"link,omitempty"`
+	ResignRequired bool   `json:"resignRequired,omitempty"`
+}
+
+// CLARevocationRequest is the signatures revoked by cla admins
+type CLARevocationRequest struct {
+	Emails []string `json:"emails"`
+}
+
+// claVersions gets the versions of cla text, the last one is current
+func claVersions() ([]database.CLAVersions, error) {
+	var versions []database.CLAVersions
+	err := database.DBConnection.Order("id").Find(&versions).Error
+	if err != nil {
+		glog.Errorf("failed to get cla versions: %v", err)
+		return nil, err
+	}
+	return versions, nil
+}
+
+// currentCLAVersion gets the version to sign, empty if cla text is not versioned
+func currentCLAVersion(versions []database.CLAVersions) string {
+	if len(versions) == 0 {
+		return ""
+	}
+	return versions[len(versions)-1].Version
+}
+
+// claSignatureStatus checks whether the signature is revoked, or outdated
+// by a later version which requires signing again
+func claSignatureStatus(cd database.CLADetails, versions []database.CLAVersions) string {
+	if cd.RevokedAt != nil {
+		return claStatusRevoked
+	}
+	// the signatures before versioning or of unknown versions are older than all
+	signed := -1
+	for i, v := range versions {
+		if v.Version == cd.SignedVersion {
+			signed = i
+		}
+	}
+	for _, v := range versions[signed+1:] {
+		if v.ResignRequired {
+			return claStatusOutdated
+		}
+	}
+	return claStatusSigned
+}
+
+// ServeCLAVersions lists and adds the versions of cla text for cla admins
+func (s *CLAHandler) ServeCLAVersions(w http.ResponseWriter, r *http.Request) {
+	email, ok := s.authenticateAdmin(w, r)
+	if !ok {
+		return
+	}
+
+	switch r.Method {
+	case "GET":
+		versions, err := claVersions()
+		if err != nil {
+			s.HandleResult(w, CLAResult{
+				Description: fmt.Sprintf("get cla versions error: %v", err),
+				ErrorCode:   ErrorCode_ServerHandleError,
+			})
+			return
+		}
+		s.HandleResult(w, CLAResult{IsSuccess: true, ErrorCode: ErrorCode_OK, Data: versions})
+	case "POST":
+		var request CLAVersionRequest
+		if !s.readRequest(w, r, &request) {
+			return
+		}
+		request.Version = strings.TrimSpace(request.Version)
+		var count int
+		err := database.DBConnection.Model(&database.CLAVersions{}).
+			Where("version = ?", request.Version).Count(&count).Error
+		if err == nil && (request.Version == "" || count > 0) {
+			err = fmt.Errorf("version %q is empty or exists", request.Version)
+		}
+		if err == nil {
+			err = database.DBConnection.Create(&database.CLAVersions{
+				Version:        request.Version,
+				Link:           request.Link,
+				ResignRequired: request.ResignRequired,
+			}).Error
+		}
+		if err != nil {
+			s.HandleResult(w, CLAResult{
+				Description: fmt.Sprintf("add cla version error: %v", err),
+				ErrorCode:   ErrorCode_ServerHandleError,
+			})
+			return
+		}
+		glog.Infof("cla version %s (resign required: %v) is added by %s", request.Version, request.ResignRequired, email)
+		s.HandleResult(w, CLAResult{IsSuccess: true, ErrorCode: ErrorCode_OK})
+	default:
+		glog.Infof("unsupport request method: %s", r.Method)
+	}
+}
+
+// ServeCLARevocations revokes the signatures of emails for cla admins
+func (s *CLAHandler) ServeCLARevocations(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "POST" {
+		glog.Infof("unsupport request method: %s", r.Method)
+		return
+	}
+	email, ok := s.authenticateAdmin(w, r)
+	if !ok {
+		return
+	}
+	var request CLARevocationRequest
+	if !s.readRequest(w, r, &request) {
+		return
+	}
+	emails := splitEmails(strings.Join(request.Emails, ","))
+	c, err := currentCLACrypto()
+	if err == nil {
+		hashes := c.hashEmails(emails)
+		err = database.DBConnection.Model(&database.CLADetails{}).
+			Where("email_hash in (?) AND revoked_at IS NULL", hashes).Update("revoked_at", time.Now()).Error
+	}
+	if err != nil {
+		s.HandleResult(w, CLAResult{
+			Description: fmt.Sprintf("revoke cla error: %v", err),
+			ErrorCode:   ErrorCode_ServerHandleError,
+		})
+		return
+	}
+	glog.Infof("cla of %d emails is revoked by %s", len(emails), email)
+	s.HandleResult(w, CLAResult{IsSuccess: true, ErrorCode: ErrorCode_OK})
+}
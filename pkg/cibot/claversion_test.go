@@ -0,0 +1,51 @@
+package cibot
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"gitee.com/openeuler/ci-bot/pkg/cibot/config"
+	"gitee.com/openeuler/ci-bot/pkg/cibot/database"
+)
+
+func TestClaSignatureStatus(t *testing.T) {
+	now := time.Now()
+	versions := []database.CLAVersions{
+		{Version: "v1"},
+		{Version: "v2", ResignRequired: true},
+		{Version: "v2.1"},
+	}
+	tests := []struct {
+		name     string
+		cd       database.CLADetails
+		versions []database.CLAVersions
+		want     string
+	}{
+		{"not versioned", database.CLADetails{}, nil, claStatusSigned},
+		{"current", database.CLADetails{SignedVersion: "v2.1"}, versions, claStatusSigned},
+		{"minor update", database.CLADetails{SignedVersion: "v2"}, versions, claStatusSigned},
+		{"resign required", database.CLADetails{SignedVersion: "v1"}, versions, claStatusOutdated},
+		{"before versioning", database.CLADetails{}, versions, claStatusOutdated},
+		{"revoked", database.CLADetails{SignedVersion: "v2.1", RevokedAt: &now}, versions, claStatusRevoked},
+	}
+	for _, tt := range tests {
+		if got := claSignatureStatus(tt.cd, tt.versions); got != tt.want {
+			t.Errorf("%s: claSignatureStatus() = %s, want %s", tt.name, got, tt.want)
+		}
+	}
+	if got := currentCLAVersion(versions); got != "v2.1" {
+		t.Errorf("currentCLAVersion() = %s, want v2.1", got)
+	}
+}
+
+func TestRenderClaOutdated(t *testing.T) {
+	got := RenderMessage(config.Config{ClaLink: "https://example.com/cla"}, LocaleEn, claFoundMessage, MessageData{
+		"Commits": []claCommit{{Sha: "a1", Subject: "fix", Identities: []claIdentity{
+			{Role: "author", Name: "Alice", Email: "alice@example.com", Status: claStatusOutdated}}}}})
+	want := "- a1 fix\n  - author Alice <a***@example.com>: :warning: signed an outdated version of the CLA, " +
+		"please sign the current version at <https://example.com/cla> again"
+	if !strings.HasSuffix(got, want) {
+		t.Errorf("RenderMessage() = %q, want suffix %q", got, want)
+	}
+}
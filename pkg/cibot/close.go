@@ -1,7 +1,6 @@
 package cibot
 
 import (
-	"fmt"
 	"strings"
 
 	"github.com/antihax/optional"
@@ -10,11 +9,6 @@ import (
 	"github.com/golang/glog"
 )
 
-const (
-	closeIssueMessage       = `this issue is closed by: ***%s***.`
-	closePullRequestMessage = `this pull request is closed by: ***%s***.`
-)
-
 // Close closes pr or issue
 func (s *Server) Close(event *gitee.NoteEvent) error {
 	// handle PullRequest
@@ -65,7 +59,7 @@ func (s *Server) Close(event *gitee.NoteEvent) error {
 				// add comment
 				bodyComment := gitee.PullRequestCommentPostParam{}
 				bodyComment.AccessToken = s.Config.GiteeToken
-				bodyComment.Body = fmt.Sprintf(closePullRequestMessage, commentAuthor)
+				bodyComment.Body = s.message(owner, repo, closePullRequestMessage, MessageData{"Commenter": commentAuthor})
 				_, _, err = s.GiteeClient.PullRequestsApi.PostV5ReposOwnerRepoPullsNumberComments(s.Context, owner, repo, prNumber, bodyComment)
 				if err != nil {
 					glog.Errorf("unable to add comment in pull request: %v", err)
@@ -131,7 +125,7 @@ func (s *Server) Close(event *gitee.NoteEvent) error {
 				// add comment
 				bodyComment := gitee.IssueCommentPostParam{}
 				bodyComment.AccessToken = s.Config.GiteeToken
-				bodyComment.Body = fmt.Sprintf(closeIssueMessage, commentAuthor)
+				bodyComment.Body = s.message(owner, repo, closeIssueMessage, MessageData{"Commenter": commentAuthor})
 				_, _, err = s.GiteeClient.IssuesApi.PostV5ReposOwnerRepoIssuesNumberComments(s.Context, owner, repo, issueNumber, bodyComment)
 				if err != nil {
 					glog.Errorf("unable to add comment in issue: %v", err)
@@ -0,0 +1,184 @@
+package cibot
+
+import (
+	"regexp"
+	"strings"
+	"unicode/utf8"
+
+	"gitee.com/openeuler/ci-bot/pkg/cibot/config"
+	"gitee.com/openeuler/go-gitee/gitee"
+	"github.com/antihax/optional"
+	"github.com/golang/glog"
+)
+
+const (
+	LabelDcoYes = "dco/yes"
+	LabelDcoNo  = "dco/no"
+
+	commitProblemSignedOffMissing  = "signed-off-missing"
+	commitProblemSignedOffMismatch = "signed-off-mismatch"
+	commitProblemSubjectTooLong    = "subject-too-long"
+	commitProblemSubjectFormat     = "subject-format"
+	commitProblemSubjectForbidden  = "subject-forbidden"
+	commitProblemBodyMissing       = "body-missing"
+)
+
+var (
+	regSignedOffBy = regexp.MustCompile(`(?mi)^Signed-off-by:\s*(.*?)\s*<([^>]*)>\s*$`)
+	regTrailer     = regexp.MustCompile(`^[A-Za-z][A-Za-z0-9-]*:\s`)
+)
+
+// commitFailure is the commit failing the lint with the problems
+type commitFailure struct {
+	Sha      string
+	Subject  string
+	Problems []string
+}
+
+// lintCommitMessage lints the commit message of author, and gets the problems
+func lintCommitMessage(cfg config.CommitLint, message, authorName, authorEmail string, requireBody bool) []string {
+	problems := make([]string, 0)
+	lines := strings.Split(strings.TrimSpace(message), "\n")
+	subject := strings.TrimSpace(lines[0])
+
+	if cfg.RequireSignedOff {
+		signers := regSignedOffBy.FindAllStringSubmatch(message, -1)
+		if len(signers) == 0 {
+			problems = append(problems, commitProblemSignedOffMissing)
+		} else {
+			matched := false
+			for _, m := range signers {
+				if (authorEmail != "" && strings.EqualFold(m[2], authorEmail)) ||
+					(authorEmail == "" && strings.EqualFold(m[1], authorName)) {
+					matched = true
+					break
+				}
+			}
+			if !matched {
+				problems = append(problems, commitProblemSignedOffMismatch)
+			}
+		}
+	}
+
+	if cfg.SubjectMaxLength > 0 && utf8.RuneCountInString(subject) > cfg.SubjectMaxLength {
+		problems = append(problems, commitProblemSubjectTooLong)
+	}
+	if cfg.SubjectPattern != "" {
+		reg, err := regexp.Compile(cfg.SubjectPattern)
+		if err != nil {
+			glog.Errorf("invalid subject pattern %s: %v", cfg.SubjectPattern, err)
+		} else if !reg.MatchString(subject) {
+			problems = append(problems, commitProblemSubjectFormat)
+		}
+	}
+	for _, prefix := range cfg.ForbiddenSubjectPrefixes {
+		if strings.HasPrefix(strings.ToLower(subject), strings.ToLower(prefix)) {
+			problems = append(problems, commitProblemSubjectForbidden)
+			break
+		}
+	}
+
+	if requireBody {
+		hasBody := false
+		for _, l := range lines[1:] {
+			l = strings.TrimSpace(l)
+			if l != "" && !regTrailer.MatchString(l) {
+				hasBody = true
+				break
+			}
+		}
+		if !hasBody {
+			problems = append(problems, commitProblemBodyMissing)
+		}
+	}
+	return problems
+}
+
+// LintCommits checks the sign-off and the messages of pull request commits,
+// and labels it with dco/yes or dco/no
+func (s *Server) LintCommits(event *gitee.PullRequestEvent) error {
+	cfg := s.Config.CommitLint
+	if !cfg.Enable {
+		return nil
+	}
+	owner := event.Repository.Namespace
+	repo := event.Repository.Path
+	prNumber := event.PullRequest.Number
+
+	commitPullRequestOpts := &gitee.GetV5ReposOwnerRepoPullsNumberCommitsOpts{}
+	commitPullRequestOpts.AccessToken = optional.NewString(s.Config.GiteeToken)
+	commits, _, err := s.GiteeClient.PullRequestsApi.GetV5ReposOwnerRepoPullsNumberCommits(
+		s.Context, owner, repo, prNumber, commitPullRequestOpts)
+	if err != nil {
+		glog.Errorf("failed to get pull request commits detail : %v", err)
+		return err
+	}
+	requireBody := false
+	if cfg.BodyRequiredLines > 0 {
+		requireBody = s.changedLines(owner, repo, prNumber) >= cfg.BodyRequiredLines
+	}
+
+	failures := make([]commitFailure, 0)
+	for _, c := range commits {
+		if c.Commit == nil {
+			continue
+		}
+		authorName, authorEmail := "", ""
+		if c.Commit.Author != nil {
+			authorName, authorEmail = c.Commit.Author.Name, c.Commit.Author.Email
+		}
+		problems := lintCommitMessage(cfg, c.Commit.Message, authorName, authorEmail, requireBody)
+		if len(problems) == 0 {
+			continue
+		}
+		for i, p := range problems {
+			problems[i] = s.message(owner, repo, commitLintProblemMessagePrefix+p, MessageData{
+				"Author": authorName, "Email": authorEmail, "MaxLength": cfg.SubjectMaxLength,
+				"Pattern": cfg.SubjectPattern, "Lines": cfg.BodyRequiredLines})
+		}
+		failures = append(failures, commitFailure{
+			Sha:      c.Sha,
+			Subject:  strings.SplitN(strings.TrimSpace(c.Commit.Message), "\n", 2)[0],
+			Problems: problems,
+		})
+	}
+	glog.Infof("lint commits of %s/%s/%d: %d commits, %d failures", owner, repo, prNumber, len(commits), len(failures))
+
+	labelParam := &gitee.NoteEvent{}
+	labelParam.PullRequest = event.PullRequest
+	labelParam.Repository = event.Repository
+	labelParam.Comment = &gitee.NoteHook{}
+	addLabel, removeLabel := LabelDcoYes, LabelDcoNo
+	if len(failures) > 0 {
+		addLabel, removeLabel = LabelDcoNo, LabelDcoYes
+	}
+	err = s.RemoveSpecifyLabelsInPulRequest(labelParam, map[string]string{removeLabel: removeLabel})
+	if err != nil {
+		glog.Errorf("unable to remove label %s: %v", removeLabel, err)
+	}
+	err = s.AddSpecifyLabelsInPulRequest(labelParam, []string{addLabel}, true)
+	if err != nil {
+		return err
+	}
+	if len(failures) == 0 {
+		return nil
+	}
+	author := ""
+	if event.PullRequest.User != nil {
+		author = event.PullRequest.User.Login
+	}
+	return s.addCommentToPullRequest(owner, repo, s.message(owner, repo, commitLintFailedMessage,
+		MessageData{"Author": author, "Failures": failures}), prNumber)
+}
+
+// changedLines gets the number of lines added and deleted by pull request
+func (s *Server) changedLines(owner, repo string, number int32) int {
+	lvos := &gitee.GetV5ReposOwnerRepoPullsNumberFilesOpts{}
+	lvos.AccessToken = optional.NewString(s.Config.GiteeToken)
+	files, _, err := s.GiteeClient.PullRequestsApi.GetV5ReposOwnerRepoPullsNumberFiles(s.Context, owner, repo, number, lvos)
+	if err != nil {
+		glog.Errorf("unable to get pull request files. err: %v", err)
+		return 0
+	}
+	return changedLinesOf(files, nil)
+}
@@ -0,0 +1,39 @@
+package cibot
+
+import (
+	"reflect"
+	"testing"
+
+	"gitee.com/openeuler/ci-bot/pkg/cibot/config"
+)
+
+func TestLintCommitMessage(t *testing.T) {
+	cfg := config.CommitLint{
+		RequireSignedOff:         true,
+		SubjectMaxLength:         30,
+		SubjectPattern:           `^[a-z]+: .+`,
+		ForbiddenSubjectPrefixes: []string{"WIP", "fixup!"},
+	}
+	tests := []struct {
+		name        string
+		message     string
+		requireBody bool
+		want        []string
+	}{
+		{"pass", "fix: typo\n\nSigned-off-by: Alice <alice@example.com>", false, []string{}},
+		{"pass with body", "fix: typo\n\nThe typo breaks the build.\n\nSigned-off-by: Alice <Alice@Example.com>", true, []string{}},
+		{"no sign-off", "fix: typo", false, []string{commitProblemSignedOffMissing}},
+		{"other sign-off", "fix: typo\n\nSigned-off-by: Bob <bob@example.com>", false, []string{commitProblemSignedOffMismatch}},
+		{"long subject", "fix: a subject which is too long to read\n\nSigned-off-by: Alice <alice@example.com>", false,
+			[]string{commitProblemSubjectTooLong}},
+		{"format", "Fix typo\n\nSigned-off-by: Alice <alice@example.com>", false, []string{commitProblemSubjectFormat}},
+		{"wip", "wip: typo\n\nSigned-off-by: Alice <alice@example.com>", false, []string{commitProblemSubjectForbidden}},
+		{"body only trailers", "fix: typo\n\nSigned-off-by: Alice <alice@example.com>", true, []string{commitProblemBodyMissing}},
+	}
+	for _, tt := range tests {
+		got := lintCommitMessage(cfg, tt.message, "Alice", "alice@example.com", tt.requireBody)
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: lintCommitMessage() = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
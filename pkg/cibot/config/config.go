@@ -19,6 +19,7 @@ type Config struct {
 	WatchSigFileDuration     int                     `yaml:"watchSigFileDuration"`
 	WatchOwnerFiles          []WatchOwnerFile        `yaml:"watchOwnerFiles"`
 	WatchOwnerFileDuration   int                     `yaml:"watchOwnerFileDuration"`
+	WatchOwnerAliasesFiles   []WatchOwnerAliasesFile `yaml:"watchOwnerAliasesFiles"`
 	WatchFrozenFile          []WatchFrozenFile       `yaml:"watchFrozenFile"`
 	WatchFrozenDuration      int                     `yaml:"watchFrozenDuration"`
 	BotName                  string                  `yaml:"botName"`
@@ -40,8 +41,26 @@ type Config struct {
 	RequiringLabels          []string                `yaml:"requiringLabels"`
 	MissingLabels            []string                `yaml:"missingLabels"`
 	AutoDetectCla            bool                    `yaml:"autoDetectCla"`
+	ClaExemptEmails          []string                `yaml:"claExemptEmails"`
+	ClaAdmins                []string                `yaml:"claAdmins"`
+	ClaViewers               []string                `yaml:"claViewers"`
+	ClaSecurity              ClaSecurity             `yaml:"claSecurity"`
 	CheckPrReviewer          bool                    `yaml:"checkPrReviewer"`
 	SetReviewerTip           string                  `yaml:"setReviewerTip"`
+	MergeQueue               MergeQueue              `yaml:"mergeQueue"`
+	NeedsRebaseLabel         string                  `yaml:"needsRebaseLabel"`
+	Lifecycle                Lifecycle               `yaml:"lifecycle"`
+	MessageTemplateDir       string                  `yaml:"messageTemplateDir"`
+	DefaultLocale            string                  `yaml:"defaultLocale"`
+	Locales                  []Locale                `yaml:"locales"`
+	SigLink                  string                  `yaml:"sigLink"`
+	MergePolicies            []MergePolicy           `yaml:"mergePolicies"`
+	CommitLint               CommitLint              `yaml:"commitLint"`
+	PrSize                   PrSize                  `yaml:"prSize"`
+	PathLabels               PathLabels              `yaml:"pathLabels"`
+	IssueTemplates           []IssueTemplate         `yaml:"issueTemplates"`
+	NeedsInfoLabel           string                  `yaml:"needsInfoLabel"`
+	IssueTriage              IssueTriage             `yaml:"issueTriage"`
 }
 
 type WatchProjectFile struct {
@@ -65,6 +84,13 @@ type WatchOwnerFile struct {
 	WatchOwnerFileRef   string `yaml:"watchOwnerFileRef"`
 }
 
+type WatchOwnerAliasesFile struct {
+	WatchOwnerAliasesFileOwner string `yaml:"watchOwnerAliasesFileOwner"`
+	WatchOwnerAliasesFileRepo  string `yaml:"watchOwnerAliasesFileRepo"`
+	WatchOwnerAliasesFilePath  string `yaml:"watchOwnerAliasesFilePath"`
+	WatchOwnerAliasesFileRef   string `yaml:"watchOwnerAliasesFileRef"`
+}
+
 type WatchFrozenFile struct {
 	FrozenFileOwner string `yaml:"frozenFileOwner"`
 	FrozenFileRepo  string `yaml:"frozenFileRepo"`
@@ -73,7 +99,148 @@ type WatchFrozenFile struct {
 }
 
 type ExtraLgtmCountRequire struct {
+	// org, repo or sig
 	LcrType  string `yaml:"lcrType"`
 	LcrName  string `yaml:"lcrName"`
 	LcrCount int    `yaml:"lcrCount"`
+	// requires at least one lgtm from the maintainers of sig, only for sig
+	LcrRequireSigOwner bool `yaml:"lcrRequireSigOwner"`
+}
+
+type MergeQueue struct {
+	// repositories in the form of owner/repo, the merge queue is disabled if it is empty
+	Repos          []string `yaml:"repos"`
+	Duration       int      `yaml:"duration"`
+	PriorityLabels []string `yaml:"priorityLabels"`
+	RetestComment  string   `yaml:"retestComment"`
+	// the labels added by CI with the result, which are removed when CI is triggered again
+	CiSuccessLabel  string   `yaml:"ciSuccessLabel"`
+	CiFailureLabels []string `yaml:"ciFailureLabels"`
+}
+
+type Lifecycle struct {
+	Enable   bool `yaml:"enable"`
+	Duration int  `yaml:"duration"`
+	// idle days before marked as stale, then rotten, then closed
+	StaleDays  int `yaml:"staleDays"`
+	RottenDays int `yaml:"rottenDays"`
+	CloseDays  int `yaml:"closeDays"`
+	// repositories patterns in the form of owner/repo, e.g. openeuler/*
+	ExemptRepos  []string `yaml:"exemptRepos"`
+	ExemptLabels []string `yaml:"exemptLabels"`
+}
+
+type Locale struct {
+	// organization or repository in the form of owner/repo
+	Name   string `yaml:"name"`
+	Locale string `yaml:"locale"`
+}
+
+type MergePolicy struct {
+	Name string `yaml:"name"`
+	// the pull request matches if it matches all of the non-empty conditions,
+	// repos and branches are patterns, e.g. openeuler/* and openEuler-*
+	Orgs     []string `yaml:"orgs"`
+	Repos    []string `yaml:"repos"`
+	Sigs     []string `yaml:"sigs"`
+	Branches []string `yaml:"branches"`
+	// forbiddenLabels and oneOfLabels are patterns, e.g. kind/*
+	RequiredLabels  []string   `yaml:"requiredLabels"`
+	ForbiddenLabels []string   `yaml:"forbiddenLabels"`
+	OneOfLabels     [][]string `yaml:"oneOfLabels"`
+	// lgtmCountsRequired and extraLgtmCountRequired are used if it is 0
+	LgtmCount int `yaml:"lgtmCount"`
+	// whose approval counts: collaborators, owners or @user, anyone allowed to approve if empty
+	ApproverSources []string `yaml:"approverSources"`
+	// merge or squash, the default of gitee if empty
+	MergeMethod string `yaml:"mergeMethod"`
+	// requires at least one lgtm from the maintainers of the sig which the repository belongs to
+	RequireSigOwnerLgtm bool `yaml:"requireSigOwnerLgtm"`
+}
+
+type CommitLint struct {
+	Enable bool `yaml:"enable"`
+	// requires a Signed-off-by trailer matching the commit author
+	RequireSignedOff bool `yaml:"requireSignedOff"`
+	// the max length of subject, no limit if it is 0
+	SubjectMaxLength int `yaml:"subjectMaxLength"`
+	// the regular expression which subject must match, e.g. ^(feat|fix|docs): .+
+	SubjectPattern string `yaml:"subjectPattern"`
+	// e.g. WIP and fixup!
+	ForbiddenSubjectPrefixes []string `yaml:"forbiddenSubjectPrefixes"`
+	// the commits require body if the pull request changes more lines, disabled if it is 0
+	BodyRequiredLines int `yaml:"bodyRequiredLines"`
+}
+
+// PrSize labels the pull requests with the size of changes, from size/XS to size/XXL
+type PrSize struct {
+	Enable     bool             `yaml:"enable"`
+	Thresholds PrSizeThresholds `yaml:"thresholds"`
+	// the globs of files not counted, e.g. vendor/, **/*.pb.go and *.tar.gz
+	IgnoredFiles []string `yaml:"ignoredFiles"`
+}
+
+// PrSizeThresholds are the min changed lines of sizes, the ones not set are 10, 30, 100, 500 and 1000
+type PrSizeThresholds struct {
+	S   int `yaml:"s"`
+	M   int `yaml:"m"`
+	L   int `yaml:"l"`
+	XL  int `yaml:"xl"`
+	XXL int `yaml:"xxl"`
+}
+
+// PathLabels labels the pull requests by the paths of changed files
+type PathLabels struct {
+	Enable bool `yaml:"enable"`
+	// removes the labels added by rules which no longer match the changed files
+	RemoveUnmatched bool            `yaml:"removeUnmatched"`
+	Rules           []PathLabelRule `yaml:"rules"`
+}
+
+type PathLabelRule struct {
+	// the globs of owner/repo, e.g. openeuler/community, all repositories if empty
+	Repos []string `yaml:"repos"`
+	// the globs of changed files, e.g. *.spec, docs/** and sig/<name>/**
+	Paths []string `yaml:"paths"`
+	// the label in which the placeholders such as <name> are replaced by the matched directories
+	Label string `yaml:"label"`
+}
+
+// IssueTemplate is the sections required in the body of issues
+type IssueTemplate struct {
+	// the globs of owner/repo, the first matching template is applied
+	Repos []string `yaml:"repos"`
+	// the titles of sections, e.g. Environment
+	RequiredSections []string `yaml:"requiredSections"`
+}
+
+// IssueTriage assigns the new issues to the maintainers of the sig of repository
+type IssueTriage struct {
+	Enable bool `yaml:"enable"`
+	// round-robin by default, or least-open
+	Strategy string `yaml:"strategy"`
+	// the maintainers never assigned
+	OptOut []string `yaml:"optOut"`
+	// the maintainers not assigned during their vacations
+	Vacations []Vacation `yaml:"vacations"`
+	// the globs of owner/repo whose issues are not assigned
+	ExemptRepos []string `yaml:"exemptRepos"`
+}
+
+type Vacation struct {
+	Login string `yaml:"login"`
+	// the last day of vacation, e.g. 2020-10-08, until further notice if empty
+	Until string `yaml:"until"`
+}
+
+// ClaSecurity is the sessions and CORS of cla page
+type ClaSecurity struct {
+	// the origins of cla page allowed to call /cla with credentials
+	AllowedOrigins []string `yaml:"allowedOrigins"`
+	// 24 hours by default
+	SessionHours int `yaml:"sessionHours"`
+	// lax by default, strict or none
+	SameSite string `yaml:"sameSite"`
+	// the cookies are sent over http if true, only for local development
+	InsecureCookie bool `yaml:"insecureCookie"`
 }
@@ -0,0 +1,343 @@
+package cibot
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"sort"
+	"strings"
+
+	"gitee.com/openeuler/ci-bot/pkg/cibot/database"
+	"github.com/golang/glog"
+	"github.com/jinzhu/gorm"
+)
+
+// CorporationCLARequest is the corporate agreement updated by cla admins
+type CorporationCLARequest struct {
+	Corporation string   `json:"corporation"`
+	Domains     []string `json:"domains,omitempty"`
+	Managers    []string `json:"managers,omitempty"`
+	Active      *bool    `json:"active,omitempty"`
+}
+
+// CorporationEmployeesRequest is the employees added or removed by corporation managers
+type CorporationEmployeesRequest struct {
+	Corporation string   `json:"corporation"`
+	Emails      []string `json:"emails"`
+}
+
+// splitEmails splits the comma separated emails or domains into lower case
+func splitEmails(s string) []string {
+	result := make([]string, 0)
+	for _, e := range strings.Split(s, ",") {
+		e = strings.ToLower(strings.TrimSpace(e))
+		if e != "" {
+			result = append(result, e)
+		}
+	}
+	return result
+}
+
+// joinEmails joins the emails or domains into lower case comma separated string
+func joinEmails(emails []string) string {
+	return strings.Join(splitEmails(strings.Join(emails, ",")), ",")
+}
+
+// corporationCoversDomain checks whether the email is in the domains of agreement
+func corporationCoversDomain(cla database.CorporationCLAs, email string) bool {
+	i := strings.LastIndex(email, "@")
+	if !cla.Active || i < 0 {
+		return false
+	}
+	domain := strings.ToLower(email[i+1:])
+	for _, d := range splitEmails(cla.Domains) {
+		if domain == strings.TrimPrefix(d, "@") {
+			return true
+		}
+	}
+	return false
+}
+
+// corporationCoveredEmails gets the emails covered by the active corporate agreements,
+// either by the domains or by the employees list
+func corporationCoveredEmails(emails []string) (map[string]bool, error) {
+	covered := make(map[string]bool)
+	if len(emails) == 0 {
+		return covered, nil
+	}
+	var clas []database.CorporationCLAs
+	err := database.DBConnection.Where("active = ?", true).Find(&clas).Error
+	if err != nil {
+		glog.Errorf("failed to get corporation clas: %v", err)
+		return nil, err
+	}
+	if len(clas) == 0 {
+		return covered, nil
+	}
+	corporations := make([]string, 0, len(clas))
+	for _, cla := range clas {
+		corporations = append(corporations, cla.Corporation)
+		for _, e := range emails {
+			if corporationCoversDomain(cla, e) {
+				covered[strings.ToLower(e)] = true
+			}
+		}
+	}
+	c, err := currentCLACrypto()
+	if err != nil {
+		return nil, err
+	}
+	var employees []database.CorporationEmployees
+	err = database.DBConnection.Where("corporation in (?) AND email_hash in (?)", corporations, c.hashEmails(emails)).
+		Find(&employees).Error
+	if err != nil {
+		glog.Errorf("failed to get corporation employees: %v", err)
+		return nil, err
+	}
+	hashes := make(map[string]bool, len(employees))
+	for _, e := range employees {
+		hashes[e.EmailHash] = true
+	}
+	for _, e := range emails {
+		if hashes[c.hashEmail(e)] {
+			covered[strings.ToLower(e)] = true
+		}
+	}
+	return covered, nil
+}
+
+// ensureCorporationCLA adds the inactive agreement without managers if the corporation has none,
+// the managers are set by cla admins
+func ensureCorporationCLA(corporation string) error {
+	var cla database.CorporationCLAs
+	err := database.DBConnection.Where("corporation = ?", corporation).First(&cla).Error
+	if err == nil {
+		return nil
+	}
+	if !gorm.IsRecordNotFoundError(err) {
+		return err
+	}
+	return database.DBConnection.Create(&database.CorporationCLAs{
+		Corporation: corporation,
+	}).Error
+}
+
+// isCLAAdmin checks whether the email is one of cla admins
+func (s *CLAHandler) isCLAAdmin(email string) bool {
+	return containsUser(s.Config.ClaAdmins, email)
+}
+
+// authenticateAdmin gets the email of cla admin, and outputs the error if not authorized
+func (s *CLAHandler) authenticateAdmin(w http.ResponseWriter, r *http.Request) (string, bool) {
+	email, err := s.authenticatedEmail(r)
+	if err != nil {
+		s.HandleResult(w, CLAResult{
+			Description: fmt.Sprintf("authenticate error: %v", err),
+			ErrorCode:   ErrorCode_AuthenticationError,
+		})
+		return "", false
+	}
+	if !s.isCLAAdmin(email) {
+		s.HandleResult(w, CLAResult{
+			Description: fmt.Sprintf("%s is not cla admin", email),
+			ErrorCode:   ErrorCode_PermissionError,
+		})
+		return "", false
+	}
+	return email, true
+}
+
+// ServeCorporations lists and updates the corporate agreements for cla admins
+func (s *CLAHandler) ServeCorporations(w http.ResponseWriter, r *http.Request) {
+	email, ok := s.authenticateAdmin(w, r)
+	if !ok {
+		return
+	}
+
+	var err error
+	switch r.Method {
+	case "GET":
+		var clas []database.CorporationCLAs
+		err = database.DBConnection.Order("corporation").Find(&clas).Error
+		if err != nil {
+			s.HandleResult(w, CLAResult{
+				Description: fmt.Sprintf("get corporation clas error: %v", err),
+				ErrorCode:   ErrorCode_ServerHandleError,
+			})
+			return
+		}
+		s.HandleResult(w, CLAResult{IsSuccess: true, ErrorCode: ErrorCode_OK, Data: clas})
+	case "POST":
+		var request CorporationCLARequest
+		if !s.readRequest(w, r, &request) {
+			return
+		}
+		if strings.TrimSpace(request.Corporation) == "" {
+			s.HandleResult(w, CLAResult{
+				Description: "corporation is empty",
+				ErrorCode:   ErrorCode_RequestError,
+			})
+			return
+		}
+		var cla database.CorporationCLAs
+		err = database.DBConnection.Where("corporation = ?", request.Corporation).First(&cla).Error
+		if err != nil && !gorm.IsRecordNotFoundError(err) {
+			s.HandleResult(w, CLAResult{
+				Description: fmt.Sprintf("get corporation cla error: %v", err),
+				ErrorCode:   ErrorCode_ServerHandleError,
+			})
+			return
+		}
+		cla.Corporation = request.Corporation
+		if request.Domains != nil {
+			cla.Domains = joinEmails(request.Domains)
+		}
+		if request.Managers != nil {
+			cla.Managers = joinEmails(request.Managers)
+		}
+		if request.Active != nil {
+			cla.Active = *request.Active
+		}
+		err = database.DBConnection.Save(&cla).Error
+		if err != nil {
+			s.HandleResult(w, CLAResult{
+				Description: fmt.Sprintf("save corporation cla error: %v", err),
+				ErrorCode:   ErrorCode_ServerHandleError,
+			})
+			return
+		}
+		glog.Infof("corporation cla of %s is updated by %s", cla.Corporation, email)
+		s.HandleResult(w, CLAResult{IsSuccess: true, ErrorCode: ErrorCode_OK, Data: cla})
+	default:
+		glog.Infof("unsupport request method: %s", r.Method)
+	}
+}
+
+// ServeCorporationEmployees lists, adds and removes the employees for corporation managers
+func (s *CLAHandler) ServeCorporationEmployees(w http.ResponseWriter, r *http.Request) {
+	email, err := s.authenticatedEmail(r)
+	if err != nil {
+		s.HandleResult(w, CLAResult{
+			Description: fmt.Sprintf("authenticate error: %v", err),
+			ErrorCode:   ErrorCode_AuthenticationError,
+		})
+		return
+	}
+
+	var request CorporationEmployeesRequest
+	if r.Method == "GET" {
+		request.Corporation = r.URL.Query().Get("corporation")
+	} else if !s.readRequest(w, r, &request) {
+		return
+	}
+	var cla database.CorporationCLAs
+	err = database.DBConnection.Where("corporation = ?", request.Corporation).First(&cla).Error
+	if err != nil {
+		errorCode := ErrorCode_ServerHandleError
+		if gorm.IsRecordNotFoundError(err) {
+			errorCode = ErrorCode_RequestError
+		}
+		s.HandleResult(w, CLAResult{
+			Description: fmt.Sprintf("get corporation cla of %s error: %v", request.Corporation, err),
+			ErrorCode:   errorCode,
+		})
+		return
+	}
+	if !containsUser(splitEmails(cla.Managers), email) && !s.isCLAAdmin(email) {
+		s.HandleResult(w, CLAResult{
+			Description: fmt.Sprintf("%s is not manager of %s", email, cla.Corporation),
+			ErrorCode:   ErrorCode_PermissionError,
+		})
+		return
+	}
+
+	c, err := currentCLACrypto()
+	if err != nil {
+		s.HandleResult(w, CLAResult{
+			Description: fmt.Sprintf("cla crypto error: %v", err),
+			ErrorCode:   ErrorCode_ServerHandleError,
+		})
+		return
+	}
+	employees := splitEmails(strings.Join(request.Emails, ","))
+	if r.Method != "GET" && len(employees) == 0 {
+		s.HandleResult(w, CLAResult{
+			Description: "emails are empty",
+			ErrorCode:   ErrorCode_RequestError,
+		})
+		return
+	}
+	switch r.Method {
+	case "GET":
+		var ces []database.CorporationEmployees
+		err = database.DBConnection.Where("corporation = ?", cla.Corporation).Find(&ces).Error
+		if err != nil {
+			s.HandleResult(w, CLAResult{
+				Description: fmt.Sprintf("get corporation employees error: %v", err),
+				ErrorCode:   ErrorCode_ServerHandleError,
+			})
+			return
+		}
+		result := c.decryptEmployeeEmails(ces)
+		sort.Strings(result)
+		s.HandleResult(w, CLAResult{IsSuccess: true, ErrorCode: ErrorCode_OK, Data: result})
+		return
+	case "POST":
+		err = addCorporationEmployees(c, cla.Corporation, employees, email)
+	case "DELETE":
+		err = database.DBConnection.Where("corporation = ? AND email_hash in (?)", cla.Corporation, c.hashEmails(employees)).
+			Delete(&database.CorporationEmployees{}).Error
+	default:
+		glog.Infof("unsupport request method: %s", r.Method)
+		return
+	}
+	if err != nil {
+		s.HandleResult(w, CLAResult{
+			Description: fmt.Sprintf("update corporation employees error: %v", err),
+			ErrorCode:   ErrorCode_ServerHandleError,
+		})
+		return
+	}
+	glog.Infof("%d employees of %s are updated by %s with %s", len(employees), cla.Corporation, email, r.Method)
+	s.HandleResult(w, CLAResult{IsSuccess: true, ErrorCode: ErrorCode_OK})
+}
+
+// addCorporationEmployees adds the employees not in the list of corporation with the emails encrypted
+func addCorporationEmployees(c *claCrypto, corporation string, employees []string, manager string) error {
+	tx := database.DBConnection.Begin()
+	for _, e := range employees {
+		var count int
+		err := tx.Model(&database.CorporationEmployees{}).
+			Where("corporation = ? AND email_hash = ?", corporation, c.hashEmail(e)).Count(&count).Error
+		if err == nil && count == 0 {
+			var ce database.CorporationEmployees
+			ce, err = c.encryptCorporationEmployee(database.CorporationEmployees{
+				Corporation: corporation, Email: e, AddedBy: manager})
+			if err == nil {
+				err = tx.Create(&ce).Error
+			}
+		}
+		if err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+	return tx.Commit().Error
+}
+
+// readRequest reads the json body of request, and outputs the error if failed
+func (s *CLAHandler) readRequest(w http.ResponseWriter, r *http.Request, request interface{}) bool {
+	body, err := ioutil.ReadAll(r.Body)
+	if err == nil {
+		err = json.Unmarshal(body, request)
+	}
+	if err != nil {
+		s.HandleResult(w, CLAResult{
+			Description: fmt.Sprintf("read request error: %v", err),
+			ErrorCode:   ErrorCode_RequestError,
+		})
+		return false
+	}
+	return true
+}
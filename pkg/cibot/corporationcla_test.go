@@ -0,0 +1,107 @@
+package cibot
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"gitee.com/openeuler/ci-bot/pkg/cibot/database"
+)
+
+func TestSplitEmails(t *testing.T) {
+	got := splitEmails(" Alice@Example.com, ,bob@example.com,")
+	want := []string{"alice@example.com", "bob@example.com"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("splitEmails() = %v, want %v", got, want)
+	}
+}
+
+func TestCorporationCoversDomain(t *testing.T) {
+	cla := database.CorporationCLAs{Corporation: "Example", Domains: "example.com, @example.org", Active: true}
+	tests := []struct {
+		name  string
+		cla   database.CorporationCLAs
+		email string
+		want  bool
+	}{
+		{"domain", cla, "alice@Example.COM", true},
+		{"domain with at", cla, "bob@example.org", true},
+		{"sub domain", cla, "carol@dev.example.com", false},
+		{"other domain", cla, "dave@example.net", false},
+		{"invalid email", cla, "example.com", false},
+		{"inactive", database.CorporationCLAs{Domains: "example.com"}, "alice@example.com", false},
+	}
+	for _, tt := range tests {
+		if got := corporationCoversDomain(tt.cla, tt.email); got != tt.want {
+			t.Errorf("%s: corporationCoversDomain() = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestReadRequestInvalid(t *testing.T) {
+	handler := &CLAHandler{}
+	r := httptest.NewRequest("POST", "/cla/corporations", strings.NewReader(`{"corporation": `))
+	w := httptest.NewRecorder()
+	var request CorporationCLARequest
+	if handler.readRequest(w, r, &request) {
+		t.Fatalf("readRequest() of invalid json = true")
+	}
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("status = %d, want %d", w.Code, http.StatusBadRequest)
+	}
+}
+
+func TestCorporationEmployeesEncrypted(t *testing.T) {
+	defer useFakeDB(t)()
+	c, err := newCLACrypto(strings.Repeat("k", claKeyMinLength))
+	if err != nil {
+		t.Fatal(err)
+	}
+	previous := claCipher
+	claCipher = c
+	defer func() { claCipher = previous }()
+
+	err = database.DBConnection.Create(&database.CorporationCLAs{Corporation: "Example", Active: true}).Error
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = addCorporationEmployees(c, "Example", []string{"alice@example.com", "bob@example.com"}, "manager@example.com")
+	if err == nil {
+		err = addCorporationEmployees(c, "Example", []string{"Alice@Example.com"}, "manager@example.com")
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	var ces []database.CorporationEmployees
+	if err = database.DBConnection.Find(&ces).Error; err != nil {
+		t.Fatal(err)
+	}
+	if len(ces) != 2 {
+		t.Fatalf("employees = %d, want 2", len(ces))
+	}
+	for _, ce := range ces {
+		if strings.Contains(ce.Email, "example.com") || ce.EmailHash == "" {
+			t.Errorf("email of employee is not encrypted: %+v", ce)
+		}
+	}
+	if got := c.decryptEmployeeEmails(ces); !reflect.DeepEqual(got, []string{"alice@example.com", "bob@example.com"}) {
+		t.Errorf("decryptEmployeeEmails() = %v", got)
+	}
+
+	covered, err := corporationCoveredEmails([]string{"ALICE@example.com", "carol@example.com"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(covered, map[string]bool{"alice@example.com": true}) {
+		t.Errorf("corporationCoveredEmails() = %v, want alice only", covered)
+	}
+	found, err := findCorporationEmployeesByEmails([]string{"bob@example.com"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(found) != 1 || found[0].Email != "bob@example.com" || found[0].Corporation != "Example" {
+		t.Errorf("findCorporationEmployeesByEmails() = %+v", found)
+	}
+}
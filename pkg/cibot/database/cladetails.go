@@ -3,6 +3,7 @@ package database
 import (
 	"encoding/json"
 	"fmt"
+	"time"
 
 	"github.com/jinzhu/gorm"
 )
@@ -29,7 +30,24 @@ var CLADetailsTableSQL = fmt.Sprintf(`CREATE TABLE %s (
 	PRIMARY KEY (id)
   ) ENGINE=InnoDB DEFAULT CHARSET=utf8`, CLADetailsTableName)
 
-// CLADetails defines
+// AddVersionColumnsCLADetailsTableSQL adds new columns: signed_version and revoked_at
+var AddVersionColumnsCLADetailsTableSQL = fmt.Sprintf(`ALTER TABLE %s
+	ADD signed_version varchar(255) DEFAULT NULL,
+	ADD revoked_at timestamp NULL DEFAULT NULL`, CLADetailsTableName)
+
+// EncryptColumnsCLADetailsTableSQL widens the encrypted columns, and adds new columns: email_hash and erased_at
+var EncryptColumnsCLADetailsTableSQL = fmt.Sprintf(`ALTER TABLE %s
+	MODIFY name text,
+	MODIFY title text,
+	MODIFY address text,
+	MODIFY email text,
+	MODIFY telephone text,
+	MODIFY fax text,
+	ADD email_hash varchar(64) DEFAULT NULL,
+	ADD erased_at timestamp NULL DEFAULT NULL,
+	ADD KEY idx_cla_details_email_hash (email_hash)`, CLADetailsTableName)
+
+// CLADetails defines, name, title, address, email, telephone and fax are encrypted
 type CLADetails struct {
 	gorm.Model
 	Type           int
@@ -41,6 +59,10 @@ type CLADetails struct {
 	Email          string
 	Telephone      string
 	Fax            string
+	SignedVersion  string // empty for the signatures before versioning
+	RevokedAt      *time.Time
+	EmailHash      string // hmac of lower case email for lookup
+	ErasedAt       *time.Time
 	AdditionalInfo string `sql:"type:text"`
 }
 
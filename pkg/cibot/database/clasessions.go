@@ -0,0 +1,60 @@
+package database
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/jinzhu/gorm"
+)
+
+// CLASessionsTableName defines
+var CLASessionsTableName = "cla_sessions"
+
+// CLASessionsTableSQL matches with CLASessions Object
+var CLASessionsTableSQL = fmt.Sprintf(`CREATE TABLE %s (
+	id int(10) unsigned NOT NULL AUTO_INCREMENT,
+	created_at timestamp NULL DEFAULT NULL,
+	updated_at timestamp NULL DEFAULT NULL,
+	deleted_at timestamp NULL DEFAULT NULL,
+	session_hash varchar(64) DEFAULT NULL,
+	access_token text,
+	csrf_token varchar(64) DEFAULT NULL,
+	expires_at timestamp NULL DEFAULT NULL,
+	additional_info text,
+	PRIMARY KEY (id),
+	UNIQUE KEY idx_cla_sessions_session_hash (session_hash)
+  ) ENGINE=InnoDB DEFAULT CHARSET=utf8`, CLASessionsTableName)
+
+// CLASessions defines
+type CLASessions struct {
+	gorm.Model
+	// sha256 of the random session id in cookie
+	SessionHash string
+	// the encrypted gitee oauth access token
+	AccessToken    string `sql:"type:text"`
+	CsrfToken      string
+	ExpiresAt      time.Time
+	AdditionalInfo string `sql:"type:text"`
+}
+
+// GetAdditionalInfo for CLASessions
+func (css CLASessions) GetAdditionalInfo(additionalinfo interface{}) error {
+	if css.AdditionalInfo != "" {
+		err := json.Unmarshal([]byte(css.AdditionalInfo), &additionalinfo)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ToString for convert
+func (css CLASessions) ToString() (string, error) {
+	// Marshal datas
+	datas, err := json.Marshal(css)
+	if err != nil {
+		return "", fmt.Errorf("marshal cla sessions failed. Error: %s", err)
+	}
+	return string(datas), nil
+}
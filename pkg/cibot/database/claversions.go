@@ -0,0 +1,56 @@
+package database
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/jinzhu/gorm"
+)
+
+// CLAVersionsTableName defines
+var CLAVersionsTableName = "cla_versions"
+
+// CLAVersionsTableSQL matches with CLAVersions Object
+var CLAVersionsTableSQL = fmt.Sprintf(`CREATE TABLE %s (
+	id int(10) unsigned NOT NULL AUTO_INCREMENT,
+	created_at timestamp NULL DEFAULT NULL,
+	updated_at timestamp NULL DEFAULT NULL,
+	deleted_at timestamp NULL DEFAULT NULL,
+	version varchar(255) DEFAULT NULL,
+	link varchar(255) DEFAULT NULL,
+	resign_required BOOLEAN NOT NULL DEFAULT 0,
+	additional_info text,
+	PRIMARY KEY (id)
+  ) ENGINE=InnoDB DEFAULT CHARSET=utf8`, CLAVersionsTableName)
+
+// CLAVersions defines, the versions are ordered by id and the last one is current
+type CLAVersions struct {
+	gorm.Model
+	Version string
+	// the link of cla text
+	Link string
+	// the signatures of previous versions are outdated if true
+	ResignRequired bool
+	AdditionalInfo string `sql:"type:text"`
+}
+
+// GetAdditionalInfo for CLAVersions
+func (cvs CLAVersions) GetAdditionalInfo(additionalinfo interface{}) error {
+	if cvs.AdditionalInfo != "" {
+		err := json.Unmarshal([]byte(cvs.AdditionalInfo), &additionalinfo)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ToString for convert
+func (cvs CLAVersions) ToString() (string, error) {
+	// Marshal datas
+	datas, err := json.Marshal(cvs)
+	if err != nil {
+		return "", fmt.Errorf("marshal cla versions failed. Error: %s", err)
+	}
+	return string(datas), nil
+}
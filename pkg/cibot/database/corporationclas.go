@@ -0,0 +1,60 @@
+package database
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/jinzhu/gorm"
+)
+
+// CorporationCLAsTableName defines
+var CorporationCLAsTableName = "corporation_clas"
+
+// CorporationCLAsTableSQL matches with CorporationCLAs Object
+var CorporationCLAsTableSQL = fmt.Sprintf(`CREATE TABLE %s (
+	id int(10) unsigned NOT NULL AUTO_INCREMENT,
+	created_at timestamp NULL DEFAULT NULL,
+	updated_at timestamp NULL DEFAULT NULL,
+	deleted_at timestamp NULL DEFAULT NULL,
+	corporation varchar(255) DEFAULT NULL,
+	domains text,
+	managers text,
+	active BOOLEAN NOT NULL DEFAULT 0,
+	additional_info text,
+	PRIMARY KEY (id),
+	KEY idx_corporation_clas_corporation (corporation)
+  ) ENGINE=InnoDB DEFAULT CHARSET=utf8`, CorporationCLAsTableName)
+
+// CorporationCLAs defines
+type CorporationCLAs struct {
+	gorm.Model
+	Corporation string
+	// comma separated email domains covered by the agreement
+	Domains string `sql:"type:text"`
+	// comma separated emails of the managers maintaining the employees
+	Managers string `sql:"type:text"`
+	// only the active agreements cover the emails
+	Active         bool
+	AdditionalInfo string `sql:"type:text"`
+}
+
+// GetAdditionalInfo for CorporationCLAs
+func (ccs CorporationCLAs) GetAdditionalInfo(additionalinfo interface{}) error {
+	if ccs.AdditionalInfo != "" {
+		err := json.Unmarshal([]byte(ccs.AdditionalInfo), &additionalinfo)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ToString for convert
+func (ccs CorporationCLAs) ToString() (string, error) {
+	// Marshal datas
+	datas, err := json.Marshal(ccs)
+	if err != nil {
+		return "", fmt.Errorf("marshal corporation clas failed. Error: %s", err)
+	}
+	return string(datas), nil
+}
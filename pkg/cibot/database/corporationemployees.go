@@ -0,0 +1,64 @@
+package database
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/jinzhu/gorm"
+)
+
+// CorporationEmployeesTableName defines
+var CorporationEmployeesTableName = "corporation_employees"
+
+// CorporationEmployeesTableSQL matches with CorporationEmployees Object
+var CorporationEmployeesTableSQL = fmt.Sprintf(`CREATE TABLE %s (
+	id int(10) unsigned NOT NULL AUTO_INCREMENT,
+	created_at timestamp NULL DEFAULT NULL,
+	updated_at timestamp NULL DEFAULT NULL,
+	deleted_at timestamp NULL DEFAULT NULL,
+	corporation varchar(255) DEFAULT NULL,
+	email varchar(255) DEFAULT NULL,
+	added_by varchar(255) DEFAULT NULL,
+	additional_info text,
+	PRIMARY KEY (id),
+	KEY idx_corporation_employees_email (email)
+  ) ENGINE=InnoDB DEFAULT CHARSET=utf8`, CorporationEmployeesTableName)
+
+// EncryptEmailCorporationEmployeesTableSQL widens the encrypted email, and adds a new column: email_hash
+var EncryptEmailCorporationEmployeesTableSQL = fmt.Sprintf(`ALTER TABLE %s
+	DROP KEY idx_corporation_employees_email,
+	MODIFY email text,
+	ADD email_hash varchar(64) DEFAULT NULL,
+	ADD KEY idx_corporation_employees_email_hash (email_hash)`, CorporationEmployeesTableName)
+
+// CorporationEmployees defines, email is encrypted
+type CorporationEmployees struct {
+	gorm.Model
+	Corporation string
+	Email       string
+	EmailHash   string // hmac of lower case email for lookup
+	// the email of manager who added the employee
+	AddedBy        string
+	AdditionalInfo string `sql:"type:text"`
+}
+
+// GetAdditionalInfo for CorporationEmployees
+func (ces CorporationEmployees) GetAdditionalInfo(additionalinfo interface{}) error {
+	if ces.AdditionalInfo != "" {
+		err := json.Unmarshal([]byte(ces.AdditionalInfo), &additionalinfo)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ToString for convert
+func (ces CorporationEmployees) ToString() (string, error) {
+	// Marshal datas
+	datas, err := json.Marshal(ces)
+	if err != nil {
+		return "", fmt.Errorf("marshal corporation employees failed. Error: %s", err)
+	}
+	return string(datas), nil
+}
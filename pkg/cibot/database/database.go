@@ -54,7 +54,7 @@ func ConnectDataBase(config config.Config) (*gorm.DB, error) {
 func UpgradeDataBase(db *gorm.DB) error {
 
 	// upgrades defines
-	upgrades := make([]func() error, 5)
+	upgrades := make([]func() error, 17)
 	upgrades[0] = func() error {
 		// table upgrades
 		if err := db.Exec(UpgradesTableSQL).Error; err != nil {
@@ -110,6 +110,98 @@ func UpgradeDataBase(db *gorm.DB) error {
 		}
 		return nil
 	}
+	upgrades[5] = func() error {
+		// table review_votes
+		if err := db.Exec(ReviewVotesTableSQL).Error; err != nil {
+			return err
+		}
+		return nil
+	}
+	upgrades[6] = func() error {
+		// table pr_fingerprints
+		if err := db.Exec(PrFingerprintsTableSQL).Error; err != nil {
+			return err
+		}
+		return nil
+	}
+	upgrades[7] = func() error {
+		// table freeze_exceptions
+		if err := db.Exec(FreezeExceptionsTableSQL).Error; err != nil {
+			return err
+		}
+		return nil
+	}
+	upgrades[8] = func() error {
+		// table corporation_clas
+		if err := db.Exec(CorporationCLAsTableSQL).Error; err != nil {
+			return err
+		}
+		// table corporation_employees
+		if err := db.Exec(CorporationEmployeesTableSQL).Error; err != nil {
+			return err
+		}
+		return nil
+	}
+	upgrades[9] = func() error {
+		// add signed_version and revoked_at columns for table cla_details
+		if err := db.Exec(AddVersionColumnsCLADetailsTableSQL).Error; err != nil {
+			return err
+		}
+		// table cla_versions
+		if err := db.Exec(CLAVersionsTableSQL).Error; err != nil {
+			return err
+		}
+		return nil
+	}
+	upgrades[10] = func() error {
+		// widen encrypted columns and add email_hash and erased_at columns for table cla_details
+		if err := db.Exec(EncryptColumnsCLADetailsTableSQL).Error; err != nil {
+			return err
+		}
+		return nil
+	}
+	upgrades[11] = func() error {
+		// table cla_sessions
+		if err := db.Exec(CLASessionsTableSQL).Error; err != nil {
+			return err
+		}
+		return nil
+	}
+	upgrades[12] = func() error {
+		// table issue_triages
+		if err := db.Exec(IssueTriagesTableSQL).Error; err != nil {
+			return err
+		}
+		return nil
+	}
+	upgrades[13] = func() error {
+		// table pr_path_labels
+		if err := db.Exec(PrPathLabelsTableSQL).Error; err != nil {
+			return err
+		}
+		return nil
+	}
+	upgrades[14] = func() error {
+		// add cla_identities column for table pr_fingerprints
+		if err := db.Exec(AddCLAIdentitiesColumnPrFingerprintsTableSQL).Error; err != nil {
+			return err
+		}
+		return nil
+	}
+	upgrades[15] = func() error {
+		// widen encrypted email and add email_hash column for table corporation_employees
+		if err := db.Exec(EncryptEmailCorporationEmployeesTableSQL).Error; err != nil {
+			return err
+		}
+		return nil
+	}
+	upgrades[16] = func() error {
+		// add open column for table issue_triages
+		if err := db.Exec(AddOpenColumnIssueTriagesTableSQL).Error; err != nil {
+			return err
+		}
+		return nil
+	}
 
 	// Get UpgradeID
 	var lastUpgrade = -1
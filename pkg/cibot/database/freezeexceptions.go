@@ -0,0 +1,67 @@
+package database
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/jinzhu/gorm"
+)
+
+// FreezeExceptionsTableName defines
+var FreezeExceptionsTableName = "freeze_exceptions"
+
+// FreezeExceptionsTableSQL matches with FreezeExceptions Object
+var FreezeExceptionsTableSQL = fmt.Sprintf(`CREATE TABLE %s (
+	id int(10) unsigned NOT NULL AUTO_INCREMENT,
+	created_at timestamp NULL DEFAULT NULL,
+	updated_at timestamp NULL DEFAULT NULL,
+	deleted_at timestamp NULL DEFAULT NULL,
+	owner varchar(255) DEFAULT NULL,
+	repo varchar(255) DEFAULT NULL,
+	number int(10) DEFAULT NULL,
+	branch varchar(255) DEFAULT NULL,
+	requester varchar(255) DEFAULT NULL,
+	reason text,
+	approver varchar(255) DEFAULT NULL,
+	sha varchar(255) DEFAULT NULL,
+	additional_info text,
+	PRIMARY KEY (id),
+	KEY idx_freeze_exceptions_pr (owner, repo, number)
+  ) ENGINE=InnoDB DEFAULT CHARSET=utf8`, FreezeExceptionsTableName)
+
+// FreezeExceptions defines
+type FreezeExceptions struct {
+	gorm.Model
+	Owner     string
+	Repo      string
+	Number    int
+	Branch    string
+	Requester string
+	Reason    string `sql:"type:text"`
+	// empty until a branch owner approves
+	Approver string
+	// the head sha of pull request when approved, the exception is only for it
+	Sha            string
+	AdditionalInfo string `sql:"type:text"`
+}
+
+// GetAdditionalInfo for FreezeExceptions
+func (fes FreezeExceptions) GetAdditionalInfo(additionalinfo interface{}) error {
+	if fes.AdditionalInfo != "" {
+		err := json.Unmarshal([]byte(fes.AdditionalInfo), &additionalinfo)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ToString for convert
+func (fes FreezeExceptions) ToString() (string, error) {
+	// Marshal datas
+	datas, err := json.Marshal(fes)
+	if err != nil {
+		return "", fmt.Errorf("marshal freeze exceptions failed. Error: %s", err)
+	}
+	return string(datas), nil
+}
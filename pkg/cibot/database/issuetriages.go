@@ -0,0 +1,66 @@
+package database
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/jinzhu/gorm"
+)
+
+// IssueTriagesTableName defines
+var IssueTriagesTableName = "issue_triages"
+
+// IssueTriagesTableSQL matches with IssueTriages Object
+var IssueTriagesTableSQL = fmt.Sprintf(`CREATE TABLE %s (
+	id int(10) unsigned NOT NULL AUTO_INCREMENT,
+	created_at timestamp NULL DEFAULT NULL,
+	updated_at timestamp NULL DEFAULT NULL,
+	deleted_at timestamp NULL DEFAULT NULL,
+	owner varchar(255) DEFAULT NULL,
+	repo varchar(255) DEFAULT NULL,
+	number varchar(255) DEFAULT NULL,
+	sig varchar(255) DEFAULT NULL,
+	assignee varchar(255) DEFAULT NULL,
+	additional_info text,
+	PRIMARY KEY (id),
+	KEY idx_issue_triages_sig (sig)
+  ) ENGINE=InnoDB DEFAULT CHARSET=utf8`, IssueTriagesTableName)
+
+// AddOpenColumnIssueTriagesTableSQL adds a new column: open
+var AddOpenColumnIssueTriagesTableSQL = fmt.Sprintf(`ALTER TABLE %s
+	ADD open BOOLEAN NOT NULL DEFAULT 1`, IssueTriagesTableName)
+
+// IssueTriages defines
+type IssueTriages struct {
+	gorm.Model
+	Owner  string
+	Repo   string
+	Number string
+	// the sig whose maintainers take turns to triage
+	Sig      string
+	Assignee string
+	// false once the issue is closed, rejected or deleted
+	Open           bool
+	AdditionalInfo string `sql:"type:text"`
+}
+
+// GetAdditionalInfo for IssueTriages
+func (its IssueTriages) GetAdditionalInfo(additionalinfo interface{}) error {
+	if its.AdditionalInfo != "" {
+		err := json.Unmarshal([]byte(its.AdditionalInfo), &additionalinfo)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ToString for convert
+func (its IssueTriages) ToString() (string, error) {
+	// Marshal datas
+	datas, err := json.Marshal(its)
+	if err != nil {
+		return "", fmt.Errorf("marshal issue triages failed. Error: %s", err)
+	}
+	return string(datas), nil
+}
@@ -0,0 +1,67 @@
+package database
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/jinzhu/gorm"
+)
+
+// PrFingerprintsTableName defines
+var PrFingerprintsTableName = "pr_fingerprints"
+
+// PrFingerprintsTableSQL matches with PrFingerprints Object
+var PrFingerprintsTableSQL = fmt.Sprintf(`CREATE TABLE %s (
+	id int(10) unsigned NOT NULL AUTO_INCREMENT,
+	created_at timestamp NULL DEFAULT NULL,
+	updated_at timestamp NULL DEFAULT NULL,
+	deleted_at timestamp NULL DEFAULT NULL,
+	owner varchar(255) DEFAULT NULL,
+	repo varchar(255) DEFAULT NULL,
+	number int(10) DEFAULT NULL,
+	sha varchar(255) DEFAULT NULL,
+	fingerprint varchar(255) DEFAULT NULL,
+	additional_info text,
+	PRIMARY KEY (id),
+	KEY idx_pr_fingerprints_pr (owner, repo, number)
+  ) ENGINE=InnoDB DEFAULT CHARSET=utf8`, PrFingerprintsTableName)
+
+// AddCLAIdentitiesColumnPrFingerprintsTableSQL adds a new column: cla_identities
+var AddCLAIdentitiesColumnPrFingerprintsTableSQL = fmt.Sprintf(`ALTER TABLE %s
+	ADD cla_identities varchar(255) DEFAULT NULL`, PrFingerprintsTableName)
+
+// PrFingerprints defines
+type PrFingerprints struct {
+	gorm.Model
+	Owner  string
+	Repo   string
+	Number int
+	// the head sha of pull request
+	Sha string
+	// the fingerprint of normalized patches, empty if the diff is too large
+	Fingerprint string
+	// the fingerprint of cla identities with their status in the last cla comment
+	CLAIdentities  string
+	AdditionalInfo string `sql:"type:text"`
+}
+
+// GetAdditionalInfo for PrFingerprints
+func (pfs PrFingerprints) GetAdditionalInfo(additionalinfo interface{}) error {
+	if pfs.AdditionalInfo != "" {
+		err := json.Unmarshal([]byte(pfs.AdditionalInfo), &additionalinfo)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ToString for convert
+func (pfs PrFingerprints) ToString() (string, error) {
+	// Marshal datas
+	datas, err := json.Marshal(pfs)
+	if err != nil {
+		return "", fmt.Errorf("marshal pr fingerprints failed. Error: %s", err)
+	}
+	return string(datas), nil
+}
@@ -0,0 +1,58 @@
+package database
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/jinzhu/gorm"
+)
+
+// PrPathLabelsTableName defines
+var PrPathLabelsTableName = "pr_path_labels"
+
+// PrPathLabelsTableSQL matches with PrPathLabels Object
+var PrPathLabelsTableSQL = fmt.Sprintf(`CREATE TABLE %s (
+	id int(10) unsigned NOT NULL AUTO_INCREMENT,
+	created_at timestamp NULL DEFAULT NULL,
+	updated_at timestamp NULL DEFAULT NULL,
+	deleted_at timestamp NULL DEFAULT NULL,
+	owner varchar(255) DEFAULT NULL,
+	repo varchar(255) DEFAULT NULL,
+	number int(10) DEFAULT NULL,
+	label varchar(255) DEFAULT NULL,
+	additional_info text,
+	PRIMARY KEY (id),
+	KEY idx_pr_path_labels_pr (owner, repo, number)
+  ) ENGINE=InnoDB DEFAULT CHARSET=utf8`, PrPathLabelsTableName)
+
+// PrPathLabels defines
+type PrPathLabels struct {
+	gorm.Model
+	Owner  string
+	Repo   string
+	Number int
+	// the label added by path label rules
+	Label          string
+	AdditionalInfo string `sql:"type:text"`
+}
+
+// GetAdditionalInfo for PrPathLabels
+func (ppls PrPathLabels) GetAdditionalInfo(additionalinfo interface{}) error {
+	if ppls.AdditionalInfo != "" {
+		err := json.Unmarshal([]byte(ppls.AdditionalInfo), &additionalinfo)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ToString for convert
+func (ppls PrPathLabels) ToString() (string, error) {
+	// Marshal datas
+	datas, err := json.Marshal(ppls)
+	if err != nil {
+		return "", fmt.Errorf("marshal pr path labels failed. Error: %s", err)
+	}
+	return string(datas), nil
+}
@@ -0,0 +1,65 @@
+package database
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/jinzhu/gorm"
+)
+
+// ReviewVotesTableName defines
+var ReviewVotesTableName = "review_votes"
+
+// ReviewVotesTableSQL matches with ReviewVotes Object
+var ReviewVotesTableSQL = fmt.Sprintf(`CREATE TABLE %s (
+	id int(10) unsigned NOT NULL AUTO_INCREMENT,
+	created_at timestamp NULL DEFAULT NULL,
+	updated_at timestamp NULL DEFAULT NULL,
+	deleted_at timestamp NULL DEFAULT NULL,
+	owner varchar(255) DEFAULT NULL,
+	repo varchar(255) DEFAULT NULL,
+	number int(10) DEFAULT NULL,
+	user varchar(255) DEFAULT NULL,
+	kind varchar(255) DEFAULT NULL,
+	sha varchar(255) DEFAULT NULL,
+	retracted BOOLEAN NOT NULL DEFAULT 0,
+	additional_info text,
+	PRIMARY KEY (id),
+	KEY idx_review_votes_pr (owner, repo, number)
+  ) ENGINE=InnoDB DEFAULT CHARSET=utf8`, ReviewVotesTableName)
+
+// ReviewVotes defines
+type ReviewVotes struct {
+	gorm.Model
+	Owner  string
+	Repo   string
+	Number int
+	User   string
+	// "lgtm" or "approve"
+	Kind string
+	// the head sha of pull request when voting
+	Sha            string
+	Retracted      bool
+	AdditionalInfo string `sql:"type:text"`
+}
+
+// GetAdditionalInfo for ReviewVotes
+func (rvs ReviewVotes) GetAdditionalInfo(additionalinfo interface{}) error {
+	if rvs.AdditionalInfo != "" {
+		err := json.Unmarshal([]byte(rvs.AdditionalInfo), &additionalinfo)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ToString for convert
+func (rvs ReviewVotes) ToString() (string, error) {
+	// Marshal datas
+	datas, err := json.Marshal(rvs)
+	if err != nil {
+		return "", fmt.Errorf("marshal review votes failed. Error: %s", err)
+	}
+	return string(datas), nil
+}
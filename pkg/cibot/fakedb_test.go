@@ -0,0 +1,281 @@
+package cibot
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"io"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	"gitee.com/openeuler/ci-bot/pkg/cibot/database"
+	"github.com/jinzhu/gorm"
+)
+
+// fakeDB is an in-memory database understanding the single table statements generated by gorm,
+// the conditions are joined by and with the operators =, <>, in, IS NULL and IS NOT NULL
+type fakeDB struct {
+	mu     sync.Mutex
+	nextID int64
+	tables map[string][]map[string]driver.Value
+}
+
+var (
+	fakeDBs     = map[string]*fakeDB{}
+	fakeDBsLock sync.Mutex
+	fakeDBOnce  sync.Once
+)
+
+// useFakeDB replaces the database connection with an empty fake database, the returned func restores it
+func useFakeDB(t *testing.T) func() {
+	fakeDBOnce.Do(func() { sql.Register("fakedb", fakeDriver{}) })
+	fakeDBsLock.Lock()
+	fakeDBs[t.Name()] = &fakeDB{tables: map[string][]map[string]driver.Value{}}
+	fakeDBsLock.Unlock()
+
+	db, err := sql.Open("fakedb", t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	conn, err := gorm.Open("mysql", db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	previous := database.DBConnection
+	database.DBConnection = conn
+	return func() {
+		database.DBConnection = previous
+		conn.Close()
+	}
+}
+
+type fakeDriver struct{}
+
+func (fakeDriver) Open(name string) (driver.Conn, error) {
+	fakeDBsLock.Lock()
+	defer fakeDBsLock.Unlock()
+	db, ok := fakeDBs[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown fake database %s", name)
+	}
+	return &fakeConn{db: db}, nil
+}
+
+type fakeConn struct {
+	db *fakeDB
+}
+
+func (c *fakeConn) Prepare(query string) (driver.Stmt, error) {
+	return &fakeStmt{db: c.db, query: query}, nil
+}
+
+func (c *fakeConn) Close() error { return nil }
+
+func (c *fakeConn) Begin() (driver.Tx, error) { return fakeTx{}, nil }
+
+type fakeTx struct{}
+
+func (fakeTx) Commit() error   { return nil }
+func (fakeTx) Rollback() error { return nil }
+
+type fakeStmt struct {
+	db    *fakeDB
+	query string
+}
+
+func (s *fakeStmt) Close() error  { return nil }
+func (s *fakeStmt) NumInput() int { return -1 }
+
+var (
+	fakeInsertRe = regexp.MustCompile("^INSERT INTO `(\\w+)` \\((.*)\\) VALUES")
+	fakeUpdateRe = regexp.MustCompile("^UPDATE `(\\w+)` SET (.*?)\\s+WHERE (.*)$")
+	fakeSelectRe = regexp.MustCompile("^SELECT (.*?) FROM `(\\w+)`\\s*(?:WHERE (.*?))?\\s*(?:ORDER BY (.*?))?\\s*(?:LIMIT (\\d+))?\\s*(?:OFFSET (\\d+))?$")
+	fakeCondRe   = regexp.MustCompile(`^(\w+)\s*(=|<>|!=)\s*\?$|^(\w+) IS (NOT )?NULL$|^(\w+) (?i:in) (\?(?:\s*,\s*\?)*)$`)
+	fakeNameRe   = regexp.MustCompile("`?(?:\\w+`?\\.`?)?(\\w+)`?")
+)
+
+func (s *fakeStmt) Exec(args []driver.Value) (driver.Result, error) {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+	query := strings.Join(strings.Fields(s.query), " ")
+	if m := fakeInsertRe.FindStringSubmatch(query); m != nil {
+		columns := fakeColumns(m[2])
+		if len(columns) != len(args) {
+			return nil, fmt.Errorf("fake db: %d columns with %d values", len(columns), len(args))
+		}
+		s.db.nextID++
+		row := map[string]driver.Value{"id": s.db.nextID}
+		for i, c := range columns {
+			row[c] = args[i]
+		}
+		s.db.tables[m[1]] = append(s.db.tables[m[1]], row)
+		return fakeResult{id: s.db.nextID, affected: 1}, nil
+	}
+	if m := fakeUpdateRe.FindStringSubmatch(query); m != nil {
+		columns := fakeColumns(strings.Replace(m[2], " = ?", "", -1))
+		rows, err := s.db.filter(m[1], m[3], args[len(columns):])
+		if err != nil {
+			return nil, err
+		}
+		for _, row := range rows {
+			for i, c := range columns {
+				row[c] = args[i]
+			}
+		}
+		return fakeResult{affected: int64(len(rows))}, nil
+	}
+	return nil, fmt.Errorf("fake db: unsupported statement %s", query)
+}
+
+func (s *fakeStmt) Query(args []driver.Value) (driver.Rows, error) {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+	query := strings.Join(strings.Fields(s.query), " ")
+	m := fakeSelectRe.FindStringSubmatch(query)
+	if m == nil {
+		return nil, fmt.Errorf("fake db: unsupported query %s", query)
+	}
+	rows, err := s.db.filter(m[2], m[3], args)
+	if err != nil {
+		return nil, err
+	}
+	if strings.EqualFold(m[1], "count(*)") {
+		return &fakeRows{columns: []string{"count(*)"}, values: [][]driver.Value{{int64(len(rows))}}}, nil
+	}
+	if m[4] != "" {
+		order := strings.Fields(strings.Split(m[4], ",")[0])
+		column := fakeNameRe.FindStringSubmatch(order[0])[1]
+		desc := len(order) > 1 && strings.EqualFold(order[1], "desc")
+		sort.SliceStable(rows, func(i, j int) bool {
+			less := fakeLess(rows[i][column], rows[j][column])
+			if desc {
+				return fakeLess(rows[j][column], rows[i][column])
+			}
+			return less
+		})
+	}
+	if m[6] != "" {
+		offset, _ := strconv.Atoi(m[6])
+		if offset > len(rows) {
+			offset = len(rows)
+		}
+		rows = rows[offset:]
+	}
+	if m[5] != "" {
+		limit, _ := strconv.Atoi(m[5])
+		if limit < len(rows) {
+			rows = rows[:limit]
+		}
+	}
+	columnSet := map[string]bool{"id": true}
+	for _, row := range s.db.tables[m[2]] {
+		for c := range row {
+			columnSet[c] = true
+		}
+	}
+	result := &fakeRows{}
+	for c := range columnSet {
+		result.columns = append(result.columns, c)
+	}
+	sort.Strings(result.columns)
+	for _, row := range rows {
+		values := make([]driver.Value, len(result.columns))
+		for i, c := range result.columns {
+			values[i] = row[c]
+		}
+		result.values = append(result.values, values)
+	}
+	return result, nil
+}
+
+// filter gets the rows of table matching the conditions of where
+func (db *fakeDB) filter(table, where string, args []driver.Value) ([]map[string]driver.Value, error) {
+	var conds []string
+	if where = strings.TrimSpace(strings.NewReplacer("(", " ", ")", " ").Replace(where)); where != "" {
+		conds = regexp.MustCompile(`(?i)\s+and\s+`).Split(where, -1)
+	}
+	var rows []map[string]driver.Value
+	for _, row := range db.tables[table] {
+		matched, arg := true, 0
+		for _, cond := range conds {
+			cond = fakeNameRe.ReplaceAllString(strings.Join(strings.Fields(cond), " "), "$1")
+			m := fakeCondRe.FindStringSubmatch(cond)
+			if m == nil {
+				return nil, fmt.Errorf("fake db: unsupported condition %s", cond)
+			}
+			if m[5] != "" {
+				n := strings.Count(m[6], "?")
+				if arg+n > len(args) {
+					return nil, fmt.Errorf("fake db: missing arguments of %s", cond)
+				}
+				in := false
+				for _, a := range args[arg : arg+n] {
+					in = in || fmt.Sprint(row[m[5]]) == fmt.Sprint(a)
+				}
+				arg += n
+				matched = matched && in
+			} else if m[1] != "" {
+				if arg >= len(args) {
+					return nil, fmt.Errorf("fake db: missing argument of %s", cond)
+				}
+				equal := fmt.Sprint(row[m[1]]) == fmt.Sprint(args[arg])
+				arg++
+				if equal != (m[2] == "=") {
+					matched = false
+				}
+			} else if (row[m[3]] == nil) == (m[4] != "") {
+				matched = false
+			}
+		}
+		if matched {
+			rows = append(rows, row)
+		}
+	}
+	return rows, nil
+}
+
+func fakeColumns(list string) []string {
+	var columns []string
+	for _, c := range strings.Split(list, ",") {
+		columns = append(columns, fakeNameRe.FindStringSubmatch(strings.TrimSpace(c))[1])
+	}
+	return columns
+}
+
+func fakeLess(a, b driver.Value) bool {
+	x, okx := a.(int64)
+	y, oky := b.(int64)
+	if okx && oky {
+		return x < y
+	}
+	return fmt.Sprint(a) < fmt.Sprint(b)
+}
+
+type fakeResult struct {
+	id, affected int64
+}
+
+func (r fakeResult) LastInsertId() (int64, error) { return r.id, nil }
+func (r fakeResult) RowsAffected() (int64, error) { return r.affected, nil }
+
+type fakeRows struct {
+	columns []string
+	values  [][]driver.Value
+	next    int
+}
+
+func (r *fakeRows) Columns() []string { return r.columns }
+func (r *fakeRows) Close() error      { return nil }
+
+func (r *fakeRows) Next(dest []driver.Value) error {
+	if r.next >= len(r.values) {
+		return io.EOF
+	}
+	copy(dest, r.values[r.next])
+	r.next++
+	return nil
+}
@@ -0,0 +1,171 @@
+package cibot
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"sort"
+	"strings"
+
+	"gitee.com/openeuler/ci-bot/pkg/cibot/database"
+	"gitee.com/openeuler/go-gitee/gitee"
+	"github.com/antihax/optional"
+	"github.com/golang/glog"
+)
+
+// PatchFingerprint computes the fingerprint of the normalized per-file patches,
+// the hunk positions and index lines are ignored so that a clean rebase keeps the same fingerprint.
+// An empty fingerprint is returned if any patch is too large to be compared.
+func PatchFingerprint(files []gitee.PullRequestFiles) string {
+	patches := make([]string, 0, len(files))
+	for _, f := range files {
+		if f.Patch == nil || f.Patch.TooLarge {
+			return ""
+		}
+		var b strings.Builder
+		b.WriteString(strings.Join([]string{f.Filename, f.Patch.OldPath, f.Patch.NewPath,
+			f.Patch.AMode, f.Patch.BMode}, "\x00"))
+		if f.Patch.NewFile {
+			b.WriteString("\x00new")
+		}
+		if f.Patch.RenamedFile {
+			b.WriteString("\x00renamed")
+		}
+		if f.Patch.DeletedFile {
+			b.WriteString("\x00deleted")
+		}
+		b.WriteString("\n")
+		for _, line := range strings.Split(f.Patch.Diff, "\n") {
+			line = strings.TrimRight(line, "\r")
+			switch {
+			case strings.HasPrefix(line, "@@"):
+				// hunk header changes with the position of the hunk after rebase
+				line = "@@"
+			case strings.HasPrefix(line, "index "):
+				continue
+			}
+			b.WriteString(line)
+			b.WriteString("\n")
+		}
+		patches = append(patches, b.String())
+	}
+	sort.Strings(patches)
+
+	h := sha256.New()
+	for _, p := range patches {
+		h.Write([]byte(p))
+		h.Write([]byte{0})
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// getPullRequestFingerprint gets the patch fingerprint of pull request
+func (s *Server) getPullRequestFingerprint(owner, repo string, number int32) (string, error) {
+	lvos := &gitee.GetV5ReposOwnerRepoPullsNumberFilesOpts{}
+	lvos.AccessToken = optional.NewString(s.Config.GiteeToken)
+	files, _, err := s.GiteeClient.PullRequestsApi.GetV5ReposOwnerRepoPullsNumberFiles(s.Context, owner, repo, number, lvos)
+	if err != nil {
+		glog.Errorf("unable to get pull request files. err: %v", err)
+		return "", err
+	}
+	return PatchFingerprint(files), nil
+}
+
+// refreshFingerprint stores the fingerprint of the head sha and returns the previous one and the current one
+func (s *Server) refreshFingerprint(owner, repo string, number int32, sha string) (string, string, error) {
+	current, err := s.getPullRequestFingerprint(owner, repo, number)
+	if err != nil {
+		return "", "", err
+	}
+
+	var fps []database.PrFingerprints
+	err = database.DBConnection.Model(&database.PrFingerprints{}).
+		Where("owner = ? and repo = ? and number = ?", owner, repo, number).Find(&fps).Error
+	if err != nil {
+		glog.Errorf("unable to get pr fingerprint: %v", err)
+		return "", "", err
+	}
+
+	previous := ""
+	if len(fps) > 0 {
+		previous = fps[0].Fingerprint
+		err = database.DBConnection.Model(&fps[0]).
+			Updates(map[string]interface{}{"sha": sha, "fingerprint": current}).Error
+	} else {
+		fp := database.PrFingerprints{
+			Owner:       owner,
+			Repo:        repo,
+			Number:      int(number),
+			Sha:         sha,
+			Fingerprint: current,
+		}
+		err = database.DBConnection.Create(&fp).Error
+	}
+	if err != nil {
+		glog.Errorf("unable to save pr fingerprint: %v", err)
+		return "", "", err
+	}
+	return previous, current, nil
+}
+
+// isCleanRebase checks whether the head of pull request changed without changing the diff
+func (s *Server) isCleanRebase(event *gitee.PullRequestEvent) bool {
+	owner := event.Repository.Namespace
+	repo := event.Repository.Path
+	number := event.PullRequest.Number
+	previous, current, err := s.refreshFingerprint(owner, repo, number, event.PullRequest.Head.Sha)
+	if err != nil {
+		return false
+	}
+	glog.Infof("pr fingerprint previous: %s current: %s", previous, current)
+	return previous != "" && previous == current
+}
+
+// carryOverVotes moves the active votes of pull request to the new head sha
+func carryOverVotes(owner, repo string, number int32, sha string) error {
+	err := database.DBConnection.Model(&database.ReviewVotes{}).
+		Where("owner = ? and repo = ? and number = ? and retracted = ?", owner, repo, number, false).
+		Update("sha", sha).Error
+	if err != nil {
+		glog.Errorf("unable to carry over votes: %v", err)
+	}
+	return err
+}
+
+// isReviewLabel checks whether the label is given by review votes
+func isReviewLabel(label string) bool {
+	return label == LabelNameLgtm || label == LabelNameApproved ||
+		strings.HasPrefix(label, fmt.Sprintf(LabelLgtmWithCommenter, ""))
+}
+
+// withoutReviewLabels filters out the review labels
+func withoutReviewLabels(labels []string) []string {
+	filtered := make([]string, 0, len(labels))
+	for _, l := range labels {
+		if !isReviewLabel(l) {
+			filtered = append(filtered, l)
+		}
+	}
+	return filtered
+}
+
+// commentCleanRebase tells the review labels are kept after a clean rebase
+func (s *Server) commentCleanRebase(event *gitee.PullRequestEvent, labels []gitee.Label) {
+	kept := make([]string, 0)
+	for _, l := range labels {
+		if isReviewLabel(l.Name) {
+			kept = append(kept, l.Name)
+		}
+	}
+	if len(kept) == 0 {
+		return
+	}
+	body := gitee.PullRequestCommentPostParam{}
+	body.AccessToken = s.Config.GiteeToken
+	body.Body = s.message(event.Repository.Namespace, event.Repository.Path, cleanRebaseMessage, MessageData{"Labels": kept})
+	_, _, err := s.GiteeClient.PullRequestsApi.PostV5ReposOwnerRepoPullsNumberComments(
+		s.Context, event.Repository.Namespace, event.Repository.Path, event.PullRequest.Number, body)
+	if err != nil {
+		glog.Errorf("unable to add comment in pull request: %v", err)
+	}
+}
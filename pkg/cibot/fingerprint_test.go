@@ -0,0 +1,60 @@
+package cibot
+
+import (
+	"testing"
+
+	"gitee.com/openeuler/go-gitee/gitee"
+)
+
+func Test_PatchFingerprint(t *testing.T) {
+	file := func(name, diff string) gitee.PullRequestFiles {
+		return gitee.PullRequestFiles{
+			Filename: name,
+			Patch:    &gitee.PullRequestFilePath{Diff: diff, OldPath: name, NewPath: name},
+		}
+	}
+	base := []gitee.PullRequestFiles{
+		file("a.go", "@@ -1,2 +1,3 @@\n a\n+b\n c\n"),
+		file("b.go", "@@ -10,1 +10,1 @@\n-x\n+y\n"),
+	}
+	tests := []struct {
+		name  string
+		files []gitee.PullRequestFiles
+		same  bool
+	}{
+		{
+			name: "hunks moved by rebase",
+			files: []gitee.PullRequestFiles{
+				file("b.go", "@@ -20,1 +20,1 @@ func foo()\n-x\n+y\n"),
+				file("a.go", "@@ -5,2 +5,3 @@\n a\n+b\n c\n"),
+			},
+			same: true,
+		},
+		{
+			name: "content changed",
+			files: []gitee.PullRequestFiles{
+				file("a.go", "@@ -1,2 +1,3 @@\n a\n+bb\n c\n"),
+				file("b.go", "@@ -10,1 +10,1 @@\n-x\n+y\n"),
+			},
+			same: false,
+		},
+		{
+			name:  "file dropped",
+			files: base[:1],
+			same:  false,
+		},
+	}
+	want := PatchFingerprint(base)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := PatchFingerprint(tt.files); (got == want) != tt.same {
+				t.Errorf("PatchFingerprint() same = %v, want %v", got == want, tt.same)
+			}
+		})
+	}
+
+	large := []gitee.PullRequestFiles{{Filename: "c.go", Patch: &gitee.PullRequestFilePath{TooLarge: true}}}
+	if got := PatchFingerprint(large); got != "" {
+		t.Errorf("PatchFingerprint() of too large patch = %v, want empty", got)
+	}
+}
@@ -0,0 +1,148 @@
+package cibot
+
+import (
+	"strings"
+
+	"gitee.com/openeuler/ci-bot/pkg/cibot/database"
+	"gitee.com/openeuler/go-gitee/gitee"
+	"github.com/golang/glog"
+	"github.com/jinzhu/gorm"
+)
+
+const (
+	// LabelFreezeException is added when a branch owner approves the freeze exception
+	LabelFreezeException = "freeze-exception"
+
+	freezeExceptionApprove = "approve"
+)
+
+// HandleFreezeException handles /freeze-exception <reason> from the author
+// and /freeze-exception approve from the owners of the frozen branch
+func (s *Server) HandleFreezeException(event *gitee.NoteEvent) error {
+	if *event.NoteableType != "PullRequest" || event.PullRequest.State != "open" {
+		return nil
+	}
+	owner := event.Repository.Namespace
+	repo := event.Repository.Path
+	number := event.PullRequest.Number
+	branch := event.PullRequest.Base.Ref
+	commenter := event.Comment.User.Login
+	m := RegFreezeException.FindStringSubmatch(event.Comment.Body)
+	if len(m) < 2 {
+		return nil
+	}
+	arg := strings.TrimSpace(m[1])
+
+	branchOwners, isFrozen := IsBranchFrozen(branch, owner)
+	if !isFrozen {
+		return s.addCommentToPullRequest(owner, repo,
+			s.message(owner, repo, freezeExceptionNotFrozenMessage, MessageData{"Commenter": commenter, "Branch": branch}), number)
+	}
+
+	if strings.EqualFold(arg, freezeExceptionApprove) {
+		if !containsUser(branchOwners, commenter) {
+			return s.addCommentToPullRequest(owner, repo, s.message(owner, repo, freezeExceptionNoPermissionMessage,
+				MessageData{"Commenter": commenter, "Branch": branch, "Owners": branchOwners}), number)
+		}
+		sha := event.PullRequest.Head.Sha
+		err := approveFreezeException(owner, repo, number, branch, commenter, sha)
+		if err != nil {
+			return err
+		}
+		err = s.AddSpecifyLabelsInPulRequest(event, []string{LabelFreezeException}, true)
+		if err != nil {
+			glog.Errorf("unable to add freeze exception label: %v", err)
+		}
+		return s.addCommentToPullRequest(owner, repo, s.message(owner, repo, freezeExceptionApprovedMessage,
+			MessageData{"Commenter": commenter, "Branch": branch, "Sha": sha}), number)
+	}
+
+	// only the author requests the exception
+	if event.PullRequest.User == nil || event.PullRequest.User.Login != commenter {
+		return s.addCommentToPullRequest(owner, repo,
+			s.message(owner, repo, freezeExceptionNotAuthorMessage, MessageData{"Commenter": commenter}), number)
+	}
+	exception := database.FreezeExceptions{
+		Owner:     owner,
+		Repo:      repo,
+		Number:    int(number),
+		Branch:    branch,
+		Requester: commenter,
+		Reason:    arg,
+	}
+	err := database.DBConnection.Create(&exception).Error
+	if err != nil {
+		glog.Errorf("unable to record freeze exception request: %v", err)
+		return err
+	}
+	return s.addCommentToPullRequest(owner, repo, s.message(owner, repo, freezeExceptionRequestedMessage,
+		MessageData{"Commenter": commenter, "Branch": branch, "Reason": arg, "Owners": branchOwners}), number)
+}
+
+// approveFreezeException approves the latest request of pull request for sha,
+// an approval without request is recorded as well
+func approveFreezeException(owner, repo string, number int32, branch, approver, sha string) error {
+	var exception database.FreezeExceptions
+	err := database.DBConnection.
+		Where("owner = ? and repo = ? and number = ? and approver = ?", owner, repo, number, "").
+		Order("id desc").First(&exception).Error
+	if err != nil && !gorm.IsRecordNotFoundError(err) {
+		glog.Errorf("unable to get freeze exception request: %v", err)
+		return err
+	}
+	exception.Owner = owner
+	exception.Repo = repo
+	exception.Number = int(number)
+	exception.Branch = branch
+	exception.Approver = approver
+	exception.Sha = sha
+	err = database.DBConnection.Save(&exception).Error
+	if err != nil {
+		glog.Errorf("unable to approve freeze exception: %v", err)
+	}
+	return err
+}
+
+// hasFreezeException checks whether the pull request at sha has an approved freeze exception
+func hasFreezeException(owner, repo string, number int32, sha string) bool {
+	if sha == "" {
+		return false
+	}
+	var count int
+	err := database.DBConnection.Model(&database.FreezeExceptions{}).
+		Where("owner = ? and repo = ? and number = ? and sha = ? and approver <> ?", owner, repo, number, sha, "").
+		Count(&count).Error
+	if err != nil {
+		glog.Errorf("unable to get freeze exceptions: %v", err)
+		return false
+	}
+	return count > 0
+}
+
+// dropFreezeException removes the freeze exception label since the exception is for the previous head sha
+func (s *Server) dropFreezeException(owner, repo string, pr gitee.PullRequest) error {
+	for _, l := range pr.Labels {
+		if l.Name != LabelFreezeException {
+			continue
+		}
+		if hasFreezeException(owner, repo, pr.Number, pr.Head.Sha) {
+			return nil
+		}
+		event := &gitee.NoteEvent{}
+		event.Repository = &gitee.ProjectHook{Namespace: owner, Path: repo, Name: repo}
+		event.PullRequest = pullRequestHookOf(pr)
+		event.Comment = &gitee.NoteHook{}
+		return s.RemoveSpecifyLabelsInPulRequest(event, map[string]string{
+			LabelFreezeException: LabelFreezeException})
+	}
+	return nil
+}
+
+func containsUser(users []string, user string) bool {
+	for _, u := range users {
+		if strings.EqualFold(u, user) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,96 @@
+package cibot
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"gitee.com/openeuler/ci-bot/pkg/cibot/database"
+	"gitee.com/openeuler/go-gitee/gitee"
+)
+
+func TestFreezeExceptionBoundToSha(t *testing.T) {
+	defer useFakeDB(t)()
+	request := database.FreezeExceptions{Owner: "openeuler", Repo: "community", Number: 1,
+		Branch: "master", Requester: "author", Reason: "security fix"}
+	if err := database.DBConnection.Create(&request).Error; err != nil {
+		t.Fatal(err)
+	}
+	if hasFreezeException("openeuler", "community", 1, "sha-a") {
+		t.Errorf("hasFreezeException(sha-a) = true before approval, want false")
+	}
+	if err := approveFreezeException("openeuler", "community", 1, "master", "owner", "sha-a"); err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		number int32
+		sha    string
+		want   bool
+	}{
+		{1, "sha-a", true},
+		{1, "sha-b", false},
+		{1, "", false},
+		{2, "sha-a", false},
+	}
+	for _, tt := range tests {
+		if got := hasFreezeException("openeuler", "community", tt.number, tt.sha); got != tt.want {
+			t.Errorf("hasFreezeException(%d, %q) = %v, want %v", tt.number, tt.sha, got, tt.want)
+		}
+	}
+
+	// the approval is recorded on the request instead of a new exception
+	var count int
+	if err := database.DBConnection.Model(&database.FreezeExceptions{}).Count(&count).Error; err != nil {
+		t.Fatal(err)
+	}
+	if count != 1 {
+		t.Errorf("freeze exceptions = %d, want 1", count)
+	}
+}
+
+func TestDropFreezeException(t *testing.T) {
+	defer useFakeDB(t)()
+	if err := approveFreezeException("openeuler", "community", 1, "master", "owner", "sha-a"); err != nil {
+		t.Fatal(err)
+	}
+	var patched []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.Method {
+		case http.MethodGet:
+			json.NewEncoder(w).Encode(gitee.PullRequest{Number: 1,
+				Labels: []gitee.Label{{Name: LabelFreezeException}, {Name: "lgtm"}}})
+		case http.MethodPatch:
+			var body gitee.PullRequestUpdateParam
+			data, _ := ioutil.ReadAll(r.Body)
+			json.Unmarshal(data, &body)
+			patched = append(patched, body.Labels)
+			json.NewEncoder(w).Encode(gitee.PullRequest{Number: 1})
+		}
+	}))
+	defer server.Close()
+	conf := gitee.NewConfiguration()
+	conf.BasePath = server.URL
+	s := &Server{Context: context.Background(), GiteeClient: gitee.NewAPIClient(conf)}
+
+	pr := gitee.PullRequest{Number: 1, Head: &gitee.BasicInfo{Sha: "sha-a"},
+		Labels: []gitee.Label{{Name: LabelFreezeException}, {Name: "lgtm"}}}
+	if err := s.dropFreezeException("openeuler", "community", pr); err != nil {
+		t.Fatal(err)
+	}
+	if len(patched) != 0 {
+		t.Errorf("labels patched to %v on the approved sha, want no change", patched)
+	}
+
+	// a new commit is pushed after the approval
+	pr.Head = &gitee.BasicInfo{Sha: "sha-b"}
+	if err := s.dropFreezeException("openeuler", "community", pr); err != nil {
+		t.Fatal(err)
+	}
+	if len(patched) != 1 || patched[0] != "lgtm" {
+		t.Errorf("labels patched to %v on the new sha, want [lgtm]", patched)
+	}
+}
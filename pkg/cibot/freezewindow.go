@@ -0,0 +1,245 @@
+package cibot
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"gitee.com/openeuler/ci-bot/pkg/cibot/database"
+	"gitee.com/openeuler/go-gitee/gitee"
+	"github.com/antihax/optional"
+	"github.com/golang/glog"
+)
+
+const (
+	freezeRecurringDaily   = "daily"
+	freezeRecurringWeekly  = "weekly"
+	freezeRecurringMonthly = "monthly"
+)
+
+// the layouts of freeze_start and freeze_end
+var freezeTimeLayouts = []string{time.RFC3339, "2006-01-02 15:04:05", "2006-01-02 15:04", "2006-01-02"}
+
+// frozenBranch is the branch of repositories in a community
+type frozenBranch struct {
+	community string
+	branch    string
+}
+
+// parseFreezeWindow parses the freeze window of branch
+func (v *FrozenBranchYaml) parseFreezeWindow() error {
+	loc := time.UTC
+	if v.TimeZone != "" {
+		l, err := time.LoadLocation(v.TimeZone)
+		if err != nil {
+			return err
+		}
+		loc = l
+	}
+	var err error
+	if v.FreezeStart != "" {
+		if v.start, err = parseFreezeTime(v.FreezeStart, loc); err != nil {
+			return err
+		}
+	}
+	if v.FreezeEnd != "" {
+		if v.end, err = parseFreezeTime(v.FreezeEnd, loc); err != nil {
+			return err
+		}
+	}
+	if !v.start.IsZero() && !v.end.IsZero() && !v.end.After(v.start) {
+		return errors.New("freeze_end must be after freeze_start")
+	}
+	if v.Recurring == "" {
+		return nil
+	}
+	if v.start.IsZero() || v.end.IsZero() {
+		return errors.New("recurring freeze requires both freeze_start and freeze_end")
+	}
+	// the window must end before it recurs
+	var period time.Duration
+	switch v.Recurring {
+	case freezeRecurringDaily:
+		period = 24 * time.Hour
+	case freezeRecurringWeekly:
+		period = 7 * 24 * time.Hour
+	case freezeRecurringMonthly:
+		period = 28 * 24 * time.Hour
+	default:
+		return fmt.Errorf("unknown recurring %q", v.Recurring)
+	}
+	if v.end.Sub(v.start) >= period {
+		return fmt.Errorf("freeze window is longer than its %s recurrence", v.Recurring)
+	}
+	return nil
+}
+
+func parseFreezeTime(value string, loc *time.Location) (time.Time, error) {
+	for _, layout := range freezeTimeLayouts {
+		t, err := time.ParseInLocation(layout, value, loc)
+		if err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("invalid freeze time %q", value)
+}
+
+func (v FrozenBranchYaml) hasFreezeWindow() bool {
+	return !v.start.IsZero() || !v.end.IsZero()
+}
+
+// occurrence gets the start of the kth recurrence of the freeze window
+func (v FrozenBranchYaml) occurrence(k int) time.Time {
+	switch v.Recurring {
+	case freezeRecurringDaily:
+		return v.start.AddDate(0, 0, k)
+	case freezeRecurringWeekly:
+		return v.start.AddDate(0, 0, 7*k)
+	case freezeRecurringMonthly:
+		return v.start.AddDate(0, k, 0)
+	}
+	return v.start
+}
+
+// frozenUntil checks whether the branch is frozen at t, and gets the end of the freeze
+// which is zero if the freeze is not scheduled to end
+func (v FrozenBranchYaml) frozenUntil(t time.Time) (time.Time, bool) {
+	if !v.hasFreezeWindow() {
+		return time.Time{}, v.Frozen
+	}
+	if !v.start.IsZero() && t.Before(v.start) {
+		return time.Time{}, false
+	}
+	if v.Recurring == "" {
+		if !v.end.IsZero() && !t.Before(v.end) {
+			return time.Time{}, false
+		}
+		return v.end, true
+	}
+
+	// estimate the latest recurrence started not after t, and correct it
+	// since the days of months and daylight saving time vary
+	var k int
+	switch v.Recurring {
+	case freezeRecurringDaily:
+		k = int(t.Sub(v.start) / (24 * time.Hour))
+	case freezeRecurringWeekly:
+		k = int(t.Sub(v.start) / (7 * 24 * time.Hour))
+	case freezeRecurringMonthly:
+		st := v.start.In(t.Location())
+		k = (t.Year()-st.Year())*12 + int(t.Month()) - int(st.Month())
+	}
+	for k > 0 && v.occurrence(k).After(t) {
+		k--
+	}
+	for !v.occurrence(k + 1).After(t) {
+		k++
+	}
+	end := v.occurrence(k).Add(v.end.Sub(v.start))
+	if !t.Before(end) {
+		return time.Time{}, false
+	}
+	return end, true
+}
+
+// frozenStatesAt gets whether the branches in frozen list are frozen at t, with the owners
+// and the end of the freezes
+func frozenStatesAt(t time.Time) (map[frozenBranch]bool, map[frozenBranch][]string, map[frozenBranch]time.Time) {
+	lock.RLock()
+	defer lock.RUnlock()
+	states := make(map[frozenBranch]bool)
+	owners := make(map[frozenBranch][]string)
+	ends := make(map[frozenBranch]time.Time)
+	for _, v := range frozenList {
+		end, frozen := v.frozenUntil(t)
+		for _, c := range v.Communtiy {
+			key := frozenBranch{community: c, branch: v.Branch}
+			if !frozen {
+				if _, ok := states[key]; !ok {
+					states[key] = false
+				}
+				continue
+			}
+			states[key] = true
+			owners[key] = append(owners[key], v.Owner...)
+			if e, ok := ends[key]; !ok || (!e.IsZero() && (end.IsZero() || end.After(e))) {
+				ends[key] = end
+			}
+		}
+	}
+	return states, owners, ends
+}
+
+// noticeFreezeChanges posts notices on the open pull requests targeting the branches
+// whose freeze begins or ends since last time
+func (fh *FrozenHandler) noticeFreezeChanges() {
+	states, owners, ends := frozenStatesAt(time.Now())
+	previous := fh.states
+	fh.states = states
+	// the states before starting are unknown
+	if previous == nil {
+		return
+	}
+	for key, frozen := range states {
+		if previous[key] == frozen {
+			continue
+		}
+		glog.Infof("freeze of branch %s in %s changed to %v", key.branch, key.community, frozen)
+		fh.noticeFreeze(key, frozen, owners[key], ends[key])
+	}
+	for key, frozen := range previous {
+		if _, ok := states[key]; !ok && frozen {
+			glog.Infof("freeze of branch %s in %s is removed", key.branch, key.community)
+			fh.noticeFreeze(key, false, nil, time.Time{})
+		}
+	}
+}
+
+// noticeFreeze posts the notice on the open pull requests targeting the branch in community
+func (fh *FrozenHandler) noticeFreeze(key frozenBranch, frozen bool, owners []string, end time.Time) {
+	s := &Server{
+		Config:      fh.Config,
+		Context:     fh.Context,
+		GiteeClient: fh.GiteeClient,
+	}
+	var rs []database.Repositories
+	err := database.DBConnection.Model(&database.Repositories{}).Where("owner = ?", key.community).Find(&rs).Error
+	if err != nil {
+		glog.Errorf("unable to get repos of %s: %v", key.community, err)
+		return
+	}
+	data := MessageData{"Branch": key.branch, "Owners": owners}
+	if !end.IsZero() {
+		data["End"] = end.Format("2006-01-02 15:04 MST")
+	}
+	id := freezeEndedMessage
+	if frozen {
+		id = freezeStartedMessage
+	}
+
+	lvos := &gitee.GetV5ReposOwnerRepoPullsOpts{}
+	lvos.AccessToken = optional.NewString(fh.Config.GiteeToken)
+	lvos.State = optional.NewString("open")
+	lvos.Base = optional.NewString(key.branch)
+	lvos.PerPage = optional.NewInt32(100)
+	for _, r := range rs {
+		comment := s.message(r.Owner, r.Repo, id, data)
+		for page := int32(1); ; page++ {
+			lvos.Page = optional.NewInt32(page)
+			prs, _, err := fh.GiteeClient.PullRequestsApi.GetV5ReposOwnerRepoPulls(fh.Context, r.Owner, r.Repo, lvos)
+			if err != nil {
+				glog.Errorf("unable to list pull requests of %s/%s: %v", r.Owner, r.Repo, err)
+				break
+			}
+			for _, pr := range prs {
+				err = s.addCommentToPullRequest(r.Owner, r.Repo, comment, pr.Number)
+				if err != nil {
+					glog.Errorf("unable to add freeze notice to %s/%s/%d: %v", r.Owner, r.Repo, pr.Number, err)
+				}
+			}
+			if len(prs) < 100 {
+				break
+			}
+		}
+	}
+}
@@ -0,0 +1,85 @@
+package cibot
+
+import (
+	"testing"
+	"time"
+)
+
+func TestFrozenUntil(t *testing.T) {
+	at := func(value string) time.Time {
+		v, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return v
+	}
+	tests := []struct {
+		name      string
+		branch    FrozenBranchYaml
+		now       string
+		want      bool
+		wantUntil string
+	}{
+		{"frozen flag", FrozenBranchYaml{Frozen: true}, "2021-06-01T00:00:00Z", true, ""},
+		{"not frozen", FrozenBranchYaml{}, "2021-06-01T00:00:00Z", false, ""},
+		{"before window", FrozenBranchYaml{FreezeStart: "2021-06-01 08:00", FreezeEnd: "2021-06-10 08:00"},
+			"2021-06-01T07:59:00Z", false, ""},
+		{"in window", FrozenBranchYaml{Frozen: true, FreezeStart: "2021-06-01 08:00", FreezeEnd: "2021-06-10 08:00"},
+			"2021-06-05T00:00:00Z", true, "2021-06-10T08:00:00Z"},
+		{"after window", FrozenBranchYaml{Frozen: true, FreezeStart: "2021-06-01 08:00", FreezeEnd: "2021-06-10 08:00"},
+			"2021-06-10T08:00:00Z", false, ""},
+		{"start only", FrozenBranchYaml{FreezeStart: "2021-06-01T08:00:00+08:00"}, "2021-06-01T00:00:00Z", true, ""},
+		{"weekly in window", FrozenBranchYaml{FreezeStart: "2021-06-04 18:00", FreezeEnd: "2021-06-07 08:00", Recurring: "weekly"},
+			"2021-06-20T12:00:00Z", true, "2021-06-21T08:00:00Z"},
+		{"weekly out of window", FrozenBranchYaml{FreezeStart: "2021-06-04 18:00", FreezeEnd: "2021-06-07 08:00", Recurring: "weekly"},
+			"2021-06-23T12:00:00Z", false, ""},
+		{"monthly in window", FrozenBranchYaml{FreezeStart: "2021-01-28", FreezeEnd: "2021-02-01", Recurring: "monthly"},
+			"2021-07-30T00:00:00Z", true, "2021-08-01T00:00:00Z"},
+		{"daily in window", FrozenBranchYaml{FreezeStart: "2021-06-01 22:00", FreezeEnd: "2021-06-02 02:00", Recurring: "daily"},
+			"2021-09-09T01:00:00Z", true, "2021-09-09T02:00:00Z"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := tt.branch
+			if err := b.parseFreezeWindow(); err != nil {
+				t.Fatalf("parseFreezeWindow() error = %v", err)
+			}
+			until, got := b.frozenUntil(at(tt.now))
+			if got != tt.want {
+				t.Errorf("frozenUntil() frozen = %v, want %v", got, tt.want)
+			}
+			if tt.wantUntil == "" && !until.IsZero() || tt.wantUntil != "" && !until.Equal(at(tt.wantUntil)) {
+				t.Errorf("frozenUntil() until = %v, want %v", until, tt.wantUntil)
+			}
+		})
+	}
+}
+
+func TestParseFreezeWindowInvalid(t *testing.T) {
+	tests := []FrozenBranchYaml{
+		{FreezeStart: "June 1st"},
+		{FreezeStart: "2021-06-10", FreezeEnd: "2021-06-01"},
+		{FreezeStart: "2021-06-01", Recurring: "weekly"},
+		{FreezeStart: "2021-06-01", FreezeEnd: "2021-06-09", Recurring: "weekly"},
+		{FreezeStart: "2021-06-01", FreezeEnd: "2021-06-02", Recurring: "yearly"},
+	}
+	for _, b := range tests {
+		if err := b.parseFreezeWindow(); err == nil {
+			t.Errorf("parseFreezeWindow(%+v) is expected to fail", b)
+		}
+	}
+}
+
+func TestExtractFrozenBranchInvalidWindow(t *testing.T) {
+	defer extractFrozenBranch(nil)
+	extractFrozenBranch([]FrozenBranchYaml{
+		{Branch: "master", Communtiy: []string{"openeuler"}, Frozen: true, FreezeStart: "June 1st"},
+		{Branch: "stable", Communtiy: []string{"openeuler"}, FreezeStart: "June 1st"},
+	})
+	if _, frozen := IsBranchFrozen("master", "openeuler"); !frozen {
+		t.Errorf("IsBranchFrozen(master) = false, want true")
+	}
+	if _, frozen := IsBranchFrozen("stable", "openeuler"); frozen {
+		t.Errorf("IsBranchFrozen(stable) = true, want false")
+	}
+}
@@ -19,6 +19,9 @@ type FrozenHandler struct {
 	Config      config.Config
 	Context     context.Context
 	GiteeClient *gitee.APIClient
+
+	// the freeze states noticed to pull requests
+	states map[frozenBranch]bool
 }
 
 type freezeFile struct {
@@ -39,6 +42,18 @@ type FrozenBranchYaml struct {
 	Frozen          bool     `yaml:"frozen"`
 	Owner           []string `yaml:"owner"`
 	Communtiy       []string `yaml:"community"`
+	// the freeze window in RFC3339 or "2006-01-02 15:04", frozen is used if neither is set
+	FreezeStart string `yaml:"freeze_start"`
+	FreezeEnd   string `yaml:"freeze_end"`
+	// repeats the freeze window daily, weekly or monthly
+	Recurring string `yaml:"recurring"`
+	// the time zone of timestamps without offset, e.g. Asia/Shanghai, UTC by default
+	TimeZone string `yaml:"timezone"`
+	// the lifecycle state: development, maintenance, security-only or eol
+	State string `yaml:"state"`
+
+	start time.Time
+	end   time.Time
 }
 
 var
@@ -104,16 +119,21 @@ func (fh *FrozenHandler) watch() {
 	for {
 		fileContent, changed, err := fh.getFrozenFileContent()
 		if err != nil {
-			emptyFrozenList()
+			// the last obtained frozen list and states are kept if the file can not be obtained
 			glog.Error(err)
 		} else {
+			var parseErr error
 			if changed {
-				err := handleContent(fileContent)
-				if err != nil {
-					glog.Error(err)
-					emptyFrozenList()
+				// the previous frozen list and states are kept if the file can not be parsed
+				parseErr = handleContent(fileContent)
+				if parseErr != nil {
+					glog.Error(parseErr)
 				}
 			}
+			// the frozen list is unreliable if the file is not obtained or parsed
+			if parseErr == nil {
+				fh.noticeFreezeChanges()
+			}
 		}
 		time.Sleep(time.Duration(watchDuration) * time.Second)
 	}
@@ -154,10 +174,20 @@ func extractFrozenBranch(release []FrozenBranchYaml) {
 	emptyFrozenList()
 	var fs []FrozenBranchYaml
 	for _, v := range release {
-		if v.Frozen {
+		err := v.parseFreezeWindow()
+		if err != nil {
+			glog.Errorf("invalid freeze window of branch %s: %v", v.Branch, err)
+			if !v.Frozen {
+				continue
+			}
+			// the branch declared frozen is kept frozen without the invalid window
+			v.start, v.end, v.Recurring = time.Time{}, time.Time{}, ""
+		}
+		if v.Frozen || v.hasFreezeWindow() {
 			fs = append(fs, v)
 		}
 	}
+	writeBranchStates(release)
 	if len(fs) > 0 {
 		writeFrozenList(fs)
 	} else {
@@ -191,7 +221,11 @@ func IsBranchFrozen(branch string, community string) (owner []string, isFrozen b
 	if len(frozenList) == 0 {
 		return nil, isFrozen
 	}
+	now := time.Now()
 	for _, v := range frozenList {
+		if _, frozen := v.frozenUntil(now); !frozen {
+			continue
+		}
 		var isCommunityFrozen = false
 		for _, c := range v.Communtiy {
 			if c == community {
@@ -2,7 +2,6 @@ package cibot
 
 import (
 	"fmt"
-	"strings"
 	"gitee.com/openeuler/ci-bot/pkg/cibot/database"
 	"gitee.com/openeuler/go-gitee/gitee"
 	"github.com/golang/glog"
@@ -49,23 +48,24 @@ func (s *Server) HandleIssueEvent(event *gitee.IssueEvent) {
 		var committors []string
 		if len(ps) > 0 {
 			for _, p := range ps {
-				committors = append(committors, fmt.Sprintf("***@%s***", p.User))
+				committors = append(committors, p.User)
 				if limitNotice && (len(committors) >= s.Config.LimitMemberCnt) {
 					break
 				}
 			}
 		}
-		committor_list := strings.Join(committors, ", ")
-		if len(committor_list) > 0 {
-			sigPath := fmt.Sprintf(SigPath, sigName)
-			proInfo := fmt.Sprintf(DisplayCommittors, sigName, sigPath)
-			committor_list = proInfo + committor_list + "."
+
+		// assign a maintainer of the sig to triage
+		assignee, err := s.TriageIssue(event, sigName)
+		if err != nil {
+			glog.Errorf("unable to triage issue: %v", err)
 		}
 
 		// add comment
 		body := gitee.IssueCommentPostParam{}
 		body.AccessToken = s.Config.GiteeToken
-		body.Body = fmt.Sprintf(tipBotMessage, event.Sender.Login, s.Config.CommunityName, s.Config.CommandLink, committor_list)
+		body.Body = s.message(event.Repository.Namespace, event.Repository.Path, tipBotMessage, MessageData{
+			"Author": event.Sender.Login, "Sig": sigName, "Committors": committors, "Assignee": assignee})
 		//Issue could exists without belonging to any repo.
 		if event.Repository == nil {
 			glog.Warningf("Issue is not created on repo, skip posting issue comment.")
@@ -75,5 +75,25 @@ func (s *Server) HandleIssueEvent(event *gitee.IssueEvent) {
 		if err != nil {
 			glog.Errorf("unable to add comment in issue: %v", err)
 		}
+
+		// check the required sections of issue template
+		err = s.CheckIssueTemplate(event.Repository, event.Issue, true)
+		if err != nil {
+			glog.Errorf("unable to check issue template: %v", err)
+		}
+	case "update":
+		glog.Info("received a issue update event")
+
+		// the body may be edited to complete the required sections, the missing ones are commented only on opening
+		err := s.CheckIssueTemplate(event.Repository, event.Issue, false)
+		if err != nil {
+			glog.Errorf("unable to check issue template: %v", err)
+		}
+	case "state_change", "assign", "delete":
+		// the open issues of assignees are counted by the triages
+		err := s.UpdateIssueTriage(event)
+		if err != nil {
+			glog.Errorf("unable to update issue triage: %v", err)
+		}
 	}
 }
@@ -0,0 +1,158 @@
+package cibot
+
+import (
+	"path"
+	"regexp"
+	"strings"
+
+	"gitee.com/openeuler/ci-bot/pkg/cibot/config"
+	"gitee.com/openeuler/go-gitee/gitee"
+	"github.com/golang/glog"
+)
+
+const (
+	defaultNeedsInfoLabel = "needs-info"
+)
+
+var (
+	// regHTMLComment finds the hints of issue template, which are not the content of sections
+	regHTMLComment = regexp.MustCompile(`(?s)<!--.*?-->`)
+	// regSectionDecoration finds the heading marks and emphasis around the title of section
+	regSectionDecoration = regexp.MustCompile(`^[#*_>\s-]+|[*_\s]+$`)
+)
+
+// issueTemplateOf gets the first template applied to the repository
+func issueTemplateOf(templates []config.IssueTemplate, owner, repo string) *config.IssueTemplate {
+	for i, t := range templates {
+		for _, pattern := range t.Repos {
+			if matched, _ := path.Match(pattern, owner+"/"+repo); matched {
+				return &templates[i]
+			}
+		}
+	}
+	return nil
+}
+
+// sectionTitleOf gets the section of line, and the content following the title in the same line,
+// e.g. "## Environment", "**Environment**" and "Environment: x86_64"
+func sectionTitleOf(line string, sections []string) (string, string, bool) {
+	title := regSectionDecoration.ReplaceAllString(line, "")
+	for _, s := range sections {
+		if strings.EqualFold(strings.TrimRight(title, ":："), s) {
+			return s, "", true
+		}
+		if len(title) <= len(s) || !strings.EqualFold(title[:len(s)], s) {
+			continue
+		}
+		rest := strings.TrimSpace(title[len(s):])
+		if strings.HasPrefix(rest, ":") || strings.HasPrefix(rest, "：") {
+			rest = strings.TrimLeft(rest, ":：")
+			return s, strings.Trim(strings.TrimSpace(rest), "*_"), true
+		}
+	}
+	return "", "", false
+}
+
+// missingSections gets the required sections which are absent or empty in the body of issue
+func missingSections(body string, required []string) []string {
+	filled := make(map[string]bool)
+	current := ""
+	for _, line := range strings.Split(regHTMLComment.ReplaceAllString(body, ""), "\n") {
+		if s, content, ok := sectionTitleOf(line, required); ok {
+			current = s
+			if strings.TrimSpace(content) != "" {
+				filled[current] = true
+			}
+			continue
+		}
+		// the other headings end the section
+		if strings.HasPrefix(strings.TrimSpace(line), "#") {
+			current = ""
+			continue
+		}
+		if current != "" && strings.TrimSpace(line) != "" {
+			filled[current] = true
+		}
+	}
+	var missing []string
+	for _, s := range required {
+		if !filled[s] {
+			missing = append(missing, s)
+		}
+	}
+	return missing
+}
+
+func (s *Server) needsInfoLabel() string {
+	if s.Config.NeedsInfoLabel != "" {
+		return s.Config.NeedsInfoLabel
+	}
+	return defaultNeedsInfoLabel
+}
+
+// CheckIssueTemplate labels the issue with needs info if the required sections are missing in its body,
+// and removes the label once the body is complete. The missing sections are commented if notify is true.
+func (s *Server) CheckIssueTemplate(repository *gitee.ProjectHook, issue *gitee.IssueHook, notify bool) error {
+	if repository == nil || issue == nil {
+		return nil
+	}
+	owner := repository.Namespace
+	repo := repository.Path
+	tmpl := issueTemplateOf(s.Config.IssueTemplates, owner, repo)
+	if tmpl == nil || issue.State == "closed" || issue.State == "rejected" {
+		return nil
+	}
+	label := s.needsInfoLabel()
+	hasLabel := false
+	for _, l := range issue.Labels {
+		if l.Name == label {
+			hasLabel = true
+			break
+		}
+	}
+
+	missing := missingSections(issue.Body, tmpl.RequiredSections)
+	glog.Infof("issue %s/%s/%s misses sections %v", owner, repo, issue.Number, missing)
+	if len(missing) == 0 {
+		if !hasLabel {
+			return nil
+		}
+		return s.RemoveSpecifyLabelsInIssue(owner, repo, issue.Number, map[string]string{label: label})
+	}
+	if !hasLabel {
+		err := s.AddSpecifyLabelsInIssue(owner, repo, issue.Number, []string{label}, true)
+		if err != nil {
+			return err
+		}
+	}
+	if !notify {
+		return nil
+	}
+	author := ""
+	if issue.User != nil {
+		author = issue.User.Login
+	}
+	body := gitee.IssueCommentPostParam{}
+	body.AccessToken = s.Config.GiteeToken
+	body.Body = s.message(owner, repo, issueNeedsInfoMessage, MessageData{
+		"Author": author, "Sections": missing, "Label": label})
+	_, _, err := s.GiteeClient.IssuesApi.PostV5ReposOwnerRepoIssuesNumberComments(s.Context, owner, repo, issue.Number, body)
+	if err != nil {
+		glog.Errorf("unable to add comment in issue: %v", err)
+		return err
+	}
+	return nil
+}
+
+// CheckIssueTemplateByNoteEvent re-checks the issue when its author comments,
+// the body may be completed without an issue event
+func (s *Server) CheckIssueTemplateByNoteEvent(event *gitee.NoteEvent) error {
+	if event.NoteableType == nil || *event.NoteableType != "Issue" || event.Issue == nil {
+		return nil
+	}
+	if event.Comment == nil || event.Comment.User == nil || event.Issue.User == nil ||
+		!strings.EqualFold(event.Comment.User.Login, event.Issue.User.Login) {
+		return nil
+	}
+	return s.CheckIssueTemplate(event.Repository, event.Issue, false)
+}
@@ -0,0 +1,28 @@
+package cibot
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestMissingSections(t *testing.T) {
+	required := []string{"Environment", "Steps to reproduce", "Expected result"}
+	tests := []struct {
+		name string
+		body string
+		want []string
+	}{
+		{"empty", "", required},
+		{"complete", "## Environment\nopenEuler 20.03\n## Steps to reproduce\n1. run\n## Expected result\nno crash", nil},
+		{"template hints only", "## Environment\n<!-- the os and arch -->\n\n## Steps to reproduce\n1. run\n## Expected result\n",
+			[]string{"Environment", "Expected result"}},
+		{"inline and emphasis", "Environment: x86_64\n**steps to reproduce**\nrun it\n### Logs\nexpected result is in logs",
+			[]string{"Expected result"}},
+		{"heading with colon", "# Environment：\naarch64\n## Steps to reproduce:\nrun\n## Expected result\nok", nil},
+	}
+	for _, tt := range tests {
+		if got := missingSections(tt.body, required); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: missingSections = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
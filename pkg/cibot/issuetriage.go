@@ -0,0 +1,234 @@
+package cibot
+
+import (
+	"path"
+	"sort"
+	"strings"
+	"time"
+
+	"gitee.com/openeuler/ci-bot/pkg/cibot/config"
+	"gitee.com/openeuler/ci-bot/pkg/cibot/database"
+	"gitee.com/openeuler/go-gitee/gitee"
+	"github.com/antihax/optional"
+	"github.com/golang/glog"
+	"github.com/jinzhu/gorm"
+)
+
+const (
+	IssueTriageRoundRobin = "round-robin"
+	IssueTriageLeastOpen  = "least-open"
+)
+
+// availableMaintainers gets the sorted maintainers except the author, the opted out ones and the ones on vacation,
+// the author is kept if no one else is available
+func availableMaintainers(maintainers []string, author string, cfg config.IssueTriage, now time.Time) []string {
+	unavailable := func(m string) bool {
+		if containsUser(cfg.OptOut, m) {
+			return true
+		}
+		for _, v := range cfg.Vacations {
+			if !strings.EqualFold(v.Login, m) {
+				continue
+			}
+			if v.Until == "" {
+				return true
+			}
+			until, err := time.ParseInLocation("2006-01-02", v.Until, time.Local)
+			if err != nil {
+				glog.Errorf("invalid vacation of %s until %s: %v", v.Login, v.Until, err)
+				continue
+			}
+			// the vacation includes the day of until
+			if now.Before(until.AddDate(0, 0, 1)) {
+				return true
+			}
+		}
+		return false
+	}
+	var available []string
+	authorAvailable := false
+	for _, m := range maintainers {
+		if unavailable(m) {
+			continue
+		}
+		if strings.EqualFold(m, author) {
+			authorAvailable = true
+			continue
+		}
+		available = append(available, m)
+	}
+	if len(available) == 0 && authorAvailable {
+		available = append(available, author)
+	}
+	sort.Strings(available)
+	return available
+}
+
+// nextRoundRobin gets the candidate after the last assigned one
+func nextRoundRobin(candidates []string, last string) string {
+	if len(candidates) == 0 {
+		return ""
+	}
+	for i, c := range candidates {
+		if strings.EqualFold(c, last) {
+			return candidates[(i+1)%len(candidates)]
+		}
+	}
+	// the last one may be unavailable now, so the one after it in order takes the turn
+	for _, c := range candidates {
+		if strings.ToLower(c) > strings.ToLower(last) {
+			return c
+		}
+	}
+	return candidates[0]
+}
+
+// leastOpen gets the candidate with the least open issues, the earlier in order if equal
+func leastOpen(candidates []string, openIssues map[string]int) string {
+	assignee := ""
+	for _, c := range candidates {
+		if assignee == "" || openIssues[strings.ToLower(c)] < openIssues[strings.ToLower(assignee)] {
+			assignee = c
+		}
+	}
+	return assignee
+}
+
+// isTriageExemptRepo checks whether the repository matches the exempt patterns
+func isTriageExemptRepo(cfg config.IssueTriage, owner, repo string) bool {
+	for _, pattern := range cfg.ExemptRepos {
+		if matched, _ := path.Match(pattern, owner+"/"+repo); matched {
+			return true
+		}
+	}
+	return false
+}
+
+// openIssuesOfAssignees counts the open issues triaged in sig by their assignees in lower case
+func openIssuesOfAssignees(sig string) (map[string]int, error) {
+	var triages []database.IssueTriages
+	err := database.DBConnection.Where("sig = ? and open = ?", sig, true).Find(&triages).Error
+	if err != nil {
+		return nil, err
+	}
+	counts := make(map[string]int)
+	for _, t := range triages {
+		if t.Assignee != "" {
+			counts[strings.ToLower(t.Assignee)]++
+		}
+	}
+	return counts, nil
+}
+
+// pickTriageAssignee picks the maintainer of sig to triage the new issue by the strategy
+func (s *Server) pickTriageAssignee(sig string, candidates []string) (string, error) {
+	if s.Config.IssueTriage.Strategy == IssueTriageLeastOpen {
+		openIssues, err := openIssuesOfAssignees(sig)
+		if err != nil {
+			return "", err
+		}
+		return leastOpen(candidates, openIssues), nil
+	}
+	var last database.IssueTriages
+	err := database.DBConnection.Where("sig = ?", sig).Order("id desc").First(&last).Error
+	if err != nil && !gorm.IsRecordNotFoundError(err) {
+		return "", err
+	}
+	return nextRoundRobin(candidates, last.Assignee), nil
+}
+
+// TriageIssue assigns the new issue to a maintainer of the sig of repository, and gets the assignee
+func (s *Server) TriageIssue(event *gitee.IssueEvent, sig string) (string, error) {
+	cfg := s.Config.IssueTriage
+	if !cfg.Enable || sig == "" || event.Repository == nil || event.Issue == nil {
+		return "", nil
+	}
+	owner := event.Repository.Namespace
+	repo := event.Repository.Path
+	number := event.Issue.Number
+	if event.Issue.Assignee != nil || isTriageExemptRepo(cfg, owner, repo) {
+		return "", nil
+	}
+	maintainers, err := maintainersOfSigs([]string{sig}, owner, repo)
+	if err != nil {
+		return "", err
+	}
+	author := ""
+	if event.Issue.User != nil {
+		author = event.Issue.User.Login
+	}
+	candidates := availableMaintainers(maintainers, author, cfg, time.Now())
+	if len(candidates) == 0 {
+		glog.Infof("no maintainer of sig %s is available to triage issue %s/%s/%s", sig, owner, repo, number)
+		return "", nil
+	}
+	assignee, err := s.pickTriageAssignee(sig, candidates)
+	if err != nil {
+		return "", err
+	}
+
+	// the labels are kept by patching them with the assignee
+	lvos := &gitee.GetV5ReposOwnerRepoIssuesNumberLabelsOpts{}
+	lvos.AccessToken = optional.NewString(s.Config.GiteeToken)
+	labels, _, err := s.GiteeClient.LabelsApi.GetV5ReposOwnerRepoIssuesNumberLabels(s.Context, owner, repo, number, lvos)
+	if err != nil {
+		return "", err
+	}
+	names := make([]string, 0, len(labels))
+	for _, l := range labels {
+		names = append(names, l.Name)
+	}
+	strLabel := strings.Join(names, ",")
+	if strLabel == "" {
+		strLabel = ","
+	}
+	body := gitee.IssueUpdateParam{}
+	body.AccessToken = s.Config.GiteeToken
+	body.Repo = repo
+	body.Assignee = assignee
+	body.Labels = strLabel
+	_, _, err = s.GiteeClient.IssuesApi.PatchV5ReposOwnerIssuesNumber(s.Context, owner, number, body)
+	if err != nil {
+		return "", err
+	}
+	glog.Infof("issue %s/%s/%s is assigned to %s of sig %s", owner, repo, number, assignee, sig)
+
+	err = database.DBConnection.Create(&database.IssueTriages{
+		Owner:    owner,
+		Repo:     repo,
+		Number:   number,
+		Sig:      sig,
+		Assignee: assignee,
+		Open:     true,
+	}).Error
+	if err != nil {
+		glog.Errorf("unable to record issue triage: %v", err)
+	}
+	return assignee, nil
+}
+
+// UpdateIssueTriage keeps the state and assignee of the triaged issue up to date,
+// so that the open issues of assignees are counted without listing the issues of sig
+func (s *Server) UpdateIssueTriage(event *gitee.IssueEvent) error {
+	if !s.Config.IssueTriage.Enable || event.Repository == nil || event.Issue == nil {
+		return nil
+	}
+	updates := make(map[string]interface{})
+	switch *event.Action {
+	case "state_change":
+		updates["open"] = event.Issue.State != "closed" && event.Issue.State != "rejected"
+	case "assign":
+		assignee := ""
+		if event.Issue.Assignee != nil {
+			assignee = event.Issue.Assignee.Login
+		}
+		updates["assignee"] = assignee
+	case "delete":
+		updates["open"] = false
+	default:
+		return nil
+	}
+	return database.DBConnection.Model(&database.IssueTriages{}).
+		Where("owner = ? and repo = ? and number = ?", event.Repository.Namespace, event.Repository.Path, event.Issue.Number).
+		Updates(updates).Error
+}
@@ -0,0 +1,122 @@
+package cibot
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"gitee.com/openeuler/ci-bot/pkg/cibot/config"
+	"gitee.com/openeuler/ci-bot/pkg/cibot/database"
+	"gitee.com/openeuler/go-gitee/gitee"
+)
+
+func TestAvailableMaintainers(t *testing.T) {
+	now := time.Date(2020, 10, 8, 12, 0, 0, 0, time.Local)
+	cfg := config.IssueTriage{
+		OptOut: []string{"Dave"},
+		Vacations: []config.Vacation{
+			{Login: "bob", Until: "2020-10-08"},
+			{Login: "carol", Until: "2020-10-07"},
+			{Login: "erin"},
+		},
+	}
+	maintainers := []string{"frank", "bob", "carol", "dave", "erin", "alice"}
+	tests := []struct {
+		name        string
+		maintainers []string
+		author      string
+		want        []string
+	}{
+		{"available", maintainers, "", []string{"alice", "carol", "frank"}},
+		{"author skipped", maintainers, "Alice", []string{"carol", "frank"}},
+		{"author only", []string{"alice", "bob"}, "alice", []string{"alice"}},
+		{"none", []string{"bob", "dave"}, "", nil},
+	}
+	for _, tt := range tests {
+		if got := availableMaintainers(tt.maintainers, tt.author, cfg, now); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: availableMaintainers = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestNextRoundRobin(t *testing.T) {
+	candidates := []string{"alice", "carol", "frank"}
+	tests := []struct {
+		last string
+		want string
+	}{
+		{"", "alice"},
+		{"alice", "carol"},
+		{"Frank", "alice"},
+		{"bob", "carol"},
+		{"zoe", "alice"},
+	}
+	for _, tt := range tests {
+		if got := nextRoundRobin(candidates, tt.last); got != tt.want {
+			t.Errorf("nextRoundRobin(%q) = %s, want %s", tt.last, got, tt.want)
+		}
+	}
+}
+
+func TestLeastOpen(t *testing.T) {
+	candidates := []string{"alice", "carol", "frank"}
+	if got := leastOpen(candidates, map[string]int{"alice": 3, "carol": 1}); got != "frank" {
+		t.Errorf("leastOpen = %s, want frank", got)
+	}
+	if got := leastOpen(candidates, map[string]int{"alice": 1, "carol": 1, "frank": 2}); got != "alice" {
+		t.Errorf("leastOpen equal = %s, want alice", got)
+	}
+}
+
+func TestOpenIssuesOfAssignees(t *testing.T) {
+	defer useFakeDB(t)()
+	triages := []database.IssueTriages{
+		{Owner: "openeuler", Repo: "kernel", Number: "I1", Sig: "Kernel", Assignee: "Alice", Open: true},
+		{Owner: "openeuler", Repo: "kernel", Number: "I2", Sig: "Kernel", Assignee: "alice", Open: true},
+		{Owner: "src-openeuler", Repo: "kernel", Number: "I3", Sig: "Kernel", Assignee: "bob", Open: true},
+		{Owner: "openeuler", Repo: "docs", Number: "I4", Sig: "Doc", Assignee: "bob", Open: true},
+	}
+	for i := range triages {
+		if err := database.DBConnection.Create(&triages[i]).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+	s := &Server{Config: config.Config{IssueTriage: config.IssueTriage{Enable: true}}}
+	event := func(action, number, state, assignee string) *gitee.IssueEvent {
+		e := &gitee.IssueEvent{Action: &action,
+			Repository: &gitee.ProjectHook{Namespace: "openeuler", Path: "kernel"},
+			Issue:      &gitee.IssueHook{Number: number, State: state}}
+		if assignee != "" {
+			e.Issue.Assignee = &gitee.UserHook{Login: assignee}
+		}
+		return e
+	}
+	for _, e := range []*gitee.IssueEvent{
+		event("state_change", "I1", "closed", "Alice"),
+		event("assign", "I2", "open", "carol"),
+		event("update", "I2", "open", "dave"),
+	} {
+		if err := s.UpdateIssueTriage(e); err != nil {
+			t.Fatal(err)
+		}
+	}
+	counts, err := openIssuesOfAssignees("Kernel")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := map[string]int{"carol": 1, "bob": 1}; !reflect.DeepEqual(counts, want) {
+		t.Errorf("openIssuesOfAssignees() = %v, want %v", counts, want)
+	}
+
+	// the reopened issue is counted again
+	if err = s.UpdateIssueTriage(event("state_change", "I1", "open", "Alice")); err != nil {
+		t.Fatal(err)
+	}
+	counts, err = openIssuesOfAssignees("Kernel")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if counts["alice"] != 1 {
+		t.Errorf("open issues of alice after reopening = %d, want 1", counts["alice"])
+	}
+}
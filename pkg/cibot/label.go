@@ -674,3 +674,81 @@ func (s *Server) RemoveSpecifyLabelsInPulRequest(event *gitee.NoteEvent, mapOfRe
 
 	return nil
 }
+
+// AddSpecifyLabelsInIssue adds specify labels in issue
+func (s *Server) AddSpecifyLabelsInIssue(owner, repo, number string, newLabels []string, createNew bool) error {
+	newLabels = truncateLabel(newLabels)
+	glog.Infof("add specify label started. owner: %s repo: %s number: %s labels: %v", owner, repo, number, newLabels)
+
+	// patch repo labels
+	legalLabels, err := s.patchRepoLabels(newLabels, owner, repo, createNew)
+	if err != nil {
+		return err
+	}
+
+	// list labels in current item
+	lvos := &gitee.GetV5ReposOwnerRepoIssuesNumberLabelsOpts{}
+	lvos.AccessToken = optional.NewString(s.Config.GiteeToken)
+	listofItemLabels, _, err := s.GiteeClient.LabelsApi.GetV5ReposOwnerRepoIssuesNumberLabels(s.Context, owner, repo, number, lvos)
+	if err != nil {
+		glog.Errorf("unable to get labels in issue. err: %v", err)
+		return err
+	}
+	listOfAddLabels, _ := s.labelDiffer(legalLabels, listofItemLabels)
+	if len(listOfAddLabels) == 0 {
+		glog.Info("all labels existed in issue, skip updating.")
+		return nil
+	}
+
+	// build label string
+	var strLabel string
+	for _, currentlabel := range listofItemLabels {
+		strLabel += currentlabel.Name + ","
+	}
+	for _, addedlabel := range listOfAddLabels {
+		strLabel += addedlabel + ","
+	}
+	strLabel = strings.TrimRight(strLabel, ",")
+	body := gitee.IssueUpdateParam{}
+	body.Repo = repo
+	body.AccessToken = s.Config.GiteeToken
+	body.Labels = strLabel
+	glog.Infof("invoke api to add labels: %v", strLabel)
+
+	// patch labels
+	_, _, err = s.GiteeClient.IssuesApi.PatchV5ReposOwnerIssuesNumber(s.Context, owner, number, body)
+	if err != nil {
+		glog.Errorf("unable to add labels: %v err: %v", listOfAddLabels, err)
+		return err
+	}
+	glog.Infof("add labels successfully: %v", listOfAddLabels)
+	return nil
+}
+
+// RemoveSpecifyLabelsInIssue removes specify labels in issue
+func (s *Server) RemoveSpecifyLabelsInIssue(owner, repo, number string, mapOfRemoveLabels map[string]string) error {
+	// list labels in current item
+	lvos := &gitee.GetV5ReposOwnerRepoIssuesNumberLabelsOpts{}
+	lvos.AccessToken = optional.NewString(s.Config.GiteeToken)
+	listofItemLabels, _, err := s.GiteeClient.LabelsApi.GetV5ReposOwnerRepoIssuesNumberLabels(s.Context, owner, repo, number, lvos)
+	if err != nil {
+		glog.Errorf("unable to get labels in issue. err: %v", err)
+		return err
+	}
+
+	// list of remove labels
+	listOfRemoveLabels := GetListOfRemoveLabels(mapOfRemoveLabels, listofItemLabels)
+	glog.Infof("list of remove labels: %v", listOfRemoveLabels)
+	for _, removedlabel := range listOfRemoveLabels {
+		localVarOptionals := &gitee.DeleteV5ReposOwnerRepoIssuesNumberLabelsNameOpts{}
+		localVarOptionals.AccessToken = optional.NewString(s.Config.GiteeToken)
+		_, err := s.GiteeClient.LabelsApi.DeleteV5ReposOwnerRepoIssuesNumberLabelsName(
+			s.Context, owner, repo, number, UrlEncode(removedlabel), localVarOptionals)
+		if err != nil {
+			glog.Errorf("unable to remove label: %s err: %v", removedlabel, err)
+			return err
+		}
+		glog.Infof("remove label successfully: %s", removedlabel)
+	}
+	return nil
+}
@@ -1,27 +1,21 @@
 package cibot
 
 import (
-	"fmt"
+	"errors"
 	"strings"
 
 	"github.com/antihax/optional"
 
+	"gitee.com/openeuler/ci-bot/pkg/cibot/config"
+	"gitee.com/openeuler/ci-bot/pkg/cibot/database"
 	"gitee.com/openeuler/go-gitee/gitee"
 	"github.com/golang/glog"
 )
 
 const (
-	lgtmSelfOwnMessage = `Sorry, you cannot add ***lgtm*** to the pull request you created. :astonished:`
-	lgtmAddedMessage   = `***lgtm*** was added to this pull request by: ***%s***. :wave:
-**NOTE:** If this pull request is not merged while all conditions are met, comment "/check-pr" to try again. :smile: `
-	lgtmRemovedMessage         = `***lgtm*** was removed in this pull request by: ***%s***. :flushed: `
-	lgtmAddNoPermissionMessage = `Thanks for your review, ***%s***, your opinion is very important to us.:wave:
-The maintainers will consider your advice carefully.`
-	lgtmRemoveNoPermissionMessage = `***%s*** has no permission to remove ***lgtm*** in this pull request. :astonished:
-please contact to the collaborators in this repository.`
-	lgtmRemovePullRequestChangeMessage = `new changes are detected. ***lgtm*** is removed in this pull request by: ***%s***. :flushed: `
-	lgtmRepo                           = "repo"
-	lgtmOrg                            = "org"
+	lgtmRepo = "repo"
+	lgtmOrg  = "org"
+	lgtmSig  = "sig"
 )
 
 // AddLgtm adds lgtm label
@@ -46,7 +40,7 @@ func (s *Server) AddLgtm(event *gitee.NoteEvent) error {
 				// add comment
 				body := gitee.PullRequestCommentPostParam{}
 				body.AccessToken = s.Config.GiteeToken
-				body.Body = lgtmSelfOwnMessage
+				body.Body = s.message(owner, repo, lgtmSelfOwnMessage, nil)
 				owner := event.Repository.Namespace
 				repo := event.Repository.Path
 				number := event.PullRequest.Number
@@ -81,19 +75,24 @@ func (s *Server) AddLgtm(event *gitee.NoteEvent) error {
 
 			// permission: admin, write, read, none
 			if permission.Permission == "admin" || permission.Permission == "write" || isOwner || r == 1 {
+				// record lgtm on current head
+				err = s.recordVoteOnHead(owner, repo, prNumber, commentAuthor, VoteKindLgtm)
+				if err != nil {
+					return err
+				}
 				// add lgtm label
 				addlabel := &gitee.NoteEvent{}
 				addlabel.PullRequest = event.PullRequest
 				addlabel.Repository = event.Repository
 				addlabel.Comment = &gitee.NoteHook{}
-				err = s.AddSpecifyLabelsInPulRequest(addlabel, []string{s.getLgtmLable(commentAuthor, owner, repo)}, true)
+				_, err = s.syncVoteLabels(addlabel)
 				if err != nil {
 					return err
 				}
 				// add comment
 				body := gitee.PullRequestCommentPostParam{}
 				body.AccessToken = s.Config.GiteeToken
-				body.Body = fmt.Sprintf(lgtmAddedMessage, commentAuthor) + fmt.Sprintf(LabelHiddenValue, event.PullRequest.Head.Sha)
+				body.Body = s.message(owner, repo, lgtmAddedMessage, MessageData{"Commenter": commentAuthor})
 				owner := event.Repository.Namespace
 				repo := event.Repository.Path
 				number := event.PullRequest.Number
@@ -111,7 +110,7 @@ func (s *Server) AddLgtm(event *gitee.NoteEvent) error {
 				// add comment
 				body := gitee.PullRequestCommentPostParam{}
 				body.AccessToken = s.Config.GiteeToken
-				body.Body = fmt.Sprintf(lgtmAddNoPermissionMessage, commentAuthor)
+				body.Body = s.message(owner, repo, lgtmAddNoPermissionMessage, MessageData{"Commenter": commentAuthor})
 				owner := event.Repository.Namespace
 				repo := event.Repository.Path
 				number := event.PullRequest.Number
@@ -126,17 +125,14 @@ func (s *Server) AddLgtm(event *gitee.NoteEvent) error {
 	return nil
 }
 
-func (s *Server) getLgtmLable(commenter, owner, repo string) string {
-	if s.calculateLgtmLabel(owner, repo) > 1 {
-		return fmt.Sprintf(LabelLgtmWithCommenter, strings.ToLower(commenter))
-	}
-	return LabelNameLgtm
-}
-
 func (s *Server) calculateLgtmLabel(owner, repo string) int {
 	if len(s.Config.ExtraLgtmCountRequired) > 0 {
 		repoNum := 0
 		orgNum := 0
+		sigNum := 0
+		if rule, ok := s.sigLgtmRule(owner, repo); ok {
+			sigNum = rule.LcrCount
+		}
 		for _, v := range s.Config.ExtraLgtmCountRequired {
 			if v.LcrType == lgtmRepo {
 				if strings.Contains(v.LcrName, "/") {
@@ -155,6 +151,9 @@ func (s *Server) calculateLgtmLabel(owner, repo string) int {
 		if repoNum > 0 {
 			return repoNum
 		}
+		if sigNum > 0 {
+			return sigNum
+		}
 		if orgNum > 0 {
 			return orgNum
 		}
@@ -164,6 +163,57 @@ func (s *Server) calculateLgtmLabel(owner, repo string) int {
 
 }
 
+// sigLgtmRule gets the lgtm rule for the sig of repository
+func (s *Server) sigLgtmRule(owner, repo string) (config.ExtraLgtmCountRequire, bool) {
+	sigName := ""
+	for _, v := range s.Config.ExtraLgtmCountRequired {
+		if v.LcrType != lgtmSig {
+			continue
+		}
+		if sigName == "" {
+			sigName = s.getSigNameFromRepo(owner + "/" + repo)
+			if sigName == "" {
+				break
+			}
+		}
+		if strings.EqualFold(v.LcrName, sigName) {
+			return v, true
+		}
+	}
+	return config.ExtraLgtmCountRequire{}, false
+}
+
+// sigMaintainers gets the maintainers in OWNERS of the sigs which the repository belongs to,
+// the developers of project files are not included
+func sigMaintainers(owner, repo string) ([]string, error) {
+	var srepos []database.SigRepositories
+	err := database.DBConnection.Model(&database.SigRepositories{}).
+		Where("repo_name = ?", owner+"/"+repo).Find(&srepos).Error
+	if err != nil {
+		glog.Errorf("unable to get sig repos: %v", err)
+		return nil, err
+	}
+	sigs := make([]string, 0, len(srepos))
+	for _, srepo := range srepos {
+		sigs = append(sigs, srepo.Name)
+	}
+	return maintainersOfSigs(sigs, owner, repo)
+}
+
+// maintainersOfSigs gets the maintainers in OWNERS of sigs, with the aliases of repository expanded
+func maintainersOfSigs(sigs []string, owner, repo string) ([]string, error) {
+	var owners []string
+	for _, sig := range sigs {
+		maintainers, loaded := getSigOwners(sig)
+		if !loaded {
+			return nil, errors.New("the owners of sigs are not loaded yet")
+		}
+		owners = append(owners, maintainers...)
+	}
+	aliases, _ := getRepoAliases(owner + "/" + repo)
+	return ExpandOwnerAliases(owners, MergeOwnerAliases(getCommunityAliases(), aliases)), nil
+}
+
 // RemoveLgtm removes lgtm label
 func (s *Server) RemoveLgtm(event *gitee.NoteEvent) error {
 	// handle PullRequest
@@ -209,7 +259,7 @@ func (s *Server) RemoveLgtm(event *gitee.NoteEvent) error {
 					// add comment
 					body := gitee.PullRequestCommentPostParam{}
 					body.AccessToken = s.Config.GiteeToken
-					body.Body = fmt.Sprintf(lgtmRemoveNoPermissionMessage, commentAuthor)
+					body.Body = s.message(owner, repo, lgtmRemoveNoPermissionMessage, MessageData{"Commenter": commentAuthor})
 					owner := event.Repository.Namespace
 					repo := event.Repository.Path
 					number := event.PullRequest.Number
@@ -222,15 +272,25 @@ func (s *Server) RemoveLgtm(event *gitee.NoteEvent) error {
 				}
 			}
 
+			// retract lgtm, the author of pull request retracts all of lgtm
+			voter := commentAuthor
+			if prAuthor == commentAuthor {
+				voter = ""
+			}
+			err := s.backfillVotesOnHead(owner, repo, prNumber)
+			if err != nil {
+				return err
+			}
+			err = retractVotes(owner, repo, prNumber, voter, VoteKindLgtm)
+			if err != nil {
+				return err
+			}
 			// remove lgtm label
 			removelabel := &gitee.NoteEvent{}
 			removelabel.PullRequest = event.PullRequest
 			removelabel.Repository = event.Repository
 			removelabel.Comment = &gitee.NoteHook{}
-			mapOfRemoveLabels := map[string]string{}
-			lgtmLable := s.getLgtmLable(commentAuthor, owner, repo)
-			mapOfRemoveLabels[lgtmLable] = lgtmLable
-			err := s.RemoveSpecifyLabelsInPulRequest(removelabel, mapOfRemoveLabels)
+			_, err = s.syncVoteLabels(removelabel)
 			if err != nil {
 				return err
 			}
@@ -238,7 +298,7 @@ func (s *Server) RemoveLgtm(event *gitee.NoteEvent) error {
 			// add comment
 			body := gitee.PullRequestCommentPostParam{}
 			body.AccessToken = s.Config.GiteeToken
-			body.Body = fmt.Sprintf(lgtmRemovedMessage, commentAuthor)
+			body.Body = s.message(owner, repo, lgtmRemovedMessage, MessageData{"Commenter": commentAuthor})
 			number := event.PullRequest.Number
 			_, _, err = s.GiteeClient.PullRequestsApi.PostV5ReposOwnerRepoPullsNumberComments(s.Context, owner, repo, number, body)
 			if err != nil {
@@ -250,104 +310,50 @@ func (s *Server) RemoveLgtm(event *gitee.NoteEvent) error {
 	return nil
 }
 
-func (s *Server) collectExistingLgtmLabel(owner, repo string, number int32) (map[string]string, error) {
-	labels := make(map[string]string)
-	if s.calculateLgtmLabel(owner, repo) > 1 {
-		lvos := &gitee.GetV5ReposOwnerRepoPullsNumberOpts{
-			AccessToken: optional.NewString(s.Config.GiteeToken),
-		}
-		pr, _, err := s.GiteeClient.PullRequestsApi.GetV5ReposOwnerRepoPullsNumber(s.Context, owner, repo, number, lvos)
-		if err != nil {
-			glog.Errorf("unable to get pull request. err: %v", err)
-			return nil, err
-		}
-		glog.Infof("list of pr labels: %v", pr.Labels)
-		for _, label := range pr.Labels {
-			if strings.HasPrefix(label.Name, fmt.Sprintf(LabelLgtmWithCommenter, "")) {
-				labels[label.Name] = label.Name
-			}
-		}
-	} else {
-		labels[LabelNameLgtm] = LabelNameLgtm
-	}
-	return labels, nil
-}
-
 // CheckLgtmByPullRequestUpdate checks lgtm when received the pull request update event
 func (s *Server) CheckLgtmByPullRequestUpdate(event *gitee.PullRequestEvent) error {
 	owner := event.Repository.Namespace
 	repo := event.Repository.Path
 	prNumber := event.PullRequest.Number
-	commentCount := event.PullRequest.Comments
-	var perPage int32 = 20
-	pageCount := commentCount / perPage
-	if commentCount%perPage > 0 {
-		pageCount++
+	sha := event.PullRequest.Head.Sha
+
+	// the labels in payload are added on the previous head, which is unknown
+	err := backfillVotesByHook(owner, repo, event.PullRequest, "")
+	if err != nil {
+		return err
 	}
-	if perPage == 0 {
-		pageCount++
+	// the votes on the previous head are no longer valid
+	count, err := invalidateStaleVotes(owner, repo, prNumber, sha)
+	if err != nil {
+		return err
+	}
+	glog.Infof("invalidate %d stale votes. sha: %v", count, sha)
+	if count == 0 {
+		return nil
 	}
-	glog.Infof("pull request comment count: %v page count: %v per page: %v", commentCount, pageCount, perPage)
-
-	// find comments from the last page
-	lastlgtmSha := ""
-	for page := pageCount; page > 0; page-- {
-		localVarOptionals := &gitee.GetV5ReposOwnerRepoPullsNumberCommentsOpts{}
-		localVarOptionals.AccessToken = optional.NewString(s.Config.GiteeToken)
-		localVarOptionals.PerPage = optional.NewInt32(perPage)
-		localVarOptionals.Page = optional.NewInt32(page)
-
-		comments, _, err := s.GiteeClient.PullRequestsApi.GetV5ReposOwnerRepoPullsNumberComments(s.Context, owner, repo, prNumber, localVarOptionals)
-		if err != nil {
-			glog.Errorf("unable to get pull request comments. err: %v", err)
-			return err
-		}
-		if len(comments) > 0 {
-			// from the last comment
-			for length := len(comments) - 1; length >= 0; length-- {
-				comment := comments[length]
-				m := RegBotAddLgtm.FindStringSubmatch(comment.Body)
-				if /*comment.User.Login == BotName &&*/ m != nil && comment.UpdatedAt == comment.CreatedAt {
-					lastlgtmSha = m[1]
-					glog.Infof("pull request comment with lastlgtmSha: %v", comment)
-					break
-				}
-			}
-		}
-
-		// get the last sha when lgtm
-		if lastlgtmSha != "" {
-			glog.Infof("lastlgtmSha: %v sha: %v", lastlgtmSha, event.PullRequest.Head.Sha)
-			// if the sha is changed
-			if lastlgtmSha != event.PullRequest.Head.Sha {
-				// remove lgtm label
-				removelabel := &gitee.NoteEvent{}
-				removelabel.PullRequest = event.PullRequest
-				removelabel.Repository = event.Repository
-				removelabel.Comment = &gitee.NoteHook{}
-				labels, err := s.collectExistingLgtmLabel(owner, repo, prNumber)
-				if err != nil {
-					glog.Errorf("unable to pick lgtm label in pr: %v", err)
-					return err
-				}
-				err = s.RemoveSpecifyLabelsInPulRequest(removelabel, labels)
-				if err != nil {
-					return err
-				}
 
-				// add comment
-				body := gitee.PullRequestCommentPostParam{}
-				body.AccessToken = s.Config.GiteeToken
-				body.Body = fmt.Sprintf(lgtmRemovePullRequestChangeMessage, s.Config.BotName)
-				_, _, err = s.GiteeClient.PullRequestsApi.PostV5ReposOwnerRepoPullsNumberComments(s.Context, owner, repo, prNumber, body)
-				if err != nil {
-					glog.Errorf("unable to add comment in pull request: %v", err)
-					return err
-				}
-			}
-			break
-		}
+	// remove lgtm and approved labels
+	removelabel := &gitee.NoteEvent{}
+	removelabel.PullRequest = event.PullRequest
+	removelabel.Repository = event.Repository
+	removelabel.Comment = &gitee.NoteHook{}
+	removed, err := s.syncVoteLabels(removelabel)
+	if err != nil {
+		glog.Errorf("unable to sync vote labels in pr: %v", err)
+		return err
+	}
+	if len(removed) == 0 {
+		return nil
 	}
 
+	// add comment
+	body := gitee.PullRequestCommentPostParam{}
+	body.AccessToken = s.Config.GiteeToken
+	body.Body = s.message(owner, repo, lgtmRemovePullRequestChangeMessage, MessageData{"Labels": removed})
+	_, _, err = s.GiteeClient.PullRequestsApi.PostV5ReposOwnerRepoPullsNumberComments(s.Context, owner, repo, prNumber, body)
+	if err != nil {
+		glog.Errorf("unable to add comment in pull request: %v", err)
+		return err
+	}
 	return nil
 }
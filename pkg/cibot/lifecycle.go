@@ -0,0 +1,337 @@
+package cibot
+
+import (
+	"context"
+	"path"
+	"strings"
+	"time"
+
+	"gitee.com/openeuler/ci-bot/pkg/cibot/config"
+	"gitee.com/openeuler/ci-bot/pkg/cibot/database"
+	"gitee.com/openeuler/go-gitee/gitee"
+	"github.com/antihax/optional"
+	"github.com/golang/glog"
+)
+
+const (
+	LabelLifecycleStale  = "lifecycle/stale"
+	LabelLifecycleRotten = "lifecycle/rotten"
+	LabelLifecycleFrozen = "lifecycle/frozen"
+
+	lifecycleActionStale  = "stale"
+	lifecycleActionRotten = "rotten"
+	lifecycleActionClose  = "close"
+
+	lifecycleDefaultDuration = 86400
+)
+
+// LifecycleHandler marks the inactive issues and pull requests as stale and rotten, and closes them finally
+type LifecycleHandler struct {
+	Config      config.Config
+	Context     context.Context
+	GiteeClient *gitee.APIClient
+}
+
+// Serve
+func (handler *LifecycleHandler) Serve() {
+	if !handler.Config.Lifecycle.Enable {
+		glog.Info("lifecycle is disabled")
+		return
+	}
+	handler.watch()
+}
+
+// watch repositories
+func (handler *LifecycleHandler) watch() {
+	for {
+		watchDuration := handler.Config.Lifecycle.Duration
+		if watchDuration <= 0 {
+			watchDuration = lifecycleDefaultDuration
+		}
+		// get repositories from DB
+		var rs []database.Repositories
+		err := database.DBConnection.Model(&database.Repositories{}).Find(&rs).Error
+		if err != nil {
+			glog.Errorf("unable to get repos: %v", err)
+		} else {
+			for _, r := range rs {
+				if handler.isExemptRepo(r.Owner, r.Repo) {
+					continue
+				}
+				handler.handlePullRequests(r.Owner, r.Repo)
+				handler.handleIssues(r.Owner, r.Repo)
+			}
+		}
+
+		// watch duration
+		glog.Infof("lifecycle sleep %v seconds...", watchDuration)
+		time.Sleep(time.Duration(watchDuration) * time.Second)
+	}
+}
+
+// server returns the server sharing the config and client of handler
+func (handler *LifecycleHandler) server() *Server {
+	return &Server{
+		Config:      handler.Config,
+		Context:     handler.Context,
+		GiteeClient: handler.GiteeClient,
+	}
+}
+
+// isExemptRepo checks whether the repository matches the exempt patterns, e.g. openeuler/community or openeuler/*
+func (handler *LifecycleHandler) isExemptRepo(owner, repo string) bool {
+	for _, pattern := range handler.Config.Lifecycle.ExemptRepos {
+		if matched, _ := path.Match(pattern, owner+"/"+repo); matched {
+			return true
+		}
+	}
+	return false
+}
+
+func (handler *LifecycleHandler) handlePullRequests(owner, repo string) {
+	s := handler.server()
+	lvos := &gitee.GetV5ReposOwnerRepoPullsOpts{}
+	lvos.AccessToken = optional.NewString(handler.Config.GiteeToken)
+	lvos.State = optional.NewString("open")
+	lvos.PerPage = optional.NewInt32(100)
+	for page := int32(1); ; page++ {
+		lvos.Page = optional.NewInt32(page)
+		prs, _, err := handler.GiteeClient.PullRequestsApi.GetV5ReposOwnerRepoPulls(handler.Context, owner, repo, lvos)
+		if err != nil {
+			glog.Errorf("unable to list pull requests of %s/%s: %v", owner, repo, err)
+			return
+		}
+		for _, pr := range prs {
+			updatedAt, err := time.Parse(time.RFC3339, pr.UpdatedAt)
+			if err != nil {
+				glog.Errorf("invalid updated time of pull request %s/%s/%d: %v", owner, repo, pr.Number, err)
+				continue
+			}
+			action := lifecycleAction(handler.Config.Lifecycle, pr.Labels, time.Since(updatedAt))
+			if action == "" {
+				continue
+			}
+			glog.Infof("lifecycle %s pull request %s/%s/%d", action, owner, repo, pr.Number)
+			err = s.applyLifecycleToPullRequest(owner, repo, pr, action)
+			if err != nil {
+				glog.Errorf("unable to apply lifecycle to pull request %s/%s/%d: %v", owner, repo, pr.Number, err)
+			}
+		}
+		if len(prs) < 100 {
+			break
+		}
+	}
+}
+
+func (handler *LifecycleHandler) handleIssues(owner, repo string) {
+	s := handler.server()
+	lvos := &gitee.GetV5ReposOwnerRepoIssuesOpts{}
+	lvos.AccessToken = optional.NewString(handler.Config.GiteeToken)
+	lvos.State = optional.NewString("open")
+	lvos.PerPage = optional.NewInt32(100)
+	for page := int32(1); ; page++ {
+		lvos.Page = optional.NewInt32(page)
+		issues, _, err := handler.GiteeClient.IssuesApi.GetV5ReposOwnerRepoIssues(handler.Context, owner, repo, lvos)
+		if err != nil {
+			glog.Errorf("unable to list issues of %s/%s: %v", owner, repo, err)
+			return
+		}
+		for _, issue := range issues {
+			action := lifecycleAction(handler.Config.Lifecycle, issue.Labels, time.Since(issue.UpdatedAt))
+			if action == "" {
+				continue
+			}
+			glog.Infof("lifecycle %s issue %s/%s/%s", action, owner, repo, issue.Number)
+			err = s.applyLifecycleToIssue(owner, repo, issue, action)
+			if err != nil {
+				glog.Errorf("unable to apply lifecycle to issue %s/%s/%s: %v", owner, repo, issue.Number, err)
+			}
+		}
+		if len(issues) < 100 {
+			break
+		}
+	}
+}
+
+// lifecycleAction decides the next lifecycle action of the item idle for the duration.
+// The bot's own label and comment refresh the updated time, so the idle duration of
+// a stale or rotten item is counted from the time it was marked.
+func lifecycleAction(cfg config.Lifecycle, labels []gitee.Label, idle time.Duration) string {
+	stale, rotten := false, false
+	for _, l := range labels {
+		if l.Name == LabelLifecycleFrozen {
+			return ""
+		}
+		for _, el := range cfg.ExemptLabels {
+			if l.Name == el {
+				return ""
+			}
+		}
+		switch l.Name {
+		case LabelLifecycleStale:
+			stale = true
+		case LabelLifecycleRotten:
+			rotten = true
+		}
+	}
+	days := func(d int) time.Duration {
+		return time.Duration(d) * 24 * time.Hour
+	}
+	switch {
+	case rotten:
+		if cfg.CloseDays > 0 && idle >= days(cfg.CloseDays) {
+			return lifecycleActionClose
+		}
+	case stale:
+		if cfg.RottenDays > 0 && idle >= days(cfg.RottenDays) {
+			return lifecycleActionRotten
+		}
+	default:
+		if cfg.StaleDays > 0 && idle >= days(cfg.StaleDays) {
+			return lifecycleActionStale
+		}
+	}
+	return ""
+}
+
+func (s *Server) applyLifecycleToPullRequest(owner, repo string, pr gitee.PullRequest, action string) error {
+	event := &gitee.NoteEvent{}
+	event.Repository = &gitee.ProjectHook{Namespace: owner, Path: repo, Name: repo}
+	event.PullRequest = pullRequestHookOf(pr)
+	event.Comment = &gitee.NoteHook{}
+	cfg := s.Config.Lifecycle
+
+	var comment string
+	switch action {
+	case lifecycleActionStale:
+		err := s.AddSpecifyLabelsInPulRequest(event, []string{LabelLifecycleStale}, true)
+		if err != nil {
+			return err
+		}
+		comment = s.message(owner, repo, lifecycleStaleMessage, MessageData{
+			"IsPullRequest": true, "Days": cfg.StaleDays, "Label": LabelLifecycleStale})
+	case lifecycleActionRotten:
+		err := s.AddSpecifyLabelsInPulRequest(event, []string{LabelLifecycleRotten}, true)
+		if err != nil {
+			return err
+		}
+		err = s.RemoveSpecifyLabelsInPulRequest(event, map[string]string{LabelLifecycleStale: LabelLifecycleStale})
+		if err != nil {
+			return err
+		}
+		comment = s.message(owner, repo, lifecycleRottenMessage, MessageData{
+			"IsPullRequest": true, "Days": cfg.RottenDays, "Label": LabelLifecycleRotten, "CloseDays": cfg.CloseDays})
+	case lifecycleActionClose:
+		body := gitee.PullRequestUpdateParam{}
+		body.AccessToken = s.Config.GiteeToken
+		body.State = "closed"
+		_, _, err := s.GiteeClient.PullRequestsApi.PatchV5ReposOwnerRepoPullsNumber(s.Context, owner, repo, pr.Number, body)
+		if err != nil {
+			glog.Errorf("unable to close: %d err: %v", pr.Number, err)
+			return err
+		}
+		comment = s.message(owner, repo, lifecycleCloseMessage, MessageData{"IsPullRequest": true, "Days": cfg.CloseDays})
+	}
+	return s.addCommentToPullRequest(owner, repo, comment, pr.Number)
+}
+
+func (s *Server) applyLifecycleToIssue(owner, repo string, issue gitee.Issue, action string) error {
+	cfg := s.Config.Lifecycle
+
+	var comment string
+	switch action {
+	case lifecycleActionStale:
+		err := s.AddSpecifyLabelsInIssue(owner, repo, issue.Number, []string{LabelLifecycleStale}, true)
+		if err != nil {
+			return err
+		}
+		comment = s.message(owner, repo, lifecycleStaleMessage, MessageData{
+			"IsPullRequest": false, "Days": cfg.StaleDays, "Label": LabelLifecycleStale})
+	case lifecycleActionRotten:
+		err := s.AddSpecifyLabelsInIssue(owner, repo, issue.Number, []string{LabelLifecycleRotten}, true)
+		if err != nil {
+			return err
+		}
+		err = s.RemoveSpecifyLabelsInIssue(owner, repo, issue.Number, map[string]string{LabelLifecycleStale: LabelLifecycleStale})
+		if err != nil {
+			return err
+		}
+		comment = s.message(owner, repo, lifecycleRottenMessage, MessageData{
+			"IsPullRequest": false, "Days": cfg.RottenDays, "Label": LabelLifecycleRotten, "CloseDays": cfg.CloseDays})
+	case lifecycleActionClose:
+		body := gitee.IssueUpdateParam{}
+		body.Repo = repo
+		body.AccessToken = s.Config.GiteeToken
+		body.State = "closed"
+		// keep the labels of issue
+		var strLabel string
+		for _, l := range issue.Labels {
+			strLabel += l.Name + ","
+		}
+		strLabel = strings.TrimRight(strLabel, ",")
+		if strLabel == "" {
+			strLabel = ","
+		}
+		body.Labels = strLabel
+		_, _, err := s.GiteeClient.IssuesApi.PatchV5ReposOwnerIssuesNumber(s.Context, owner, issue.Number, body)
+		if err != nil {
+			glog.Errorf("unable to close: %s err: %v", issue.Number, err)
+			return err
+		}
+		comment = s.message(owner, repo, lifecycleCloseMessage, MessageData{"IsPullRequest": false, "Days": cfg.CloseDays})
+	}
+	bodyComment := gitee.IssueCommentPostParam{}
+	bodyComment.AccessToken = s.Config.GiteeToken
+	bodyComment.Body = comment
+	_, _, err := s.GiteeClient.IssuesApi.PostV5ReposOwnerRepoIssuesNumberComments(s.Context, owner, repo, issue.Number, bodyComment)
+	if err != nil {
+		glog.Errorf("unable to add comment in issue: %v", err)
+		return err
+	}
+	return nil
+}
+
+// HandleLifecycleCommand handles /lifecycle and /remove-lifecycle commands
+func (s *Server) HandleLifecycleCommand(event *gitee.NoteEvent) error {
+	owner := event.Repository.Namespace
+	repo := event.Repository.Path
+	comment := event.Comment.Body
+
+	addLabels := make([]string, 0)
+	for _, m := range RegLifecycle.FindAllStringSubmatch(comment, -1) {
+		addLabels = append(addLabels, "lifecycle/"+strings.ToLower(m[1]))
+	}
+	removeLabels := make(map[string]string)
+	for _, m := range RegRemoveLifecycle.FindAllStringSubmatch(comment, -1) {
+		l := "lifecycle/" + strings.ToLower(m[1])
+		removeLabels[l] = l
+	}
+	glog.Infof("lifecycle command. add: %v remove: %v", addLabels, removeLabels)
+
+	if *event.NoteableType == "PullRequest" {
+		if event.PullRequest.State != "open" {
+			return nil
+		}
+		if len(addLabels) > 0 {
+			if err := s.AddSpecifyLabelsInPulRequest(event, addLabels, true); err != nil {
+				return err
+			}
+		}
+		if len(removeLabels) > 0 {
+			return s.RemoveSpecifyLabelsInPulRequest(event, removeLabels)
+		}
+	} else if *event.NoteableType == "Issue" {
+		if event.Issue.State != "open" {
+			return nil
+		}
+		if len(addLabels) > 0 {
+			if err := s.AddSpecifyLabelsInIssue(owner, repo, event.Issue.Number, addLabels, true); err != nil {
+				return err
+			}
+		}
+		if len(removeLabels) > 0 {
+			return s.RemoveSpecifyLabelsInIssue(owner, repo, event.Issue.Number, removeLabels)
+		}
+	}
+	return nil
+}
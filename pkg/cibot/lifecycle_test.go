@@ -0,0 +1,47 @@
+package cibot
+
+import (
+	"testing"
+	"time"
+
+	"gitee.com/openeuler/ci-bot/pkg/cibot/config"
+	"gitee.com/openeuler/go-gitee/gitee"
+)
+
+func Test_lifecycleAction(t *testing.T) {
+	cfg := config.Lifecycle{
+		StaleDays:    90,
+		RottenDays:   30,
+		CloseDays:    30,
+		ExemptLabels: []string{"kind/security"},
+	}
+	day := 24 * time.Hour
+	labels := func(names ...string) []gitee.Label {
+		ls := make([]gitee.Label, 0, len(names))
+		for _, n := range names {
+			ls = append(ls, gitee.Label{Name: n})
+		}
+		return ls
+	}
+	tests := []struct {
+		name   string
+		labels []gitee.Label
+		idle   time.Duration
+		want   string
+	}{
+		{"active", labels("sig/Kernel"), 10 * day, ""},
+		{"idle", labels("sig/Kernel"), 90 * day, lifecycleActionStale},
+		{"stale but recently marked", labels(LabelLifecycleStale), 10 * day, ""},
+		{"stale", labels(LabelLifecycleStale), 30 * day, lifecycleActionRotten},
+		{"rotten", labels(LabelLifecycleRotten), 31 * day, lifecycleActionClose},
+		{"frozen", labels(LabelLifecycleFrozen, LabelLifecycleRotten), 365 * day, ""},
+		{"exempt label", labels("kind/security"), 365 * day, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := lifecycleAction(cfg, tt.labels, tt.idle); got != tt.want {
+				t.Errorf("lifecycleAction() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,210 @@
+package cibot
+
+import (
+	"errors"
+	"path"
+	"strings"
+
+	"gitee.com/openeuler/ci-bot/pkg/cibot/config"
+	"gitee.com/openeuler/go-gitee/gitee"
+	"github.com/antihax/optional"
+	"github.com/golang/glog"
+)
+
+const (
+	approverSourceCollaborators = "collaborators"
+	approverSourceOwners        = "owners"
+)
+
+// mergePolicy gets the first merge policy matching the target branch of repository,
+// or the policy from requiringLabels, missingLabels and lgtm counts if none matches
+func (s *Server) mergePolicy(owner, repo, branch string) config.MergePolicy {
+	sig := ""
+	sigLoaded := false
+	for _, p := range s.Config.MergePolicies {
+		if len(p.Sigs) > 0 && !sigLoaded {
+			sig = s.getSigNameFromRepo(owner + "/" + repo)
+			sigLoaded = true
+		}
+		if !mergePolicyMatches(p, owner, repo, sig, branch) {
+			continue
+		}
+		if p.LgtmCount < 1 {
+			p.LgtmCount = s.calculateLgtmLabel(owner, repo)
+		}
+		if rule, ok := s.sigLgtmRule(owner, repo); ok && rule.LcrRequireSigOwner {
+			p.RequireSigOwnerLgtm = true
+		}
+		glog.Infof("merge policy %s matches %s/%s branch %s", p.Name, owner, repo, branch)
+		return p
+	}
+	rule, _ := s.sigLgtmRule(owner, repo)
+	return config.MergePolicy{
+		RequiredLabels:      s.Config.RequiringLabels,
+		ForbiddenLabels:     s.Config.MissingLabels,
+		LgtmCount:           s.calculateLgtmLabel(owner, repo),
+		RequireSigOwnerLgtm: rule.LcrRequireSigOwner,
+	}
+}
+
+// mergePolicyMatches checks whether the policy matches, the empty conditions match any
+func mergePolicyMatches(p config.MergePolicy, owner, repo, sig, branch string) bool {
+	matchAny := func(patterns []string, value string) bool {
+		if len(patterns) == 0 {
+			return true
+		}
+		for _, pattern := range patterns {
+			if matched, _ := path.Match(pattern, value); matched {
+				return true
+			}
+		}
+		return false
+	}
+	return matchAny(p.Orgs, owner) && matchAny(p.Repos, owner+"/"+repo) &&
+		matchAny(p.Sigs, sig) && matchAny(p.Branches, branch)
+}
+
+// mergePolicyLabels checks the labels with policy, and gets the required labels not added,
+// the forbidden labels not removed and the one-of groups none of which is added
+func mergePolicyLabels(p config.MergePolicy, labels []gitee.Label) ([]string, []string, [][]string) {
+	existing := make(map[string]bool, len(labels))
+	for _, l := range labels {
+		existing[l.Name] = true
+	}
+	matchLabel := func(pattern string) []string {
+		matched := make([]string, 0)
+		for _, l := range labels {
+			if ok, _ := path.Match(pattern, l.Name); ok {
+				matched = append(matched, l.Name)
+			}
+		}
+		return matched
+	}
+
+	nonRequiring := make([]string, 0)
+	for _, l := range p.RequiredLabels {
+		if !existing[l] {
+			nonRequiring = append(nonRequiring, l)
+		}
+	}
+	nonMissing := make([]string, 0)
+	for _, pattern := range p.ForbiddenLabels {
+		nonMissing = append(nonMissing, matchLabel(pattern)...)
+	}
+	nonOneOf := make([][]string, 0)
+	for _, group := range p.OneOfLabels {
+		found := false
+		for _, pattern := range group {
+			if len(matchLabel(pattern)) > 0 {
+				found = true
+				break
+			}
+		}
+		if !found {
+			nonOneOf = append(nonOneOf, group)
+		}
+	}
+	return nonRequiring, nonMissing, nonOneOf
+}
+
+// approversOf filters the approvers whose approval counts in policy
+func (s *Server) approversOf(p config.MergePolicy, owner, repo, branch string, voters []string) []string {
+	if len(p.ApproverSources) == 0 {
+		return voters
+	}
+	var owners []string
+	ownersLoaded := false
+	approvers := make([]string, 0)
+	for _, v := range voters {
+		// the backfilled approval without voter can not be checked against the sources
+		if v == "" {
+			continue
+		}
+		for _, source := range p.ApproverSources {
+			counted := false
+			switch source {
+			case approverSourceCollaborators:
+				counted = s.hasWritePermission(owner, repo, v)
+			case approverSourceOwners:
+				if !ownersLoaded {
+					event := &gitee.NoteEvent{}
+					event.Repository = &gitee.ProjectHook{Namespace: owner, Path: repo, Name: repo}
+					event.PullRequest = &gitee.PullRequestHook{Base: &gitee.BranchHook{Ref: branch}}
+					owners = s.GetOwners(event)
+					ownersLoaded = true
+				}
+				counted = containsUser(owners, v)
+			default:
+				counted = strings.HasPrefix(source, "@") && strings.EqualFold(source[1:], v)
+			}
+			if counted {
+				approvers = append(approvers, v)
+				break
+			}
+		}
+	}
+	return approvers
+}
+
+// hasWritePermission checks whether the user is a collaborator with write permission
+func (s *Server) hasWritePermission(owner, repo, user string) bool {
+	localVarOptionals := &gitee.GetV5ReposOwnerRepoCollaboratorsUsernamePermissionOpts{}
+	localVarOptionals.AccessToken = optional.NewString(s.Config.GiteeToken)
+	permission, _, err := s.GiteeClient.RepositoriesApi.GetV5ReposOwnerRepoCollaboratorsUsernamePermission(
+		s.Context, owner, repo, user, localVarOptionals)
+	if err != nil {
+		glog.Errorf("unable to get permission of %s: %v", user, err)
+		return false
+	}
+	return permission.Permission == "admin" || permission.Permission == "write"
+}
+
+// readyForMerge checks the votes on head of pull request with policy
+func (s *Server) readyForMerge(p config.MergePolicy, owner, repo string, pr gitee.PullRequest) error {
+	err := backfillVotesOf(owner, repo, pr)
+	if err != nil {
+		return err
+	}
+	votes, err := getActiveVotes(owner, repo, pr.Number, pr.Head.Sha)
+	if err != nil {
+		return err
+	}
+	leastLgtm := p.LgtmCount
+	if leastLgtm < 1 {
+		leastLgtm = 1
+	}
+	approvers := s.approversOf(p, owner, repo, pr.Base.Ref, votersOf(votes, VoteKindApprove))
+	lgtmVoters := votersOf(votes, VoteKindLgtm)
+	lgtmVotes := len(lgtmVoters)
+	sigOwnerLgtm := true
+	if p.RequireSigOwnerLgtm {
+		maintainers, err := sigMaintainers(owner, repo)
+		if err != nil {
+			return err
+		}
+		sigOwnerLgtm = hasSigOwnerLgtm(lgtmVoters, maintainers)
+	}
+	glog.Infof("Pr votes on %s have approve: %d lgtm: %d sig owner lgtm: %v, required (%d) by policy %s",
+		pr.Head.Sha, len(approvers), lgtmVotes, sigOwnerLgtm, leastLgtm, p.Name)
+	if len(approvers) > 0 && lgtmVotes >= leastLgtm && sigOwnerLgtm {
+		return nil
+	}
+	return errors.New(s.message(owner, repo, notReadyForMergeMessage, MessageData{
+		"Policy": p.Name, "LeastLgtm": leastLgtm, "Lgtm": lgtmVotes,
+		"Approved": len(approvers) > 0, "ApproverSources": p.ApproverSources, "SigOwnerLgtmMissing": !sigOwnerLgtm}))
+}
+
+// hasSigOwnerLgtm checks whether one of the lgtm voters is a maintainer of sig
+func hasSigOwnerLgtm(voters, maintainers []string) bool {
+	for _, v := range voters {
+		if containsUser(maintainers, v) {
+			return true
+		}
+	}
+	return false
+}
+
+// legalLabelsForMerge checks with the labels constraints of policy to determine if mergable
+func (s *Server) legalLabelsForMerge(p config.MergePolicy, labels []gitee.Label) ([]string, []string, [][]string) {
+	return mergePolicyLabels(p, labels)
+}
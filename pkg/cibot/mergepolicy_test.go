@@ -0,0 +1,125 @@
+package cibot
+
+import (
+	"reflect"
+	"testing"
+
+	"gitee.com/openeuler/ci-bot/pkg/cibot/config"
+	"gitee.com/openeuler/go-gitee/gitee"
+)
+
+func TestMergePolicyMatches(t *testing.T) {
+	policy := config.MergePolicy{
+		Orgs:     []string{"src-openeuler"},
+		Repos:    []string{"src-openeuler/kernel*"},
+		Sigs:     []string{"Kernel"},
+		Branches: []string{"openEuler-*"},
+	}
+	tests := []struct {
+		name                     string
+		policy                   config.MergePolicy
+		owner, repo, sig, branch string
+		want                     bool
+	}{
+		{"empty policy", config.MergePolicy{}, "openeuler", "community", "", "master", true},
+		{"all match", policy, "src-openeuler", "kernel-tools", "Kernel", "openEuler-20.03-LTS", true},
+		{"org", policy, "openeuler", "kernel", "Kernel", "openEuler-20.03-LTS", false},
+		{"repo", policy, "src-openeuler", "gcc", "Kernel", "openEuler-20.03-LTS", false},
+		{"sig", policy, "src-openeuler", "kernel", "Compiler", "openEuler-20.03-LTS", false},
+		{"branch", policy, "src-openeuler", "kernel", "Kernel", "master", false},
+	}
+	for _, tt := range tests {
+		if got := mergePolicyMatches(tt.policy, tt.owner, tt.repo, tt.sig, tt.branch); got != tt.want {
+			t.Errorf("%s: mergePolicyMatches() = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestMergePolicyLabels(t *testing.T) {
+	policy := config.MergePolicy{
+		RequiredLabels:  []string{"openeuler-cla/yes"},
+		ForbiddenLabels: []string{"do-not-merge/*"},
+		OneOfLabels:     [][]string{{"kind/*"}, {"priority/high", "priority/low"}},
+	}
+	labels := func(names ...string) []gitee.Label {
+		ls := make([]gitee.Label, 0, len(names))
+		for _, n := range names {
+			ls = append(ls, gitee.Label{Name: n})
+		}
+		return ls
+	}
+	tests := []struct {
+		name             string
+		labels           []gitee.Label
+		wantNonRequiring []string
+		wantNonMissing   []string
+		wantNonOneOf     [][]string
+	}{
+		{"legal", labels("openeuler-cla/yes", "kind/bug", "priority/low"), []string{}, []string{}, [][]string{}},
+		{"missing required", labels("kind/bug", "priority/low"), []string{"openeuler-cla/yes"}, []string{}, [][]string{}},
+		{"forbidden", labels("openeuler-cla/yes", "kind/bug", "priority/low", "do-not-merge/hold"),
+			[]string{}, []string{"do-not-merge/hold"}, [][]string{}},
+		{"one of", labels("openeuler-cla/yes", "priority/medium"),
+			[]string{}, []string{}, [][]string{{"kind/*"}, {"priority/high", "priority/low"}}},
+	}
+	for _, tt := range tests {
+		nonRequiring, nonMissing, nonOneOf := mergePolicyLabels(policy, tt.labels)
+		if !reflect.DeepEqual(nonRequiring, tt.wantNonRequiring) || !reflect.DeepEqual(nonMissing, tt.wantNonMissing) ||
+			!reflect.DeepEqual(nonOneOf, tt.wantNonOneOf) {
+			t.Errorf("%s: mergePolicyLabels() = %v, %v, %v", tt.name, nonRequiring, nonMissing, nonOneOf)
+		}
+	}
+}
+
+func TestRenderNotReadyForMerge(t *testing.T) {
+	got := RenderMessage(config.Config{}, LocaleEn, notReadyForMergeMessage, MessageData{
+		"Policy": "kernel", "LeastLgtm": 2, "Lgtm": 1, "Approved": true, "ApproverSources": []string{"owners"}})
+	want := "This pull request can not be merged by merge policy **kernel**, please check that the number of **lgtm** labels >= 2 " +
+		"and there are an **approve** labels from owners. \n **lgtm**: 1/2."
+	if got != want {
+		t.Errorf("RenderMessage() = %q, want %q", got, want)
+	}
+}
+
+func TestApproversOf(t *testing.T) {
+	s := &Server{}
+	voters := []string{"", "Alice", "bob"}
+	if got := s.approversOf(config.MergePolicy{}, "openeuler", "kernel", "master", voters); !reflect.DeepEqual(got, voters) {
+		t.Errorf("approversOf() without sources = %v, want %v", got, voters)
+	}
+	// the approval backfilled from the label has no voter to check
+	p := config.MergePolicy{ApproverSources: []string{"@alice"}}
+	if got := s.approversOf(p, "openeuler", "kernel", "master", voters); !reflect.DeepEqual(got, []string{"Alice"}) {
+		t.Errorf("approversOf() = %v, want [Alice]", got)
+	}
+	if got := s.approversOf(p, "openeuler", "kernel", "master", []string{""}); len(got) != 0 {
+		t.Errorf("approversOf() of backfilled approval = %v, want none", got)
+	}
+}
+
+func TestHasSigOwnerLgtm(t *testing.T) {
+	maintainers := []string{"alice", "Bob"}
+	if !hasSigOwnerLgtm([]string{"carol", "bob"}, maintainers) {
+		t.Errorf("lgtm from maintainer bob is not found")
+	}
+	if hasSigOwnerLgtm([]string{"carol"}, maintainers) {
+		t.Errorf("lgtm from non maintainer carol is counted")
+	}
+}
+
+func TestVotesFromLabels(t *testing.T) {
+	votes := votesFromLabels("openeuler", "kernel", 1, "abc",
+		[]string{"lgtm-alice", "lgtm", "approved", "kind/bug", "lgtm-"})
+	if len(votes) != 3 {
+		t.Fatalf("votesFromLabels got %d votes, want 3", len(votes))
+	}
+	if got := votersOf(votes, VoteKindLgtm); !reflect.DeepEqual(got, []string{"alice", ""}) {
+		t.Errorf("lgtm voters = %v", got)
+	}
+	if got := votersOf(votes, VoteKindApprove); !reflect.DeepEqual(got, []string{""}) {
+		t.Errorf("approve voters = %v", got)
+	}
+	if votes[0].Sha != "abc" || votes[0].Number != 1 {
+		t.Errorf("vote = %+v", votes[0])
+	}
+}
@@ -0,0 +1,450 @@
+package cibot
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"gitee.com/openeuler/ci-bot/pkg/cibot/config"
+	"gitee.com/openeuler/go-gitee/gitee"
+	"github.com/antihax/optional"
+	"github.com/golang/glog"
+)
+
+const (
+	mergeQueueDefaultRetest    = "/retest"
+	mergeQueueDefaultCiSuccess = "ci_successful"
+	mergeQueueDefaultCiFailure = "ci_failed"
+	mergeQueueDefaultDuration  = 60
+)
+
+// mergeQueueChanged notifies merge queue handler to sync without waiting for the next round
+var mergeQueueChanged = make(chan struct{}, 1)
+
+// MergeQueueHandler merges the ready pull requests one at a time per target branch
+type MergeQueueHandler struct {
+	Config      config.Config
+	Context     context.Context
+	GiteeClient *gitee.APIClient
+	// the status comments and retests of the pull requests in queue
+	status map[string]*mergeQueueStatus
+	lock   sync.Mutex
+}
+
+type mergeQueueStatus struct {
+	owner     string
+	repo      string
+	number    int32
+	branch    string
+	commentID int32
+	message   string
+	// head and base sha when CI is triggered again
+	retested string
+}
+
+// mergeQueueItem is the ready pull request in queue
+type mergeQueueItem struct {
+	owner    string
+	repo     string
+	pr       gitee.PullRequest
+	priority int
+}
+
+// Serve
+func (handler *MergeQueueHandler) Serve() {
+	if len(handler.Config.MergeQueue.Repos) == 0 {
+		glog.Info("merge queue is disabled")
+		return
+	}
+	handler.status = make(map[string]*mergeQueueStatus)
+	handler.watch()
+}
+
+// watch open pull requests
+func (handler *MergeQueueHandler) watch() {
+	for {
+		handler.sync()
+
+		glog.Infof("merge queue sleep %v...", handler.duration())
+		select {
+		case <-time.After(handler.duration()):
+		case <-mergeQueueChanged:
+			glog.Info("merge queue is notified")
+		}
+	}
+}
+
+func (handler *MergeQueueHandler) duration() time.Duration {
+	watchDuration := handler.Config.MergeQueue.Duration
+	if watchDuration <= 0 {
+		watchDuration = mergeQueueDefaultDuration
+	}
+	return time.Duration(watchDuration) * time.Second
+}
+
+// server returns the server sharing the config and client of handler
+func (handler *MergeQueueHandler) server() *Server {
+	return &Server{
+		Config:      handler.Config,
+		Context:     handler.Context,
+		GiteeClient: handler.GiteeClient,
+	}
+}
+
+// sync runs one round of the merge queue
+func (handler *MergeQueueHandler) sync() {
+	handler.lock.Lock()
+	defer handler.lock.Unlock()
+
+	queues := make(map[string][]mergeQueueItem)
+	for _, r := range handler.Config.MergeQueue.Repos {
+		owner, repo := splitFullName(r)
+		if owner == "" || repo == "" {
+			glog.Errorf("invalid repository in merge queue: %s", r)
+			continue
+		}
+		items, err := handler.listReadyPullRequests(owner, repo)
+		if err != nil {
+			glog.Errorf("unable to list ready pull requests of %s: %v", r, err)
+			continue
+		}
+		for _, item := range items {
+			key := fmt.Sprintf("%s/%s/%s", owner, repo, item.pr.Base.Ref)
+			queues[key] = append(queues[key], item)
+		}
+	}
+
+	s := handler.server()
+	inQueue := make(map[string]bool)
+	for _, items := range queues {
+		sortMergeQueue(items)
+		merged := handler.mergeHead(items)
+		for i, item := range items {
+			key := mergeQueueKey(item.owner, item.repo, item.pr.Number)
+			if key == merged {
+				continue
+			}
+			inQueue[key] = true
+			message := s.message(item.owner, item.repo, mergeQueuePositionMessage, MessageData{
+				"Branch": item.pr.Base.Ref, "Position": i + 1, "Total": len(items)})
+			// the head of queue is waiting for CI after the base moved
+			if st, ok := handler.status[key]; ok && i == 0 && st.retested != "" {
+				message = s.message(item.owner, item.repo, mergeQueueRetestMessage, MessageData{"Branch": item.pr.Base.Ref})
+			}
+			handler.updateStatus(item, message)
+		}
+	}
+
+	// the pull requests which are not ready any more
+	for key, st := range handler.status {
+		if inQueue[key] {
+			continue
+		}
+		handler.editStatusComment(st, s.message(st.owner, st.repo, mergeQueueLeftMessage, MessageData{"Branch": st.branch}))
+		delete(handler.status, key)
+	}
+}
+
+// listReadyPullRequests lists the open pull requests which meet the votes and labels requirement
+func (handler *MergeQueueHandler) listReadyPullRequests(owner, repo string) ([]mergeQueueItem, error) {
+	s := handler.server()
+	items := make([]mergeQueueItem, 0)
+	lvos := &gitee.GetV5ReposOwnerRepoPullsOpts{}
+	lvos.AccessToken = optional.NewString(handler.Config.GiteeToken)
+	lvos.State = optional.NewString("open")
+	lvos.PerPage = optional.NewInt32(100)
+	for page := int32(1); ; page++ {
+		lvos.Page = optional.NewInt32(page)
+		prs, _, err := handler.GiteeClient.PullRequestsApi.GetV5ReposOwnerRepoPulls(handler.Context, owner, repo, lvos)
+		if err != nil {
+			return nil, err
+		}
+		for _, pr := range prs {
+			if pr.Head == nil || pr.Base == nil {
+				continue
+			}
+			// the frozen branch can only be merged by its owners
+			if _, isFrozen := IsBranchFrozen(pr.Base.Ref, owner); isFrozen {
+				continue
+			}
+			if _, ok := s.checkBranchStateForMerge(owner, repo, pr); !ok {
+				continue
+			}
+			policy := s.mergePolicy(owner, repo, pr.Base.Ref)
+			if s.readyForMerge(policy, owner, repo, pr) != nil {
+				continue
+			}
+			nonRequiring, nonMissing, nonOneOf := s.legalLabelsForMerge(policy, pr.Labels)
+			if len(nonRequiring) > 0 || len(nonMissing) > 0 || len(nonOneOf) > 0 {
+				continue
+			}
+			items = append(items, mergeQueueItem{
+				owner:    owner,
+				repo:     repo,
+				pr:       pr,
+				priority: mergeQueuePriority(pr.Labels, handler.Config.MergeQueue.PriorityLabels),
+			})
+		}
+		if len(prs) < 100 {
+			break
+		}
+	}
+	return items, nil
+}
+
+const (
+	ciResultSuccess = "success"
+	ciResultFailure = "failure"
+)
+
+// ciLabels gets the labels of CI result, the default ones are used if not set
+func (handler *MergeQueueHandler) ciLabels() (string, []string) {
+	success := handler.Config.MergeQueue.CiSuccessLabel
+	if success == "" {
+		success = mergeQueueDefaultCiSuccess
+	}
+	failures := handler.Config.MergeQueue.CiFailureLabels
+	if len(failures) == 0 {
+		failures = []string{mergeQueueDefaultCiFailure}
+	}
+	return success, failures
+}
+
+func containsString(list []string, s string) bool {
+	for _, l := range list {
+		if l == s {
+			return true
+		}
+	}
+	return false
+}
+
+// ciResultOf gets the result of CI by the labels, it is empty if CI has not finished
+func ciResultOf(labels []gitee.Label, successLabel string, failureLabels []string) string {
+	result := ""
+	for _, l := range labels {
+		if containsString(failureLabels, l.Name) {
+			return ciResultFailure
+		}
+		if l.Name == successLabel {
+			result = ciResultSuccess
+		}
+	}
+	return result
+}
+
+// mergeHead merges the first mergeable pull request of the queue and returns its key,
+// the pull request is retested first if the base branch has moved
+func (handler *MergeQueueHandler) mergeHead(items []mergeQueueItem) string {
+	s := handler.server()
+	for _, item := range items {
+		owner, repo := item.owner, item.repo
+		// the mergeable in list may be stale
+		lvos := &gitee.GetV5ReposOwnerRepoPullsNumberOpts{}
+		lvos.AccessToken = optional.NewString(handler.Config.GiteeToken)
+		pr, _, err := handler.GiteeClient.PullRequestsApi.GetV5ReposOwnerRepoPullsNumber(handler.Context, owner, repo, item.pr.Number, lvos)
+		if err != nil {
+			glog.Errorf("unable to get pull request. err: %v", err)
+			return ""
+		}
+		if !pr.Mergeable {
+			glog.Infof("pull request %s/%s/%d is not mergeable, try the next one", owner, repo, pr.Number)
+			continue
+		}
+
+		// trigger CI again if the base moved since the head is tested
+		lvosbranch := &gitee.GetV5ReposOwnerRepoBranchesBranchOpts{}
+		lvosbranch.AccessToken = optional.NewString(handler.Config.GiteeToken)
+		branch, _, err := handler.GiteeClient.RepositoriesApi.GetV5ReposOwnerRepoBranchesBranch(handler.Context, owner, repo, pr.Base.Ref, lvosbranch)
+		if err != nil {
+			glog.Errorf("unable to get branch %s. err: %v", pr.Base.Ref, err)
+			return ""
+		}
+		key := mergeQueueKey(owner, repo, pr.Number)
+		st := handler.getStatus(item)
+		if branch.Commit != nil && branch.Commit.Sha != pr.Base.Sha {
+			successLabel, failureLabels := handler.ciLabels()
+			tested := pr.Head.Sha + ":" + branch.Commit.Sha
+			if st.retested != tested {
+				// the result of CI on the previous base is no longer valid
+				event := &gitee.NoteEvent{}
+				event.Repository = &gitee.ProjectHook{Namespace: owner, Path: repo, Name: repo}
+				event.PullRequest = pullRequestHookOf(pr)
+				event.Comment = &gitee.NoteHook{}
+				stale := make(map[string]string)
+				for _, l := range pr.Labels {
+					if l.Name == successLabel || containsString(failureLabels, l.Name) {
+						stale[l.Name] = l.Name
+					}
+				}
+				if len(stale) > 0 {
+					err = s.RemoveSpecifyLabelsInPulRequest(event, stale)
+					if err != nil {
+						return ""
+					}
+				}
+				retest := handler.Config.MergeQueue.RetestComment
+				if retest == "" {
+					retest = mergeQueueDefaultRetest
+				}
+				err = s.addCommentToPullRequest(owner, repo, retest, pr.Number)
+				if err != nil {
+					return ""
+				}
+				st.retested = tested
+				return ""
+			}
+			// merge once CI succeeds on the head with the new base
+			switch ciResultOf(pr.Labels, successLabel, failureLabels) {
+			case ciResultSuccess:
+			case ciResultFailure:
+				glog.Infof("CI of %s failed on base %s, try the next one", key, branch.Commit.Sha)
+				continue
+			default:
+				glog.Infof("wait for the result of CI of %s on base %s", key, branch.Commit.Sha)
+				return ""
+			}
+		}
+
+		event := &gitee.NoteEvent{}
+		event.Repository = &gitee.ProjectHook{Namespace: owner, Path: repo, Name: repo}
+		event.PullRequest = pullRequestHookOf(pr)
+		err = s.mergePullRequest(event, pr)
+		if err != nil {
+			glog.Errorf("merge queue unable to merge %s: %v", key, err)
+			return ""
+		}
+		glog.Infof("merge queue merged %s", key)
+		handler.editStatusComment(st, s.message(owner, repo, mergeQueueMergedMessage, MessageData{"Branch": pr.Base.Ref}))
+		delete(handler.status, key)
+		return key
+	}
+	return ""
+}
+
+func (handler *MergeQueueHandler) getStatus(item mergeQueueItem) *mergeQueueStatus {
+	key := mergeQueueKey(item.owner, item.repo, item.pr.Number)
+	st, ok := handler.status[key]
+	if !ok {
+		st = &mergeQueueStatus{
+			owner:  item.owner,
+			repo:   item.repo,
+			number: item.pr.Number,
+			branch: item.pr.Base.Ref,
+		}
+		handler.status[key] = st
+	}
+	return st
+}
+
+// updateStatus posts the status comment of pull request or edits it when the status changes
+func (handler *MergeQueueHandler) updateStatus(item mergeQueueItem, message string) {
+	st := handler.getStatus(item)
+	if st.commentID == 0 {
+		body := gitee.PullRequestCommentPostParam{}
+		body.AccessToken = handler.Config.GiteeToken
+		body.Body = message
+		comment, _, err := handler.GiteeClient.PullRequestsApi.PostV5ReposOwnerRepoPullsNumberComments(
+			handler.Context, st.owner, st.repo, st.number, body)
+		if err != nil {
+			glog.Errorf("unable to add comment in pull request: %v", err)
+			return
+		}
+		st.commentID = comment.Id
+		st.message = message
+		return
+	}
+	handler.editStatusComment(st, message)
+}
+
+func (handler *MergeQueueHandler) editStatusComment(st *mergeQueueStatus, message string) {
+	if st.commentID == 0 || st.message == message {
+		return
+	}
+	body := gitee.PullRequestCommentPatchParam{}
+	body.AccessToken = handler.Config.GiteeToken
+	body.Body = message
+	_, _, err := handler.GiteeClient.PullRequestsApi.PatchV5ReposOwnerRepoPullsCommentsId(
+		handler.Context, st.owner, st.repo, st.commentID, body)
+	if err != nil {
+		glog.Errorf("unable to edit comment in pull request: %v", err)
+		return
+	}
+	st.message = message
+}
+
+// mergeQueueEnabled checks whether the repository is merged by merge queue
+func (s *Server) mergeQueueEnabled(owner, repo string) bool {
+	for _, r := range s.Config.MergeQueue.Repos {
+		if r == owner+"/"+repo {
+			return true
+		}
+	}
+	return false
+}
+
+// notifyMergeQueue asks merge queue handler to sync without blocking
+func notifyMergeQueue() {
+	select {
+	case mergeQueueChanged <- struct{}{}:
+	default:
+	}
+}
+
+// mergeQueuePriority returns the index of the first matched priority label,
+// the pull request without priority labels is in the lowest priority
+func mergeQueuePriority(labels []gitee.Label, priorityLabels []string) int {
+	for i, pl := range priorityLabels {
+		for _, l := range labels {
+			if l.Name == pl {
+				return i
+			}
+		}
+	}
+	return len(priorityLabels)
+}
+
+// sortMergeQueue orders the pull requests by priority, then the older first
+func sortMergeQueue(items []mergeQueueItem) {
+	sort.SliceStable(items, func(i, j int) bool {
+		if items[i].priority != items[j].priority {
+			return items[i].priority < items[j].priority
+		}
+		ti, erri := time.Parse(time.RFC3339, items[i].pr.CreatedAt)
+		tj, errj := time.Parse(time.RFC3339, items[j].pr.CreatedAt)
+		if erri != nil || errj != nil {
+			return items[i].pr.Number < items[j].pr.Number
+		}
+		return ti.Before(tj)
+	})
+}
+
+func mergeQueueKey(owner, repo string, number int32) string {
+	return fmt.Sprintf("%s/%s/%d", owner, repo, number)
+}
+
+// splitFullName splits owner/repo
+func splitFullName(fullName string) (string, string) {
+	parts := strings.SplitN(fullName, "/", 2)
+	if len(parts) != 2 {
+		return "", ""
+	}
+	return parts[0], parts[1]
+}
+
+// pullRequestHookOf converts the fetched pull request for the helpers handling the hook
+func pullRequestHookOf(pr gitee.PullRequest) *gitee.PullRequestHook {
+	hook := &gitee.PullRequestHook{}
+	hook.Number = pr.Number
+	hook.Mergeable = pr.Mergeable
+	for _, a := range pr.Assignees {
+		hook.Assignees = append(hook.Assignees, gitee.UserHook{Login: a.Login})
+	}
+	for _, t := range pr.Testers {
+		hook.Testers = append(hook.Testers, gitee.UserHook{Login: t.Login})
+	}
+	return hook
+}
@@ -0,0 +1,58 @@
+package cibot
+
+import (
+	"reflect"
+	"testing"
+
+	"gitee.com/openeuler/go-gitee/gitee"
+)
+
+func Test_sortMergeQueue(t *testing.T) {
+	priorityLabels := []string{"priority/high", "priority/medium"}
+	item := func(number int32, createdAt string, labels ...string) mergeQueueItem {
+		pr := gitee.PullRequest{Number: number, CreatedAt: createdAt}
+		for _, l := range labels {
+			pr.Labels = append(pr.Labels, gitee.Label{Name: l})
+		}
+		return mergeQueueItem{pr: pr, priority: mergeQueuePriority(pr.Labels, priorityLabels)}
+	}
+	items := []mergeQueueItem{
+		item(1, "2020-03-01T10:00:00+08:00"),
+		item(2, "2020-03-03T10:00:00+08:00", "priority/medium"),
+		item(3, "2020-03-02T10:00:00+08:00", "lgtm", "priority/high"),
+		item(4, "2020-02-28T10:00:00+08:00"),
+		item(5, "2020-03-01T10:00:00+08:00", "priority/medium", "priority/high"),
+	}
+	sortMergeQueue(items)
+	got := make([]int32, 0, len(items))
+	for _, i := range items {
+		got = append(got, i.pr.Number)
+	}
+	if want := []int32{5, 3, 2, 4, 1}; !reflect.DeepEqual(got, want) {
+		t.Errorf("sortMergeQueue() = %v, want %v", got, want)
+	}
+}
+
+func Test_ciResultOf(t *testing.T) {
+	labels := func(names ...string) []gitee.Label {
+		result := make([]gitee.Label, 0, len(names))
+		for _, n := range names {
+			result = append(result, gitee.Label{Name: n})
+		}
+		return result
+	}
+	failures := []string{"ci_failed"}
+	tests := []struct {
+		labels []gitee.Label
+		want   string
+	}{
+		{labels("lgtm"), ""},
+		{labels("lgtm", "ci_successful"), ciResultSuccess},
+		{labels("ci_successful", "ci_failed"), ciResultFailure},
+	}
+	for _, tt := range tests {
+		if got := ciResultOf(tt.labels, "ci_successful", failures); got != tt.want {
+			t.Errorf("ciResultOf(%v) = %q, want %q", tt.labels, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,409 @@
+package cibot
+
+import (
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"sync"
+	"text/template"
+
+	"gitee.com/openeuler/ci-bot/pkg/cibot/config"
+	"github.com/golang/glog"
+)
+
+const (
+	LocaleEn   = "en"
+	LocaleZhCN = "zh_CN"
+
+	// the extension of message template files: <messageTemplateDir>/<locale>/<message id>.tmpl
+	messageTemplateExt = ".tmpl"
+	defaultSigLink     = "https://gitee.com/openeuler/community/tree/master/sig/%s"
+)
+
+// message ids
+const (
+	tipBotMessage                      = "tip-bot"
+	autoAddProjectMessage              = "auto-add-project"
+	lgtmSelfOwnMessage                 = "lgtm-self-own"
+	lgtmAddedMessage                   = "lgtm-added"
+	lgtmRemovedMessage                 = "lgtm-removed"
+	lgtmAddNoPermissionMessage         = "lgtm-add-no-permission"
+	lgtmRemoveNoPermissionMessage      = "lgtm-remove-no-permission"
+	lgtmRemovePullRequestChangeMessage = "lgtm-removed-by-change"
+	approvedAddedMessage               = "approved-added"
+	approvedRemovedMessage             = "approved-removed"
+	approvedAddNoPermissionMessage     = "approved-add-no-permission"
+	approvedRemoveNoPermissionMessage  = "approved-remove-no-permission"
+	issueAssignMessage                 = "issue-assigned"
+	issueCanNotAssignMessage           = "issue-cannot-assign"
+	issueNoNeedAssignMessage           = "issue-no-need-assign"
+	issueUnAssignMessage               = "issue-unassigned"
+	issueCanNotUnAssignMessage         = "issue-cannot-unassign"
+	closeIssueMessage                  = "issue-closed"
+	closePullRequestMessage            = "pull-request-closed"
+	reopenIssueMessage                 = "issue-reopened"
+	claNotFoundMessage                 = "cla-not-found"
+	claFoundMessage                    = "cla-found"
+	checkPrComment                     = "check-pr-not-open"
+	cleanRebaseMessage                 = "clean-rebase"
+	labelsRemovedByChangeMessage       = "labels-removed-by-change"
+	cannotMergeMessage                 = "cannot-merge"
+	notReadyForMergeMessage            = "not-ready-for-merge"
+	mergeFailedMessage                 = "merge-failed"
+	frozenMergeFailedMessage           = "frozen-merge-failed"
+	lifecycleStaleMessage              = "lifecycle-stale"
+	lifecycleRottenMessage             = "lifecycle-rotten"
+	lifecycleCloseMessage              = "lifecycle-closed"
+	mergeQueuePositionMessage          = "merge-queue-position"
+	mergeQueueRetestMessage            = "merge-queue-retest"
+	mergeQueueMergedMessage            = "merge-queue-merged"
+	mergeQueueLeftMessage              = "merge-queue-left"
+	needsRebaseMessage                 = "needs-rebase"
+	freezeStartedMessage               = "freeze-started"
+	freezeEndedMessage                 = "freeze-ended"
+	freezeExceptionRequestedMessage    = "freeze-exception-requested"
+	freezeExceptionApprovedMessage     = "freeze-exception-approved"
+	freezeExceptionNotFrozenMessage    = "freeze-exception-not-frozen"
+	freezeExceptionNotAuthorMessage    = "freeze-exception-not-author"
+	freezeExceptionNoPermissionMessage = "freeze-exception-no-permission"
+	branchStateRequiresLabelsMessage   = "branch-state-requires-labels"
+	branchStateMergeMessage            = "branch-state-merge-failed"
+	branchEOLClosedMessage             = "branch-eol-closed"
+	branchEOLMergeMessage              = "branch-eol-merge-failed"
+	commitLintFailedMessage            = "commit-lint-failed"
+	issueNeedsInfoMessage              = "issue-needs-info"
+	// the message of commit lint problem is the prefix with problem, e.g. commit-lint-body-missing
+	commitLintProblemMessagePrefix = "commit-lint-"
+)
+
+// MessageData is the variables of message template, the community variables
+// CommunityName, BotName, CommandLink, ClaLink and ContactEmail are always available
+type MessageData map[string]interface{}
+
+// defaultMessages are the built-in message templates per locale
+var defaultMessages = map[string]map[string]string{
+	LocaleEn: {
+		tipBotMessage: `Hi ***{{.Author}}***, welcome to the {{.CommunityName}} Community.
+I'm the Bot here serving you. You can find the instructions on how to interact with me at
+<{{.CommandLink}}>.
+{{if .Committors}}If you have any questions, please contact the SIG: [{{.Sig}}]({{sigLink .Sig}}), and any of the maintainers: {{range $i, $c := .Committors}}{{if $i}}, {{end}}***@{{$c}}***{{end}}.{{end}}{{if .Assignee}}
+This issue is assigned to ***@{{.Assignee}}*** of the SIG for triage.{{end}}`,
+		autoAddProjectMessage: `Since you have added a item to the src-openeuler.yaml file, we will automatically generate a default package in project openEuler:Factory on OBS cluster for you.
+If you need a more customized configuration, you can configure it according to the following instructions: {{.GuideURL}}`,
+		lgtmSelfOwnMessage: `Sorry, you cannot add ***lgtm*** to the pull request you created. :astonished:`,
+		lgtmAddedMessage: `***lgtm*** was added to this pull request by: ***{{.Commenter}}***. :wave:
+**NOTE:** If this pull request is not merged while all conditions are met, comment "/check-pr" to try again. :smile: `,
+		lgtmRemovedMessage: `***lgtm*** was removed in this pull request by: ***{{.Commenter}}***. :flushed: `,
+		lgtmAddNoPermissionMessage: `Thanks for your review, ***{{.Commenter}}***, your opinion is very important to us.:wave:
+The maintainers will consider your advice carefully.`,
+		lgtmRemoveNoPermissionMessage: `***{{.Commenter}}*** has no permission to remove ***lgtm*** in this pull request. :astonished:
+please contact to the collaborators in this repository.`,
+		lgtmRemovePullRequestChangeMessage: `new changes are detected. ***{{join .Labels ","}}*** is removed in this pull request by: ***{{.BotName}}***. :flushed: `,
+		approvedAddedMessage: `***approved*** was added to this pull request by: ***{{.Commenter}}***. :wave:
+**NOTE:**: If you find this pull request unmerged while all conditions meets, you are encouraged use command: "/check-pr" to try it again. :smile: `,
+		approvedRemovedMessage: `***approved*** was removed in this pull request by: ***{{.Commenter}}***. :flushed: `,
+		approvedAddNoPermissionMessage: `***{{.Commenter}}*** has no permission to add ***approved*** in this pull request. :astonished:
+please contact to the collaborators in this repository.`,
+		approvedRemoveNoPermissionMessage: `***{{.Commenter}}*** has no permission to remove ***approved*** in this pull request. :astonished:
+please contact to the collaborators in this repository.`,
+		issueAssignMessage: `this issue is assigned to: ***@{{.Assignee}}***.`,
+		issueCanNotAssignMessage: `this issue can not be assigned to: ***{{.Assignee}}***.
+please try to assign to the repository collaborators.`,
+		issueNoNeedAssignMessage: `this issue is already assigned to: ***{{.Assignee}}***.
+please do not assign repeatedly.`,
+		issueUnAssignMessage: `***@{{.Assignee}}*** is unassigned from this issue.`,
+		issueCanNotUnAssignMessage: `***@{{.Assignee}}*** can not be unassigned from this issue.
+please try to unassign the assignee from this issue.`,
+		closeIssueMessage:       `this issue is closed by: ***{{.Commenter}}***.`,
+		closePullRequestMessage: `this pull request is closed by: ***{{.Commenter}}***.`,
+		reopenIssueMessage:      `this issue is reopened by: ***{{.Commenter}}***.`,
+		claNotFoundMessage: `Thanks for your pull request.
+**Before we can look at your pull request, you'll need to sign a Contributor License Agreement (CLA).**
+**Please follow instructions at <{{.ClaLink}}> to sign the CLA.**
+It may take a couple minutes for the CLA signature to be fully registered;
+after that, please reply here with a new comment **/check-cla** and we'll verify.
+- If you've already signed a CLA, it's possible we don't have your Gitee username or you're using a different email address.
+  Check your existing CLA data and verify that your email at <https://gitee.com/profile/emails>.
+- If you have done the above and are still having issues with the CLA being reported as unsigned,
+  send a message to the backup e-mail support address at: {{.ContactEmail}}
+
+**Every author, committer and co-author of the commits needs to sign the CLA:**{{range .Commits}}
+- {{.Sha}} {{.Subject}}{{range .Identities}}
+  - {{.Role}} {{.Name}} <{{.MaskedEmail}}>: {{if .Signed}}:white_check_mark: signed{{else if eq .Status "outdated"}}:warning: signed an outdated version of the CLA, please sign the current version at <{{$.ClaLink}}> again{{else if eq .Status "revoked"}}:x: the CLA signature was revoked, please contact {{$.ContactEmail}}{{else}}:x: not signed{{end}}{{end}}{{end}}
+`,
+		claFoundMessage: `Thanks for your pull request. All authors, committers and co-authors of the commits have already signed {{.CommunityName}} CLA successfully. :wave: {{range .Commits}}
+- {{.Sha}} {{.Subject}}{{range .Identities}}
+  - {{.Role}} {{.Name}} <{{.MaskedEmail}}>: {{if .Signed}}:white_check_mark: signed{{else if eq .Status "outdated"}}:warning: signed an outdated version of the CLA, please sign the current version at <{{$.ClaLink}}> again{{else if eq .Status "revoked"}}:x: the CLA signature was revoked, please contact {{$.ContactEmail}}{{else}}:x: not signed{{end}}{{end}}{{end}}`,
+		checkPrComment:               `Cannot use "/check-pr", because this command is only used to detect open pull requests`,
+		cleanRebaseMessage:           `The source branch is rebased without changing the diff of this pull request, ***{{join .Labels ","}}*** is kept by: ***{{.BotName}}***. :wink: `,
+		labelsRemovedByChangeMessage: `Changes detected. ***{{join .Labels ","}}*** was removed from this pull request by: ***{{.BotName}}***. :flushed: `,
+		cannotMergeMessage: `This pull request can not be merged{{if .Policy}} by merge policy **{{.Policy}}**{{end}}, you can try it again when label requirement meets. :astonished:
+{{if .NonRequiringLabels}} Labels [**{{join .NonRequiringLabels ","}}**] need to be added.{{end}}{{if .NonMissingLabels}} Labels [**{{join .NonMissingLabels ","}}**] need to be removed.{{end}}{{range .NonOneOfLabels}} One of labels [**{{join . ","}}**] needs to be added.{{end}}`,
+		notReadyForMergeMessage: `This pull request can not be merged{{if .Policy}} by merge policy **{{.Policy}}**{{end}}, please check that the number of **lgtm** labels >= {{.LeastLgtm}} and there are an **approve** labels{{if .ApproverSources}} from {{join .ApproverSources ","}}{{end}}. {{if lt .Lgtm .LeastLgtm}}
+ **lgtm**: {{.Lgtm}}/{{.LeastLgtm}}.{{end}}{{if not .Approved}}
+ **approve**: missing.{{end}}{{if .SigOwnerLgtmMissing}}
+ **lgtm** from the SIG maintainers: missing.{{end}}`,
+		mergeFailedMessage:       `The pull request merge failed, please use command "/check-pr" to try again. `,
+		frozenMergeFailedMessage: `**Merge failed** The current pull request merge target has been frozen, and only the branch owner{{if .Owners}}( {{range $i, $o := .Owners}}{{if $i}} , {{end}}@{{$o}}{{end}} ){{end}} can merge.`,
+		lifecycleStaleMessage: `This {{if .IsPullRequest}}pull request{{else}}issue{{end}} has had no activity for {{.Days}} days and is marked as ***{{.Label}}***.
+If it is still in progress, please comment ***/remove-lifecycle stale***, or ***/lifecycle frozen*** to keep it open forever. :sleeping: `,
+		lifecycleRottenMessage: `This {{if .IsPullRequest}}pull request{{else}}issue{{end}} has had no activity for {{.Days}} days since it was marked as stale and is marked as ***{{.Label}}***.
+It will be closed after {{.CloseDays}} days of inactivity, please comment ***/remove-lifecycle rotten*** if it is still in progress. :zzz: `,
+		lifecycleCloseMessage:     `This {{if .IsPullRequest}}pull request{{else}}issue{{end}} is closed by: ***{{.BotName}}*** because it has been rotten for {{.Days}} days. Please reopen it if it is still needed. :wave: `,
+		mergeQueuePositionMessage: `This pull request is in the merge queue of branch ***{{.Branch}}***, position: ***{{.Position}}/{{.Total}}***. :hourglass: `,
+		mergeQueueRetestMessage:   `This pull request is at the head of the merge queue of branch ***{{.Branch}}***, but the branch has moved. CI is triggered again before merging. :repeat: `,
+		mergeQueueMergedMessage:   `This pull request is merged by the merge queue of branch ***{{.Branch}}***. :tada: `,
+		mergeQueueLeftMessage:     `This pull request left the merge queue of branch ***{{.Branch}}*** because it is no longer ready for merge. :wave: `,
+		needsRebaseMessage: `***@{{.Author}}*** This pull request can not be merged because of conflicts with the target branch ***{{.Branch}}***, please rebase it. :scream: {{if .Files}}
+Files changed on both sides, which possibly conflict: **{{join .Files ", "}}**{{end}}`,
+		freezeStartedMessage: `The target branch ***{{.Branch}}*** of this pull request is frozen{{if .End}} until {{.End}}{{end}}, and only the branch owner{{if .Owners}}( {{range $i, $o := .Owners}}{{if $i}} , {{end}}@{{$o}}{{end}} ){{end}} can merge. :snowflake: `,
+		freezeEndedMessage:   `The freeze of target branch ***{{.Branch}}*** has ended, this pull request can be merged when it is ready. :sunny: `,
+		freezeExceptionRequestedMessage: `***{{.Commenter}}*** requested a freeze exception for the frozen branch ***{{.Branch}}***: {{.Reason}}
+{{if .Owners}}{{range $i, $o := .Owners}}{{if $i}} , {{end}}@{{$o}}{{end}} {{end}}The branch owners can comment ***/freeze-exception approve*** to allow merging this pull request. :pray: `,
+		freezeExceptionApprovedMessage:     `***{{.Commenter}}*** approved the freeze exception for the frozen branch ***{{.Branch}}***. This pull request can be merged at commit {{.Sha}}, new commits need another approval. :ok_hand: `,
+		freezeExceptionNotFrozenMessage:    `***{{.Commenter}}*** the target branch ***{{.Branch}}*** is not frozen, no freeze exception is needed. :smile: `,
+		freezeExceptionNotAuthorMessage:    `***{{.Commenter}}*** only the author of this pull request can request a freeze exception. :astonished: `,
+		freezeExceptionNoPermissionMessage: `***{{.Commenter}}*** has no permission to approve the freeze exception, only the owners of branch ***{{.Branch}}***{{if .Owners}}( {{range $i, $o := .Owners}}{{if $i}} , {{end}}@{{$o}}{{end}} ){{end}} can approve it. :astonished: `,
+		branchStateRequiresLabelsMessage:   `***@{{.Author}}*** the target branch ***{{.Branch}}*** is in {{.State}}, this pull request requires one of the labels [**{{join .Labels ","}}**] to be merged. :mag: `,
+		branchStateMergeMessage:            `This pull request can not be merged, the target branch ***{{.Branch}}*** is in {{.State}} and requires one of the labels [**{{join .Labels ","}}**]. :astonished: `,
+		branchEOLClosedMessage:             `***@{{.Author}}*** the target branch ***{{.Branch}}*** has reached its end of life and accepts no changes, this pull request is closed. :wave: `,
+		branchEOLMergeMessage:              `This pull request can not be merged, the target branch ***{{.Branch}}*** has reached its end of life. :astonished: `,
+		issueNeedsInfoMessage: `***@{{.Author}}*** the following sections of this issue are missing or empty, please edit the issue to fill them in. The label ***{{.Label}}*** will be removed once they are complete. :mag: {{range .Sections}}
+* {{.}}{{end}}`,
+		commitLintFailedMessage: `***@{{.Author}}*** the following commits of this pull request fail the check, please amend them. :scream: {{range .Failures}}
+* {{printf "%.8s" .Sha}} {{.Subject}}: {{join .Problems "; "}}{{end}}`,
+		commitLintProblemMessagePrefix + commitProblemSignedOffMissing:  "no Signed-off-by trailer, please sign off with `git commit -s`",
+		commitLintProblemMessagePrefix + commitProblemSignedOffMismatch: "no Signed-off-by trailer of the author {{.Author}} <{{.Email}}>",
+		commitLintProblemMessagePrefix + commitProblemSubjectTooLong:    "the subject is longer than {{.MaxLength}} characters",
+		commitLintProblemMessagePrefix + commitProblemSubjectFormat:     "the subject does not match `{{.Pattern}}`",
+		commitLintProblemMessagePrefix + commitProblemSubjectForbidden:  "the subject shows a work in progress or fixup commit",
+		commitLintProblemMessagePrefix + commitProblemBodyMissing:       "the body describing the change is required since the pull request changes {{.Lines}} lines or more",
+	},
+	LocaleZhCN: {
+		tipBotMessage: `***{{.Author}}*** 您好，欢迎来到 {{.CommunityName}} 社区。
+我是这里的机器人，您可以在 <{{.CommandLink}}> 找到与我交互的说明。
+{{if .Committors}}如有任何问题，请联系 SIG：[{{.Sig}}]({{sigLink .Sig}})，或者任意一位维护者：{{range $i, $c := .Committors}}{{if $i}}、{{end}}***@{{$c}}***{{end}}。{{end}}{{if .Assignee}}
+此 Issue 已分配给 SIG 的 ***@{{.Assignee}}*** 进行分析处理。{{end}}`,
+		autoAddProjectMessage: `由于您在 src-openeuler.yaml 文件中新增了条目，我们将自动在 OBS 集群的 openEuler:Factory 工程中为您生成默认的软件包。
+如果需要更多的定制配置，请参考以下说明：{{.GuideURL}}`,
+		lgtmSelfOwnMessage: `抱歉，您不能为自己创建的 Pull Request 添加 ***lgtm***。:astonished:`,
+		lgtmAddedMessage: `***{{.Commenter}}*** 为此 Pull Request 添加了 ***lgtm***。:wave:
+**注意：** 如果在满足所有条件后此 Pull Request 仍未合入，请评论 "/check-pr" 重试。:smile: `,
+		lgtmRemovedMessage: `***{{.Commenter}}*** 移除了此 Pull Request 的 ***lgtm***。:flushed: `,
+		lgtmAddNoPermissionMessage: `感谢您的检视，***{{.Commenter}}***，您的意见对我们非常重要。:wave:
+维护者会认真考虑您的建议。`,
+		lgtmRemoveNoPermissionMessage: `***{{.Commenter}}*** 没有权限移除此 Pull Request 的 ***lgtm***。:astonished:
+请联系此仓库的协作者。`,
+		lgtmRemovePullRequestChangeMessage: `检测到新的修改，***{{.BotName}}*** 移除了此 Pull Request 的 ***{{join .Labels ","}}***。:flushed: `,
+		approvedAddedMessage: `***{{.Commenter}}*** 为此 Pull Request 添加了 ***approved***。:wave:
+**注意：** 如果在满足所有条件后此 Pull Request 仍未合入，请评论 "/check-pr" 重试。:smile: `,
+		approvedRemovedMessage: `***{{.Commenter}}*** 移除了此 Pull Request 的 ***approved***。:flushed: `,
+		approvedAddNoPermissionMessage: `***{{.Commenter}}*** 没有权限为此 Pull Request 添加 ***approved***。:astonished:
+请联系此仓库的协作者。`,
+		approvedRemoveNoPermissionMessage: `***{{.Commenter}}*** 没有权限移除此 Pull Request 的 ***approved***。:astonished:
+请联系此仓库的协作者。`,
+		issueAssignMessage: `此 Issue 已指派给：***@{{.Assignee}}***。`,
+		issueCanNotAssignMessage: `此 Issue 不能指派给：***{{.Assignee}}***。
+请尝试指派给此仓库的协作者。`,
+		issueNoNeedAssignMessage: `此 Issue 已经指派给：***{{.Assignee}}***。
+请不要重复指派。`,
+		issueUnAssignMessage: `已取消 ***@{{.Assignee}}*** 对此 Issue 的指派。`,
+		issueCanNotUnAssignMessage: `不能取消 ***@{{.Assignee}}*** 对此 Issue 的指派。
+请尝试取消此 Issue 负责人的指派。`,
+		closeIssueMessage:       `此 Issue 已被 ***{{.Commenter}}*** 关闭。`,
+		closePullRequestMessage: `此 Pull Request 已被 ***{{.Commenter}}*** 关闭。`,
+		reopenIssueMessage:      `此 Issue 已被 ***{{.Commenter}}*** 重新打开。`,
+		claNotFoundMessage: `感谢您提交的 Pull Request。
+**在我们检视您的 Pull Request 之前，您需要签署贡献者许可协议（CLA）。**
+**请按照 <{{.ClaLink}}> 中的说明签署 CLA。**
+CLA 签署可能需要几分钟才能完全生效，
+之后请在此回复新的评论 **/check-cla**，我们将重新检查。
+- 如果您已经签署了 CLA，可能是我们没有您的 Gitee 用户名，或者您使用了不同的邮箱地址。
+  请检查您的 CLA 信息，并在 <https://gitee.com/profile/emails> 确认您的邮箱。
+- 如果完成以上步骤后仍然提示未签署 CLA，
+  请发送邮件至支持邮箱：{{.ContactEmail}}
+
+**提交的每一位作者、提交者和共同作者都需要签署 CLA：**{{range .Commits}}
+- {{.Sha}} {{.Subject}}{{range .Identities}}
+  - {{.Role}} {{.Name}} <{{.MaskedEmail}}>：{{if .Signed}}:white_check_mark: 已签署{{else if eq .Status "outdated"}}:warning: 签署的 CLA 版本已过期，请在 <{{$.ClaLink}}> 重新签署当前版本{{else if eq .Status "revoked"}}:x: CLA 签署已被撤销，请联系 {{$.ContactEmail}}{{else}}:x: 未签署{{end}}{{end}}{{end}}
+`,
+		claFoundMessage: `感谢您提交的 Pull Request，提交的所有作者、提交者和共同作者都已经成功签署了 {{.CommunityName}} CLA。:wave: {{range .Commits}}
+- {{.Sha}} {{.Subject}}{{range .Identities}}
+  - {{.Role}} {{.Name}} <{{.MaskedEmail}}>：{{if .Signed}}:white_check_mark: 已签署{{else if eq .Status "outdated"}}:warning: 签署的 CLA 版本已过期，请在 <{{$.ClaLink}}> 重新签署当前版本{{else if eq .Status "revoked"}}:x: CLA 签署已被撤销，请联系 {{$.ContactEmail}}{{else}}:x: 未签署{{end}}{{end}}{{end}}`,
+		checkPrComment:               `不能使用 "/check-pr"，此命令仅用于检查处于打开状态的 Pull Request`,
+		cleanRebaseMessage:           `源分支变基后此 Pull Request 的差异没有变化，***{{.BotName}}*** 保留了 ***{{join .Labels ","}}***。:wink: `,
+		labelsRemovedByChangeMessage: `检测到修改，***{{.BotName}}*** 移除了此 Pull Request 的 ***{{join .Labels ","}}***。:flushed: `,
+		cannotMergeMessage: `此 Pull Request {{if .Policy}}按合入策略 **{{.Policy}}** {{end}}不能合入，请在满足标签要求后重试。:astonished:
+{{if .NonRequiringLabels}} 需要添加标签 [**{{join .NonRequiringLabels ","}}**]。{{end}}{{if .NonMissingLabels}} 需要移除标签 [**{{join .NonMissingLabels ","}}**]。{{end}}{{range .NonOneOfLabels}} 需要添加标签 [**{{join . ","}}**] 之一。{{end}}`,
+		notReadyForMergeMessage: `此 Pull Request {{if .Policy}}按合入策略 **{{.Policy}}** {{end}}不能合入，请确认 **lgtm** 标签的数量 >= {{.LeastLgtm}} 并且存在{{if .ApproverSources}}来自 {{join .ApproverSources ","}} 的{{end}} **approve** 标签。{{if lt .Lgtm .LeastLgtm}}
+ **lgtm**：{{.Lgtm}}/{{.LeastLgtm}}。{{end}}{{if not .Approved}}
+ **approve**：缺失。{{end}}{{if .SigOwnerLgtmMissing}}
+ SIG 维护者的 **lgtm**：缺失。{{end}}`,
+		mergeFailedMessage:       `Pull Request 合入失败，请使用命令 "/check-pr" 重试。`,
+		frozenMergeFailedMessage: `**合入失败** 此 Pull Request 的目标分支已冻结，仅分支负责人{{if .Owners}}（{{range $i, $o := .Owners}}{{if $i}} , {{end}}@{{$o}}{{end}}）{{end}}可以合入。`,
+		lifecycleStaleMessage: `此{{if .IsPullRequest}} Pull Request {{else}} Issue {{end}}已经 {{.Days}} 天没有活动，被标记为 ***{{.Label}}***。
+如果仍在进行中，请评论 ***/remove-lifecycle stale***，或者评论 ***/lifecycle frozen*** 使其永久保持打开。:sleeping: `,
+		lifecycleRottenMessage: `此{{if .IsPullRequest}} Pull Request {{else}} Issue {{end}}在被标记为 stale 后又 {{.Days}} 天没有活动，被标记为 ***{{.Label}}***。
+它将在 {{.CloseDays}} 天没有活动后被关闭，如果仍在进行中，请评论 ***/remove-lifecycle rotten***。:zzz: `,
+		lifecycleCloseMessage:     `此{{if .IsPullRequest}} Pull Request {{else}} Issue {{end}}已经 rotten {{.Days}} 天，被 ***{{.BotName}}*** 关闭。如仍需要，请重新打开。:wave: `,
+		mergeQueuePositionMessage: `此 Pull Request 在分支 ***{{.Branch}}*** 的合入队列中，位置：***{{.Position}}/{{.Total}}***。:hourglass: `,
+		mergeQueueRetestMessage:   `此 Pull Request 位于分支 ***{{.Branch}}*** 合入队列的队首，但分支已经变化，合入前将重新触发 CI。:repeat: `,
+		mergeQueueMergedMessage:   `此 Pull Request 已由分支 ***{{.Branch}}*** 的合入队列合入。:tada: `,
+		mergeQueueLeftMessage:     `此 Pull Request 不再满足合入条件，已离开分支 ***{{.Branch}}*** 的合入队列。:wave: `,
+		needsRebaseMessage: `***@{{.Author}}*** 此 Pull Request 与目标分支 ***{{.Branch}}*** 存在冲突，不能合入，请变基。:scream: {{if .Files}}
+双方都修改了的文件，可能存在冲突：**{{join .Files ", "}}**{{end}}`,
+		freezeStartedMessage: `此 Pull Request 的目标分支 ***{{.Branch}}*** 已冻结{{if .End}}至 {{.End}}{{end}}，仅分支负责人{{if .Owners}}（{{range $i, $o := .Owners}}{{if $i}} , {{end}}@{{$o}}{{end}}）{{end}}可以合入。:snowflake: `,
+		freezeEndedMessage:   `目标分支 ***{{.Branch}}*** 已解除冻结，此 Pull Request 满足条件后即可合入。:sunny: `,
+		freezeExceptionRequestedMessage: `***{{.Commenter}}*** 为已冻结的分支 ***{{.Branch}}*** 申请冻结例外：{{.Reason}}
+{{if .Owners}}{{range $i, $o := .Owners}}{{if $i}} , {{end}}@{{$o}}{{end}} {{end}}分支负责人可以评论 ***/freeze-exception approve*** 允许合入此 Pull Request。:pray: `,
+		freezeExceptionApprovedMessage:     `***{{.Commenter}}*** 批准了已冻结分支 ***{{.Branch}}*** 的冻结例外。此 Pull Request 可以在提交 {{.Sha}} 合入，新的提交需要重新批准。:ok_hand: `,
+		freezeExceptionNotFrozenMessage:    `***{{.Commenter}}*** 目标分支 ***{{.Branch}}*** 未冻结，无需冻结例外。:smile: `,
+		freezeExceptionNotAuthorMessage:    `***{{.Commenter}}*** 只有此 Pull Request 的作者可以申请冻结例外。:astonished: `,
+		freezeExceptionNoPermissionMessage: `***{{.Commenter}}*** 没有权限批准冻结例外，仅分支 ***{{.Branch}}*** 的负责人{{if .Owners}}（{{range $i, $o := .Owners}}{{if $i}} , {{end}}@{{$o}}{{end}}）{{end}}可以批准。:astonished: `,
+		branchStateRequiresLabelsMessage:   `***@{{.Author}}*** 目标分支 ***{{.Branch}}*** 处于 {{.State}} 阶段，此 Pull Request 需要标签 [**{{join .Labels ","}}**] 之一才能合入。:mag: `,
+		branchStateMergeMessage:            `此 Pull Request 不能合入，目标分支 ***{{.Branch}}*** 处于 {{.State}} 阶段，需要标签 [**{{join .Labels ","}}**] 之一。:astonished: `,
+		branchEOLClosedMessage:             `***@{{.Author}}*** 目标分支 ***{{.Branch}}*** 已停止维护，不再接受修改，此 Pull Request 已关闭。:wave: `,
+		branchEOLMergeMessage:              `此 Pull Request 不能合入，目标分支 ***{{.Branch}}*** 已停止维护。:astonished: `,
+		issueNeedsInfoMessage: `***@{{.Author}}*** 此 Issue 的以下章节缺失或为空，请编辑 Issue 补充。补充完整后将移除标签 ***{{.Label}}***。:mag: {{range .Sections}}
+* {{.}}{{end}}`,
+		commitLintFailedMessage: `***@{{.Author}}*** 此 Pull Request 的以下提交未通过检查，请修改。:scream: {{range .Failures}}
+* {{printf "%.8s" .Sha}} {{.Subject}}：{{join .Problems "；"}}{{end}}`,
+		commitLintProblemMessagePrefix + commitProblemSignedOffMissing:  "缺少 Signed-off-by，请使用 `git commit -s` 签署",
+		commitLintProblemMessagePrefix + commitProblemSignedOffMismatch: "缺少作者 {{.Author}} <{{.Email}}> 的 Signed-off-by",
+		commitLintProblemMessagePrefix + commitProblemSubjectTooLong:    "标题超过 {{.MaxLength}} 个字符",
+		commitLintProblemMessagePrefix + commitProblemSubjectFormat:     "标题不符合格式 `{{.Pattern}}`",
+		commitLintProblemMessagePrefix + commitProblemSubjectForbidden:  "标题表明这是未完成或 fixup 的提交",
+		commitLintProblemMessagePrefix + commitProblemBodyMissing:       "此 Pull Request 修改了 {{.Lines}} 行以上，需要描述修改内容的正文",
+	},
+}
+
+var (
+	// overrideMessages are the message templates loaded from messageTemplateDir
+	overrideMessages     map[string]map[string]string
+	overrideMessagesLock sync.RWMutex
+)
+
+// LoadMessageTemplates loads the message templates which override the built-in ones,
+// the templates are in files <dir>/<locale>/<message id>.tmpl
+func LoadMessageTemplates(dir string) error {
+	messages := make(map[string]map[string]string)
+	if dir != "" {
+		locales, err := ioutil.ReadDir(dir)
+		if err != nil {
+			return err
+		}
+		for _, l := range locales {
+			if !l.IsDir() {
+				continue
+			}
+			files, err := ioutil.ReadDir(filepath.Join(dir, l.Name()))
+			if err != nil {
+				return err
+			}
+			for _, f := range files {
+				if f.IsDir() || filepath.Ext(f.Name()) != messageTemplateExt {
+					continue
+				}
+				content, err := ioutil.ReadFile(filepath.Join(dir, l.Name(), f.Name()))
+				if err != nil {
+					return err
+				}
+				id := strings.TrimSuffix(f.Name(), messageTemplateExt)
+				// validate the template before it is used
+				if _, err = template.New(id).Funcs(messageFuncs(config.Config{})).Parse(string(content)); err != nil {
+					return fmt.Errorf("invalid message template %s: %v", f.Name(), err)
+				}
+				if messages[l.Name()] == nil {
+					messages[l.Name()] = make(map[string]string)
+				}
+				messages[l.Name()][id] = string(content)
+			}
+		}
+	}
+	glog.Infof("load message templates from %q: %d locales", dir, len(messages))
+
+	overrideMessagesLock.Lock()
+	defer overrideMessagesLock.Unlock()
+	overrideMessages = messages
+	return nil
+}
+
+func lookupMessage(locale, id string) (string, bool) {
+	overrideMessagesLock.RLock()
+	defer overrideMessagesLock.RUnlock()
+	if text, ok := overrideMessages[locale][id]; ok {
+		return text, true
+	}
+	text, ok := defaultMessages[locale][id]
+	return text, ok
+}
+
+func messageFuncs(cfg config.Config) template.FuncMap {
+	return template.FuncMap{
+		"join": strings.Join,
+		"sigLink": func(sig string) string {
+			link := cfg.SigLink
+			if link == "" {
+				link = defaultSigLink
+			}
+			return fmt.Sprintf(link, sig)
+		},
+	}
+}
+
+// MessageLocale gets the locale of repository, the setting of repository takes precedence over the organization
+func MessageLocale(cfg config.Config, owner, repo string) string {
+	locale := cfg.DefaultLocale
+	for _, l := range cfg.Locales {
+		if l.Name == owner+"/"+repo {
+			return l.Locale
+		}
+		if l.Name == owner {
+			locale = l.Locale
+		}
+	}
+	if locale == "" {
+		locale = LocaleEn
+	}
+	return locale
+}
+
+// RenderMessage renders the message in locale, and falls back to English
+// if the message is not translated or fails to render
+func RenderMessage(cfg config.Config, locale, id string, data MessageData) string {
+	vars := MessageData{
+		"CommunityName": cfg.CommunityName,
+		"BotName":       cfg.BotName,
+		"CommandLink":   cfg.CommandLink,
+		"ClaLink":       cfg.ClaLink,
+		"ContactEmail":  cfg.ContactEmail,
+	}
+	for k, v := range data {
+		vars[k] = v
+	}
+	for _, l := range []string{locale, LocaleEn} {
+		text, ok := lookupMessage(l, id)
+		if !ok {
+			continue
+		}
+		t, err := template.New(id).Funcs(messageFuncs(cfg)).Parse(text)
+		if err != nil {
+			glog.Errorf("unable to parse message %s in %s: %v", id, l, err)
+			continue
+		}
+		var b strings.Builder
+		if err = t.Execute(&b, vars); err != nil {
+			glog.Errorf("unable to render message %s in %s: %v", id, l, err)
+			continue
+		}
+		return b.String()
+	}
+	glog.Errorf("message %s is not found", id)
+	return id
+}
+
+// message renders the message in the locale of repository
+func (s *Server) message(owner, repo, id string, data MessageData) string {
+	return RenderMessage(s.Config, MessageLocale(s.Config, owner, repo), id, data)
+}
@@ -0,0 +1,105 @@
+package cibot
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"gitee.com/openeuler/ci-bot/pkg/cibot/config"
+)
+
+func TestMessageLocale(t *testing.T) {
+	cfg := config.Config{
+		DefaultLocale: LocaleEn,
+		Locales: []config.Locale{
+			{Name: "openeuler/docs", Locale: LocaleEn},
+			{Name: "openeuler", Locale: LocaleZhCN},
+		},
+	}
+	tests := []struct {
+		owner, repo string
+		want        string
+	}{
+		{"openeuler", "docs", LocaleEn},
+		{"openeuler", "kernel", LocaleZhCN},
+		{"src-openeuler", "kernel", LocaleEn},
+	}
+	for _, tt := range tests {
+		if got := MessageLocale(cfg, tt.owner, tt.repo); got != tt.want {
+			t.Errorf("MessageLocale(%s/%s) = %s, want %s", tt.owner, tt.repo, got, tt.want)
+		}
+	}
+	if got := MessageLocale(config.Config{}, "openeuler", "kernel"); got != LocaleEn {
+		t.Errorf("MessageLocale without setting = %s, want %s", got, LocaleEn)
+	}
+}
+
+func TestRenderMessage(t *testing.T) {
+	cfg := config.Config{BotName: "ci-bot"}
+	tests := []struct {
+		name   string
+		locale string
+		id     string
+		data   MessageData
+		want   string
+	}{
+		{"en", LocaleEn, approvedRemovedMessage, MessageData{"Commenter": "alice"},
+			"***approved*** was removed in this pull request by: ***alice***. :flushed: "},
+		{"zh_CN", LocaleZhCN, mergeFailedMessage, nil, `Pull Request 合入失败，请使用命令 "/check-pr" 重试。`},
+		{"unknown locale falls back", "fr", mergeFailedMessage, nil,
+			`The pull request merge failed, please use command "/check-pr" to try again. `},
+		{"frozen with owners", LocaleEn, frozenMergeFailedMessage, MessageData{"Owners": []string{"alice", "bob"}},
+			"**Merge failed** The current pull request merge target has been frozen, and only the branch owner( @alice , @bob ) can merge."},
+		{"config vars", LocaleEn, labelsRemovedByChangeMessage, MessageData{"Labels": []string{"lgtm", "approved"}},
+			"Changes detected. ***lgtm,approved*** was removed from this pull request by: ***ci-bot***. :flushed: "},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := RenderMessage(cfg, tt.locale, tt.id, tt.data); got != tt.want {
+				t.Errorf("RenderMessage() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDefaultMessagesTranslated(t *testing.T) {
+	for id := range defaultMessages[LocaleEn] {
+		if _, ok := defaultMessages[LocaleZhCN][id]; !ok {
+			t.Errorf("message %s is not translated to %s", id, LocaleZhCN)
+		}
+	}
+}
+
+func TestLoadMessageTemplates(t *testing.T) {
+	dir, err := ioutil.TempDir("", "messages")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	defer LoadMessageTemplates("")
+
+	if err = os.Mkdir(filepath.Join(dir, LocaleEn), 0755); err != nil {
+		t.Fatal(err)
+	}
+	err = ioutil.WriteFile(filepath.Join(dir, LocaleEn, mergeFailedMessage+messageTemplateExt),
+		[]byte("{{.BotName}} failed to merge"), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = LoadMessageTemplates(dir); err != nil {
+		t.Fatal(err)
+	}
+	cfg := config.Config{BotName: "ci-bot"}
+	if got := RenderMessage(cfg, LocaleEn, mergeFailedMessage, nil); got != "ci-bot failed to merge" {
+		t.Errorf("overridden message = %q", got)
+	}
+
+	err = ioutil.WriteFile(filepath.Join(dir, LocaleEn, mergeFailedMessage+messageTemplateExt), []byte("{{.BotName"), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = LoadMessageTemplates(dir); err == nil {
+		t.Errorf("invalid template is loaded")
+	}
+}
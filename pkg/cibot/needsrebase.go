@@ -0,0 +1,203 @@
+package cibot
+
+import (
+	"strings"
+	"time"
+
+	"gitee.com/openeuler/go-gitee/gitee"
+	"github.com/antihax/optional"
+	"github.com/golang/glog"
+)
+
+const (
+	// the limit of files to find the possibly conflicting ones in
+	needsRebaseMaxFiles = 20
+)
+
+var (
+	// gitee computes the mergeable asynchronously, so it is checked after a while since the push
+	needsRebaseDelay = 30 * time.Second
+	// the unmergeable is polled again to confirm
+	needsRebasePolls        = 3
+	needsRebasePollInterval = 10 * time.Second
+)
+
+// HandleNeedsRebaseByPush re-checks the open pull requests targeting the pushed branch
+func (s *Server) HandleNeedsRebaseByPush(event *gitee.PushEvent) {
+	if s.Config.NeedsRebaseLabel == "" || event.Ref == nil || event.Repository == nil {
+		return
+	}
+	if event.Deleted != nil && *event.Deleted {
+		return
+	}
+	branch := strings.TrimPrefix(*event.Ref, "refs/heads/")
+	owner := event.Repository.Namespace
+	repo := event.Repository.Path
+	time.Sleep(needsRebaseDelay)
+
+	// the latest commits of files in the pushed branch are shared by the pull requests
+	commits := make(map[string]string)
+
+	lvos := &gitee.GetV5ReposOwnerRepoPullsOpts{}
+	lvos.AccessToken = optional.NewString(s.Config.GiteeToken)
+	lvos.State = optional.NewString("open")
+	lvos.Base = optional.NewString(branch)
+	lvos.PerPage = optional.NewInt32(100)
+	for page := int32(1); ; page++ {
+		lvos.Page = optional.NewInt32(page)
+		prs, _, err := s.GiteeClient.PullRequestsApi.GetV5ReposOwnerRepoPulls(s.Context, owner, repo, lvos)
+		if err != nil {
+			glog.Errorf("unable to list pull requests of %s/%s: %v", owner, repo, err)
+			return
+		}
+		for _, pr := range prs {
+			err = s.checkNeedsRebase(owner, repo, pr.Number, commits)
+			if err != nil {
+				glog.Errorf("unable to check needs rebase of %s/%s/%d: %v", owner, repo, pr.Number, err)
+			}
+		}
+		if len(prs) < 100 {
+			break
+		}
+	}
+}
+
+// CheckNeedsRebaseLater checks whether the pull request needs rebase after gitee computes its mergeable
+func (s *Server) CheckNeedsRebaseLater(owner, repo string, number int32) {
+	if s.Config.NeedsRebaseLabel == "" {
+		return
+	}
+	go func() {
+		time.Sleep(needsRebaseDelay)
+		err := s.checkNeedsRebase(owner, repo, number, make(map[string]string))
+		if err != nil {
+			glog.Errorf("unable to check needs rebase of %s/%s/%d: %v", owner, repo, number, err)
+		}
+	}()
+}
+
+// pollMergeable gets the pull request, and polls it again while it is unmergeable,
+// which may be not computed yet
+func (s *Server) pollMergeable(owner, repo string, number int32) (gitee.PullRequest, error) {
+	lvos := &gitee.GetV5ReposOwnerRepoPullsNumberOpts{}
+	lvos.AccessToken = optional.NewString(s.Config.GiteeToken)
+	for i := 0; ; i++ {
+		pr, _, err := s.GiteeClient.PullRequestsApi.GetV5ReposOwnerRepoPullsNumber(s.Context, owner, repo, number, lvos)
+		if err != nil || pr.Mergeable || pr.State != "open" || i >= needsRebasePolls {
+			return pr, err
+		}
+		time.Sleep(needsRebasePollInterval)
+	}
+}
+
+// checkNeedsRebase adds the needs rebase label with a comment when pull request becomes unmergeable,
+// and removes the label when it is mergeable again. The latest commits of files in target branch
+// are cached in commits.
+func (s *Server) checkNeedsRebase(owner, repo string, number int32, commits map[string]string) error {
+	if s.Config.NeedsRebaseLabel == "" {
+		return nil
+	}
+	// the mergeable in payload or list may be stale
+	pr, err := s.pollMergeable(owner, repo, number)
+	if err != nil {
+		return err
+	}
+	if pr.State != "open" {
+		return nil
+	}
+	hasLabel := false
+	for _, l := range pr.Labels {
+		if l.Name == s.Config.NeedsRebaseLabel {
+			hasLabel = true
+			break
+		}
+	}
+
+	event := &gitee.NoteEvent{}
+	event.Repository = &gitee.ProjectHook{Namespace: owner, Path: repo, Name: repo}
+	event.PullRequest = pullRequestHookOf(pr)
+	event.Comment = &gitee.NoteHook{}
+	if pr.Mergeable {
+		if !hasLabel {
+			return nil
+		}
+		glog.Infof("pull request %s/%s/%d is mergeable again", owner, repo, number)
+		return s.RemoveSpecifyLabelsInPulRequest(event, map[string]string{
+			s.Config.NeedsRebaseLabel: s.Config.NeedsRebaseLabel})
+	}
+	// the author has been told
+	if hasLabel {
+		return nil
+	}
+	glog.Infof("pull request %s/%s/%d needs rebase", owner, repo, number)
+	err = s.AddSpecifyLabelsInPulRequest(event, []string{s.Config.NeedsRebaseLabel}, true)
+	if err != nil {
+		return err
+	}
+
+	files := s.getConflictingFiles(owner, repo, pr, commits)
+	author := ""
+	if pr.User != nil {
+		author = pr.User.Login
+	}
+	return s.addCommentToPullRequest(owner, repo,
+		s.message(owner, repo, needsRebaseMessage, MessageData{"Author": author, "Branch": pr.Base.Ref, "Files": files}), number)
+}
+
+// getConflictingFiles gets the files of pull request which are also changed in the target branch
+// since the pull request is based on it, which possibly conflict
+func (s *Server) getConflictingFiles(owner, repo string, pr gitee.PullRequest, commits map[string]string) []string {
+	if pr.Base == nil || pr.Base.Sha == "" {
+		return nil
+	}
+	lvos := &gitee.GetV5ReposOwnerRepoPullsNumberFilesOpts{}
+	lvos.AccessToken = optional.NewString(s.Config.GiteeToken)
+	files, _, err := s.GiteeClient.PullRequestsApi.GetV5ReposOwnerRepoPullsNumberFiles(s.Context, owner, repo, pr.Number, lvos)
+	if err != nil {
+		glog.Errorf("unable to get pull request files. err: %v", err)
+		return nil
+	}
+	if len(files) > needsRebaseMaxFiles {
+		files = files[:needsRebaseMaxFiles]
+	}
+
+	conflicting := make([]string, 0)
+	for _, f := range files {
+		// the file is changed in target branch if its latest commit differs from the one at the base of pull request
+		key := pr.Base.Ref + ":" + f.Filename
+		latest, ok := commits[key]
+		if !ok {
+			latest, err = s.latestCommitOfPath(owner, repo, pr.Base.Ref, f.Filename)
+			if err != nil {
+				continue
+			}
+			commits[key] = latest
+		}
+		based, err := s.latestCommitOfPath(owner, repo, pr.Base.Sha, f.Filename)
+		if err != nil {
+			continue
+		}
+		if latest != based {
+			conflicting = append(conflicting, f.Filename)
+		}
+	}
+	return conflicting
+}
+
+// latestCommitOfPath gets the sha of the latest commit changing path at ref
+func (s *Server) latestCommitOfPath(owner, repo, ref, path string) (string, error) {
+	lvos := &gitee.GetV5ReposOwnerRepoCommitsOpts{}
+	lvos.AccessToken = optional.NewString(s.Config.GiteeToken)
+	lvos.Sha = optional.NewString(ref)
+	lvos.Path = optional.NewString(path)
+	lvos.PerPage = optional.NewInt32(1)
+	commits, _, err := s.GiteeClient.RepositoriesApi.GetV5ReposOwnerRepoCommits(s.Context, owner, repo, lvos)
+	if err != nil {
+		glog.Errorf("unable to list commits of %s at %s: %v", path, ref, err)
+		return "", err
+	}
+	if len(commits) == 0 {
+		return "", nil
+	}
+	return commits[0].Sha, nil
+}
@@ -103,6 +103,28 @@ func (s *Server) HandleNoteEvent(event *gitee.NoteEvent) {
 		}
 	}
 
+	// lifecycle
+	if RegLifecycle.MatchString(event.Comment.Body) || RegRemoveLifecycle.MatchString(event.Comment.Body) {
+		err := s.HandleLifecycleCommand(event)
+		if err != nil {
+			glog.Errorf("failed to handle lifecycle: %v", err)
+		}
+	}
+
+	// freeze exception
+	if RegFreezeException.MatchString(event.Comment.Body) {
+		err := s.HandleFreezeException(event)
+		if err != nil {
+			glog.Errorf("failed to handle freeze exception: %v", err)
+		}
+	}
+
+	// re-check the issue template by the comments of author
+	err := s.CheckIssueTemplateByNoteEvent(event)
+	if err != nil {
+		glog.Errorf("failed to check issue template: %v", err)
+	}
+
 	//check pr
 	if RegCheckPr.MatchString(event.Comment.Body){
 		err := s.CheckPr(event)
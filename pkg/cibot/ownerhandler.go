@@ -19,6 +19,7 @@ type OwnerHandler struct {
 	Config      config.Config
 	Context     context.Context
 	GiteeClient *gitee.APIClient
+	aliasesSha  string
 }
 
 // Serve
@@ -31,6 +32,13 @@ func (handler *OwnerHandler) Serve() {
 func (handler *OwnerHandler) watch() {
 	for {
 		watchDuration := handler.Config.WatchOwnerFileDuration
+		// get community-wide aliases before expanding owners, the ones loaded last time are kept on error
+		aliasesErr := handler.loadCommunityAliases()
+		if aliasesErr != nil {
+			glog.Errorf("unable to load owner aliases: %v", aliasesErr)
+		}
+		// the aliases never loaded may be referenced by owners, which are not logins
+		aliasesReady := len(handler.Config.WatchOwnerAliasesFiles) == 0 || handler.aliasesSha != ""
 		// get repositories from DB
 		var rs []database.Repositories
 		err := database.DBConnection.Model(&database.Repositories{}).Find(&rs).Error
@@ -67,8 +75,19 @@ func (handler *OwnerHandler) watch() {
 							}
 						}
 
-						// based on repository
+						// keep the maintainers of sigs for the sig owner checks
 						if getOwnersResult {
+							owners := make(map[string][]string, len(mapSigOwners))
+							for sig, mapOwners := range mapSigOwners {
+								for o := range mapOwners {
+									owners[sig] = append(owners[sig], o)
+								}
+							}
+							setSigOwners(owners)
+						}
+
+						// based on repository
+						if getOwnersResult && aliasesReady {
 							for _, repo := range rs {
 								err = handler.handleOwners(repo, mapSigOwners)
 								if err != nil {
@@ -85,12 +104,47 @@ func (handler *OwnerHandler) watch() {
 			}
 		}
 
-		// watch duration
+		// watch duration or re-sync at once when aliases are changed
 		glog.Info("end to serve in owner")
-		time.Sleep(time.Duration(watchDuration) * time.Second)
+		select {
+		case <-time.After(time.Duration(watchDuration) * time.Second):
+		case <-ownerAliasesChanged:
+			glog.Info("owner aliases are changed, re-sync privileges")
+		}
 	}
 }
 
+// loadCommunityAliases loads the community-wide aliases files
+func (handler *OwnerHandler) loadCommunityAliases() error {
+	if len(handler.Config.WatchOwnerAliasesFiles) == 0 {
+		return nil
+	}
+
+	localVarOptionals := &gitee.GetV5ReposOwnerRepoContentsPathOpts{}
+	localVarOptionals.AccessToken = optional.NewString(handler.Config.GiteeToken)
+	aliasesList := make([]map[string][]string, 0, len(handler.Config.WatchOwnerAliasesFiles))
+	sha := ""
+	for _, wf := range handler.Config.WatchOwnerAliasesFiles {
+		localVarOptionals.Ref = optional.NewString(wf.WatchOwnerAliasesFileRef)
+		contents, _, err := handler.GiteeClient.RepositoriesApi.GetV5ReposOwnerRepoContentsPath(
+			handler.Context, wf.WatchOwnerAliasesFileOwner, wf.WatchOwnerAliasesFileRepo,
+			wf.WatchOwnerAliasesFilePath, localVarOptionals)
+		if err != nil {
+			// keep the aliases loaded last time
+			return err
+		}
+		sha += contents.Sha + ";"
+		aliasesList = append(aliasesList, DecodeOwnerAliases(contents.Content))
+	}
+
+	if sha != handler.aliasesSha {
+		glog.Infof("owner aliases are loaded. sha: %s", sha)
+		handler.aliasesSha = sha
+	}
+	setCommunityAliases(MergeOwnerAliases(aliasesList...))
+	return nil
+}
+
 // getOwners get owners
 func (handler *OwnerHandler) getOwners(wf config.WatchOwnerFile, name string) ([]string, error) {
 	// get params
@@ -126,14 +180,29 @@ func (handler *OwnerHandler) getOwners(wf config.WatchOwnerFile, name string) ([
 		return nil, err
 	}
 
-	// return owners
-	if len(owners.Maintainers) > 0 {
-		return owners.Maintainers, nil
+	// return owners, the aliases in them are expanded by repository
+	maintainers := ExpandOwnerAliases(owners.Maintainers, nil)
+	if len(maintainers) > 0 {
+		return maintainers, nil
 	}
 
 	return nil, nil
 }
 
+// repoAliasesOf gets the aliases of repository, which are loaded once and refreshed by push events
+func (handler *OwnerHandler) repoAliasesOf(repo database.Repositories) (map[string][]string, error) {
+	fullName := repo.Owner + "/" + repo.Repo
+	if aliases, ok := getRepoAliases(fullName); ok {
+		return aliases, nil
+	}
+	aliases, err := fetchRepoAliases(handler.Context, handler.GiteeClient, handler.Config.GiteeToken, repo.Owner, repo.Repo)
+	if err != nil {
+		return nil, err
+	}
+	setRepoAliases(fullName, aliases)
+	return aliases, nil
+}
+
 // handleOwners handle owners
 func (handler *OwnerHandler) handleOwners(repo database.Repositories, mapSigOwners map[string]map[string]string) error {
 	// get owner for sig
@@ -143,12 +212,22 @@ func (handler *OwnerHandler) handleOwners(repo database.Repositories, mapSigOwne
 	if err != nil {
 		glog.Errorf("unable to get sig repos: %v", err)
 	}
-	expectedMembers := make(map[string]string)
+	// expand the owners with the aliases of repository, which take precedence over the community-wide ones
+	aliases, err := handler.repoAliasesOf(repo)
+	if err != nil {
+		glog.Errorf("unable to get owner aliases of %s/%s: %v", repo.Owner, repo.Repo, err)
+		return err
+	}
+	var owners []string
 	for _, srepo := range srepos {
-		for k, v := range mapSigOwners[srepo.Name] {
-			expectedMembers[k] = v
+		for k := range mapSigOwners[srepo.Name] {
+			owners = append(owners, k)
 		}
 	}
+	expectedMembers := make(map[string]string)
+	for _, o := range ExpandOwnerAliases(owners, MergeOwnerAliases(getCommunityAliases(), aliases)) {
+		expectedMembers[o] = o
+	}
 
 	// get current owners
 	var ps []database.Privileges
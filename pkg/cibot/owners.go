@@ -1,7 +1,11 @@
 package cibot
 
 import (
+	"context"
 	"encoding/base64"
+	"net/http"
+	"strings"
+	"sync"
 
 	"gitee.com/openeuler/go-gitee/gitee"
 	"github.com/antihax/optional"
@@ -10,13 +14,34 @@ import (
 )
 
 var (
-	DefaultOwnerFileName = "OWNERS"
+	DefaultOwnerFileName        = "OWNERS"
+	DefaultOwnerAliasesFileName = "OWNERS_ALIASES"
 )
 
 type OwnersFile struct {
 	Maintainers []string `yaml:"maintainers"`
 }
 
+// OwnersAliasesFile defines named groups of logins which can be
+// referenced in OWNERS files instead of listing every login
+type OwnersAliasesFile struct {
+	Aliases map[string][]string `yaml:"aliases"`
+}
+
+var (
+	// communityAliases is the community-wide aliases kept by owner handler
+	communityAliases     map[string][]string
+	communityAliasesLock sync.RWMutex
+	// repoAliases is the aliases files in the root of repositories, keyed by owner/repo
+	repoAliases     = make(map[string]map[string][]string)
+	repoAliasesLock sync.RWMutex
+	// sigOwners is the maintainers in OWNERS of sigs kept by owner handler, the aliases in them are not expanded
+	sigOwners     map[string][]string
+	sigOwnersLock sync.RWMutex
+	// ownerAliasesChanged notifies owner handler to re-sync privileges
+	ownerAliasesChanged = make(chan struct{}, 1)
+)
+
 // CheckIsOwner checks the author is owner in repository
 func (s *Server) CheckIsOwner(event *gitee.NoteEvent, author string) bool {
 	isOwner := false
@@ -56,10 +81,29 @@ func (s *Server) GetOwners(event *gitee.NoteEvent) []string {
 		return nil
 	}
 
-	return DecodeOwners(contents.Content)
+	return DecodeOwners(contents.Content, s.GetOwnerAliases(owner, repo))
 }
 
-func DecodeOwners(content string) []string {
+// GetOwnerAliases gets the aliases of repository merged with the community-wide aliases,
+// the aliases defined in repository take precedence. The aliases of repository are fetched
+// once and refreshed by push events.
+func (s *Server) GetOwnerAliases(owner, repo string) map[string][]string {
+	fullName := owner + "/" + repo
+	aliases, ok := getRepoAliases(fullName)
+	if !ok {
+		var err error
+		aliases, err = fetchRepoAliases(s.Context, s.GiteeClient, s.Config.GiteeToken, owner, repo)
+		if err != nil {
+			glog.Errorf("unable to get owner aliases of %s: %v", fullName, err)
+			return getCommunityAliases()
+		}
+		setRepoAliases(fullName, aliases)
+	}
+	return MergeOwnerAliases(getCommunityAliases(), aliases)
+}
+
+// DecodeOwners decodes owners file and expands the aliases in it
+func DecodeOwners(content string, aliases map[string][]string) []string {
 	// base64 decode
 	decodeBytes, err := base64.StdEncoding.DecodeString(content)
 	if err != nil {
@@ -75,9 +119,127 @@ func DecodeOwners(content string) []string {
 	}
 
 	// return owners
-	if len(owners.Maintainers) > 0 {
-		return owners.Maintainers
+	maintainers := ExpandOwnerAliases(owners.Maintainers, aliases)
+	if len(maintainers) > 0 {
+		return maintainers
 	}
 
 	return nil
 }
+
+// DecodeOwnerAliases decodes owners aliases file
+func DecodeOwnerAliases(content string) map[string][]string {
+	// base64 decode
+	decodeBytes, err := base64.StdEncoding.DecodeString(content)
+	if err != nil {
+		glog.Errorf("decode content with error: %v", err)
+		return nil
+	}
+	// unmarshal aliases file
+	var aliases OwnersAliasesFile
+	err = yaml.Unmarshal(decodeBytes, &aliases)
+	if err != nil {
+		glog.Errorf("fail to unmarshal owners aliases: %v", err)
+		return nil
+	}
+	return aliases.Aliases
+}
+
+// MergeOwnerAliases merges the aliases, the latter ones override the former ones
+func MergeOwnerAliases(aliasesList ...map[string][]string) map[string][]string {
+	merged := make(map[string][]string)
+	for _, aliases := range aliasesList {
+		for name, logins := range aliases {
+			merged[name] = logins
+		}
+	}
+	return merged
+}
+
+// ExpandOwnerAliases replaces the aliases in logins with their members
+// and removes the duplicated logins
+func ExpandOwnerAliases(logins []string, aliases map[string][]string) []string {
+	expanded := make([]string, 0, len(logins))
+	existing := make(map[string]bool)
+	for _, l := range logins {
+		l = strings.TrimSpace(l)
+		members, ok := aliases[l]
+		if !ok {
+			members = []string{l}
+		}
+		for _, m := range members {
+			m = strings.TrimSpace(m)
+			if m == "" || existing[m] {
+				continue
+			}
+			existing[m] = true
+			expanded = append(expanded, m)
+		}
+	}
+	return expanded
+}
+
+func getCommunityAliases() map[string][]string {
+	communityAliasesLock.RLock()
+	defer communityAliasesLock.RUnlock()
+	return communityAliases
+}
+
+func setCommunityAliases(aliases map[string][]string) {
+	communityAliasesLock.Lock()
+	defer communityAliasesLock.Unlock()
+	communityAliases = aliases
+}
+
+// getSigOwners gets the maintainers of sig, and whether the OWNERS of sigs have been loaded
+func getSigOwners(sig string) ([]string, bool) {
+	sigOwnersLock.RLock()
+	defer sigOwnersLock.RUnlock()
+	return sigOwners[sig], sigOwners != nil
+}
+
+func setSigOwners(owners map[string][]string) {
+	sigOwnersLock.Lock()
+	defer sigOwnersLock.Unlock()
+	sigOwners = owners
+}
+
+// getRepoAliases gets the cached aliases of repository, and whether they have been loaded
+func getRepoAliases(fullName string) (map[string][]string, bool) {
+	repoAliasesLock.RLock()
+	defer repoAliasesLock.RUnlock()
+	aliases, ok := repoAliases[fullName]
+	return aliases, ok
+}
+
+func setRepoAliases(fullName string, aliases map[string][]string) {
+	repoAliasesLock.Lock()
+	defer repoAliasesLock.Unlock()
+	repoAliases[fullName] = aliases
+}
+
+// fetchRepoAliases gets the aliases file in the root of default branch of repository, nil if there is no such file
+func fetchRepoAliases(ctx context.Context, client *gitee.APIClient, token, owner, repo string) (map[string][]string, error) {
+	localVarOptionals := &gitee.GetV5ReposOwnerRepoContentsPathOpts{}
+	localVarOptionals.AccessToken = optional.NewString(token)
+	contents, response, err := client.RepositoriesApi.GetV5ReposOwnerRepoContentsPath(
+		ctx, owner, repo, DefaultOwnerAliasesFileName, localVarOptionals)
+	if err != nil {
+		if response != nil && response.StatusCode == http.StatusNotFound {
+			return nil, nil
+		}
+		return nil, err
+	}
+	if contents.Content == "" {
+		return nil, nil
+	}
+	return DecodeOwnerAliases(contents.Content), nil
+}
+
+// notifyOwnerAliasesChanged asks owner handler to re-sync privileges without blocking
+func notifyOwnerAliasesChanged() {
+	select {
+	case ownerAliasesChanged <- struct{}{}:
+	default:
+	}
+}
@@ -0,0 +1,53 @@
+package cibot
+
+import (
+	"reflect"
+	"testing"
+)
+
+func Test_ExpandOwnerAliases(t *testing.T) {
+	type args struct {
+		logins  []string
+		aliases map[string][]string
+	}
+	tests := []struct {
+		name string
+		args args
+		want []string
+	}{
+		{
+			name: "test logins without aliases",
+			args: args{
+				logins:  []string{"aaa", "bbb"},
+				aliases: nil,
+			},
+			want: []string{"aaa", "bbb"},
+		},
+		{
+			name: "test aliases are expanded and deduplicated",
+			args: args{
+				logins: []string{"kernel-maintainers", "bbb", "ccc"},
+				aliases: map[string][]string{
+					"kernel-maintainers": {"aaa", "bbb"},
+				},
+			},
+			want: []string{"aaa", "bbb", "ccc"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ExpandOwnerAliases(tt.args.logins, tt.args.aliases); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ExpandOwnerAliases() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_MergeOwnerAliases(t *testing.T) {
+	community := map[string][]string{"reviewers": {"aaa"}, "approvers": {"bbb"}}
+	repo := map[string][]string{"reviewers": {"ccc"}}
+	want := map[string][]string{"reviewers": {"ccc"}, "approvers": {"bbb"}}
+	if got := MergeOwnerAliases(community, repo); !reflect.DeepEqual(got, want) {
+		t.Errorf("MergeOwnerAliases() = %v, want %v", got, want)
+	}
+}
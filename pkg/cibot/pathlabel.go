@@ -0,0 +1,226 @@
+package cibot
+
+import (
+	"path"
+	"sort"
+	"strings"
+
+	"gitee.com/openeuler/ci-bot/pkg/cibot/config"
+	"gitee.com/openeuler/ci-bot/pkg/cibot/database"
+	"gitee.com/openeuler/go-gitee/gitee"
+	"github.com/antihax/optional"
+	"github.com/golang/glog"
+)
+
+// pathLabelRulesOf gets the rules applied to the repository, the rules without repos are applied to all
+func pathLabelRulesOf(rules []config.PathLabelRule, owner, repo string) []config.PathLabelRule {
+	var applied []config.PathLabelRule
+	for _, r := range rules {
+		if len(r.Repos) == 0 {
+			applied = append(applied, r)
+			continue
+		}
+		for _, pattern := range r.Repos {
+			if matched, _ := path.Match(pattern, owner+"/"+repo); matched {
+				applied = append(applied, r)
+				break
+			}
+		}
+	}
+	return applied
+}
+
+// expandLabel replaces the placeholders in label with the captured directories
+func expandLabel(label string, captures map[string]string) string {
+	for placeholder, value := range captures {
+		label = strings.Replace(label, placeholder, value, -1)
+	}
+	return label
+}
+
+// pathLabelsOf gets the labels of rules matching the changed files
+func pathLabelsOf(rules []config.PathLabelRule, files []gitee.PullRequestFiles) []string {
+	labels := make(map[string]bool)
+	for _, r := range rules {
+		for _, f := range files {
+			for _, p := range r.Paths {
+				if captures, matched := matchPathCaptures(p, f.Filename); matched {
+					labels[expandLabel(r.Label, captures)] = true
+				}
+			}
+		}
+	}
+	result := make([]string, 0, len(labels))
+	for l := range labels {
+		result = append(result, l)
+	}
+	sort.Strings(result)
+	return result
+}
+
+// pathLabelDiffer gets the labels of matching rules to add, and the labels added by rules before
+// which no longer match to remove, the labels added by others are kept
+func pathLabelDiffer(cfg config.PathLabels, owner, repo string, files []gitee.PullRequestFiles,
+	labels []gitee.Label, added map[string]bool) ([]string, map[string]string) {
+	rules := pathLabelRulesOf(cfg.Rules, owner, repo)
+	matched := pathLabelsOf(rules, files)
+	existing := make(map[string]bool)
+	for _, l := range labels {
+		existing[l.Name] = true
+	}
+	var add []string
+	keep := make(map[string]bool)
+	for _, l := range matched {
+		keep[l] = true
+		if !existing[l] {
+			add = append(add, l)
+		}
+	}
+	remove := make(map[string]string)
+	if !cfg.RemoveUnmatched {
+		return add, remove
+	}
+	for _, l := range labels {
+		if !keep[l.Name] && added[l.Name] {
+			remove[l.Name] = l.Name
+		}
+	}
+	return add, remove
+}
+
+// pathLabelsAdded gets the labels added by path label rules in pull request
+func pathLabelsAdded(owner, repo string, number int32) (map[string]bool, error) {
+	var ppls []database.PrPathLabels
+	err := database.DBConnection.Where("owner = ? and repo = ? and number = ?", owner, repo, number).Find(&ppls).Error
+	if err != nil {
+		return nil, err
+	}
+	added := make(map[string]bool, len(ppls))
+	for _, p := range ppls {
+		added[p.Label] = true
+	}
+	return added, nil
+}
+
+// recordPathLabels records the labels added by path label rules, and forgets the ones no longer in pull request
+func recordPathLabels(owner, repo string, number int32, added map[string]bool, labels []string) error {
+	for _, l := range labels {
+		if added[l] {
+			continue
+		}
+		err := database.DBConnection.Create(&database.PrPathLabels{
+			Owner:  owner,
+			Repo:   repo,
+			Number: int(number),
+			Label:  l,
+		}).Error
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// forgetPathLabels removes the records of labels which are removed from pull request
+func forgetPathLabels(owner, repo string, number int32, labels []string) error {
+	if len(labels) == 0 {
+		return nil
+	}
+	return database.DBConnection.Unscoped().
+		Where("owner = ? and repo = ? and number = ? and label in (?)", owner, repo, number, labels).
+		Delete(&database.PrPathLabels{}).Error
+}
+
+// pullRequestFiles gets the changed files of pull request
+func (s *Server) pullRequestFiles(event *gitee.PullRequestEvent) ([]gitee.PullRequestFiles, error) {
+	lvos := &gitee.GetV5ReposOwnerRepoPullsNumberFilesOpts{}
+	lvos.AccessToken = optional.NewString(s.Config.GiteeToken)
+	files, _, err := s.GiteeClient.PullRequestsApi.GetV5ReposOwnerRepoPullsNumberFiles(s.Context,
+		event.Repository.Namespace, event.Repository.Path, event.PullRequest.Number, lvos)
+	return files, err
+}
+
+// LabelPullRequestByFiles labels the pull request by the size and paths of its changed files,
+// and removes the size label and path labels which are stale
+func (s *Server) LabelPullRequestByFiles(event *gitee.PullRequestEvent, files []gitee.PullRequestFiles) error {
+	if !s.Config.PrSize.Enable && !s.Config.PathLabels.Enable {
+		return nil
+	}
+	owner := event.Repository.Namespace
+	repo := event.Repository.Path
+	number := event.PullRequest.Number
+
+	// the labels in payload may be removed by the update
+	lvos := &gitee.GetV5ReposOwnerRepoPullsNumberOpts{}
+	lvos.AccessToken = optional.NewString(s.Config.GiteeToken)
+	pr, _, err := s.GiteeClient.PullRequestsApi.GetV5ReposOwnerRepoPullsNumber(s.Context, owner, repo, number, lvos)
+	if err != nil {
+		return err
+	}
+
+	var add, pathLabels []string
+	remove := make(map[string]string)
+	added := make(map[string]bool)
+	if s.Config.PrSize.Enable {
+		lines := changedLinesOf(files, s.Config.PrSize.IgnoredFiles)
+		size := sizeOf(lines, s.Config.PrSize.Thresholds)
+		glog.Infof("pull request %s/%s/%d changes %d lines, size %s", owner, repo, number, lines, size)
+		sizeLabel, stale := sizeLabelDiffer(size, pr.Labels)
+		if sizeLabel != "" {
+			add = append(add, sizeLabel)
+		}
+		for k, v := range stale {
+			remove[k] = v
+		}
+	}
+	if s.Config.PathLabels.Enable {
+		added, err = pathLabelsAdded(owner, repo, number)
+		if err != nil {
+			return err
+		}
+		// the labels added by rules but removed by others are not removed again
+		existing := make(map[string]bool, len(pr.Labels))
+		for _, l := range pr.Labels {
+			existing[l.Name] = true
+		}
+		var gone []string
+		for l := range added {
+			if !existing[l] {
+				gone = append(gone, l)
+				delete(added, l)
+			}
+		}
+		var stale map[string]string
+		pathLabels, stale = pathLabelDiffer(s.Config.PathLabels, owner, repo, files, pr.Labels, added)
+		glog.Infof("pull request %s/%s/%d adds path labels %v, removes %v", owner, repo, number, pathLabels, stale)
+		add = append(add, pathLabels...)
+		for k, v := range stale {
+			remove[k] = v
+			gone = append(gone, k)
+		}
+		defer func() {
+			if err := forgetPathLabels(owner, repo, number, gone); err != nil {
+				glog.Errorf("unable to forget path labels: %v", err)
+			}
+		}()
+	}
+
+	noteEvent := &gitee.NoteEvent{}
+	noteEvent.Repository = event.Repository
+	noteEvent.PullRequest = event.PullRequest
+	noteEvent.Comment = &gitee.NoteHook{}
+	if len(remove) > 0 {
+		err = s.RemoveSpecifyLabelsInPulRequest(noteEvent, remove)
+		if err != nil {
+			return err
+		}
+	}
+	if len(add) == 0 {
+		return nil
+	}
+	err = s.AddSpecifyLabelsInPulRequest(noteEvent, add, true)
+	if err != nil {
+		return err
+	}
+	return recordPathLabels(owner, repo, number, added, pathLabels)
+}
@@ -0,0 +1,50 @@
+package cibot
+
+import (
+	"reflect"
+	"testing"
+
+	"gitee.com/openeuler/ci-bot/pkg/cibot/config"
+	"gitee.com/openeuler/go-gitee/gitee"
+)
+
+func TestPathLabelDiffer(t *testing.T) {
+	cfg := config.PathLabels{
+		Enable: true,
+		Rules: []config.PathLabelRule{
+			{Paths: []string{"*.spec"}, Label: "kind/packaging"},
+			{Paths: []string{"docs/**"}, Label: "kind/docs"},
+			{Repos: []string{"openeuler/community"}, Paths: []string{"sig/<name>/**"}, Label: "sig/<name>"},
+		},
+	}
+	files := []gitee.PullRequestFiles{
+		{Filename: "kernel.spec"},
+		{Filename: "sig/Kernel/OWNERS"},
+		{Filename: "sig/Compiler/sig-info.yaml"},
+	}
+	labels := []gitee.Label{{Name: "kind/docs"}, {Name: "sig/Compiler"}, {Name: "sig/Desktop"}, {Name: "sig/Network"}, {Name: "kind/bug"}}
+	// sig/Network is added by others
+	added := map[string]bool{"kind/docs": true, "sig/Compiler": true, "sig/Desktop": true}
+
+	tests := []struct {
+		name            string
+		repo            string
+		removeUnmatched bool
+		add             []string
+		remove          map[string]string
+	}{
+		{"community", "community", false, []string{"kind/packaging", "sig/Kernel"}, map[string]string{}},
+		{"other repository", "kernel", false, []string{"kind/packaging"}, map[string]string{}},
+		{"remove unmatched", "community", true, []string{"kind/packaging", "sig/Kernel"},
+			map[string]string{"kind/docs": "kind/docs", "sig/Desktop": "sig/Desktop"}},
+		{"remove unmatched of other repository", "kernel", true, []string{"kind/packaging"},
+			map[string]string{"kind/docs": "kind/docs", "sig/Compiler": "sig/Compiler", "sig/Desktop": "sig/Desktop"}},
+	}
+	for _, tt := range tests {
+		cfg.RemoveUnmatched = tt.removeUnmatched
+		add, remove := pathLabelDiffer(cfg, "openeuler", tt.repo, files, labels, added)
+		if !reflect.DeepEqual(add, tt.add) || !reflect.DeepEqual(remove, tt.remove) {
+			t.Errorf("%s: pathLabelDiffer = %v, %v, want %v, %v", tt.name, add, remove, tt.add, tt.remove)
+		}
+	}
+}
@@ -0,0 +1,139 @@
+package cibot
+
+import (
+	"path"
+	"strconv"
+	"strings"
+
+	"gitee.com/openeuler/ci-bot/pkg/cibot/config"
+	"gitee.com/openeuler/go-gitee/gitee"
+)
+
+const (
+	// the pull request is labelled with its size, e.g. size/M
+	sizeLabelPrefix = "size/"
+)
+
+// defaultSizeThresholds are the min changed lines of size S, M, L, XL and XXL
+var defaultSizeThresholds = config.PrSizeThresholds{S: 10, M: 30, L: 100, XL: 500, XXL: 1000}
+
+// matchPath checks whether the file matches the glob, in which ** matches any directories,
+// a glob without / matches the base name, and a glob ending with / matches the files in directory
+func matchPath(pattern, file string) bool {
+	_, matched := matchPathCaptures(pattern, file)
+	return matched
+}
+
+// matchPathCaptures matches the file like matchPath, and captures the directories
+// matched by the placeholders such as <name> in glob
+func matchPathCaptures(pattern, file string) (map[string]string, bool) {
+	pattern = strings.TrimPrefix(pattern, "/")
+	if strings.HasSuffix(pattern, "/") {
+		pattern += "**"
+	}
+	if !strings.Contains(pattern, "/") {
+		matched, _ := path.Match(pattern, path.Base(file))
+		return nil, matched
+	}
+	captures := make(map[string]string)
+	if !matchSegments(strings.Split(pattern, "/"), strings.Split(file, "/"), captures) {
+		return nil, false
+	}
+	return captures, true
+}
+
+// isPlaceholder checks whether the segment of glob is a placeholder such as <name>
+func isPlaceholder(segment string) bool {
+	return len(segment) > 2 && strings.HasPrefix(segment, "<") && strings.HasSuffix(segment, ">")
+}
+
+func matchSegments(patterns, segments []string, captures map[string]string) bool {
+	if len(patterns) == 0 {
+		return len(segments) == 0
+	}
+	if patterns[0] == "**" {
+		// the trailing ** matches the files in directory, not the directory itself
+		if len(patterns) == 1 {
+			return len(segments) > 0
+		}
+		for i := 0; i <= len(segments); i++ {
+			if matchSegments(patterns[1:], segments[i:], captures) {
+				return true
+			}
+		}
+		return false
+	}
+	if len(segments) == 0 {
+		return false
+	}
+	if isPlaceholder(patterns[0]) {
+		captures[patterns[0]] = segments[0]
+	} else if matched, _ := path.Match(patterns[0], segments[0]); !matched {
+		return false
+	}
+	return matchSegments(patterns[1:], segments[1:], captures)
+}
+
+// matchAnyPath checks whether the file matches one of globs
+func matchAnyPath(patterns []string, file string) bool {
+	for _, p := range patterns {
+		if matchPath(p, file) {
+			return true
+		}
+	}
+	return false
+}
+
+// changedLinesOf gets the number of lines added and deleted in files except the ignored ones
+func changedLinesOf(files []gitee.PullRequestFiles, ignored []string) int {
+	lines := 0
+	for _, f := range files {
+		if matchAnyPath(ignored, f.Filename) {
+			continue
+		}
+		additions, _ := strconv.Atoi(f.Additions)
+		deletions, _ := strconv.Atoi(f.Deletions)
+		lines += additions + deletions
+	}
+	return lines
+}
+
+// sizeOf gets the size of changed lines, the thresholds not set are default
+func sizeOf(lines int, t config.PrSizeThresholds) string {
+	sizes := []struct {
+		name      string
+		threshold int
+		def       int
+	}{
+		{"XXL", t.XXL, defaultSizeThresholds.XXL},
+		{"XL", t.XL, defaultSizeThresholds.XL},
+		{"L", t.L, defaultSizeThresholds.L},
+		{"M", t.M, defaultSizeThresholds.M},
+		{"S", t.S, defaultSizeThresholds.S},
+	}
+	for _, s := range sizes {
+		threshold := s.threshold
+		if threshold <= 0 {
+			threshold = s.def
+		}
+		if lines >= threshold {
+			return s.name
+		}
+	}
+	return "XS"
+}
+
+// sizeLabelDiffer gets the size label to add if it is not there, and the stale size labels to remove
+func sizeLabelDiffer(size string, labels []gitee.Label) (string, map[string]string) {
+	label := sizeLabelPrefix + size
+	add := label
+	remove := make(map[string]string)
+	for _, l := range labels {
+		if l.Name == label {
+			add = ""
+		} else if strings.HasPrefix(l.Name, sizeLabelPrefix) {
+			remove[l.Name] = l.Name
+		}
+	}
+	return add, remove
+}
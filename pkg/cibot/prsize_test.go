@@ -0,0 +1,68 @@
+package cibot
+
+import (
+	"reflect"
+	"testing"
+
+	"gitee.com/openeuler/ci-bot/pkg/cibot/config"
+	"gitee.com/openeuler/go-gitee/gitee"
+)
+
+func TestMatchPath(t *testing.T) {
+	tests := []struct {
+		pattern string
+		file    string
+		want    bool
+	}{
+		{"vendor/", "vendor/a/b.go", true},
+		{"vendor/", "pkg/vendor/b.go", false},
+		{"*.tar.gz", "src/pkg-1.0.tar.gz", true},
+		{"**/*.pb.go", "api.pb.go", true},
+		{"**/*.pb.go", "pkg/api/api.pb.go", true},
+		{"sig/*/**", "sig/kernel/OWNERS", true},
+		{"sig/*/**", "sig/OWNERS", false},
+		{"docs/*.md", "docs/a/b.md", false},
+	}
+	for _, tt := range tests {
+		if got := matchPath(tt.pattern, tt.file); got != tt.want {
+			t.Errorf("matchPath(%q, %q) = %v, want %v", tt.pattern, tt.file, got, tt.want)
+		}
+	}
+}
+
+func TestSizeOf(t *testing.T) {
+	files := []gitee.PullRequestFiles{
+		{Filename: "main.go", Additions: "20", Deletions: "5"},
+		{Filename: "vendor/lib/lib.go", Additions: "3000"},
+	}
+	lines := changedLinesOf(files, []string{"vendor/"})
+	if lines != 25 {
+		t.Fatalf("changedLinesOf = %d, want 25", lines)
+	}
+	tests := []struct {
+		lines      int
+		thresholds config.PrSizeThresholds
+		want       string
+	}{
+		{0, config.PrSizeThresholds{}, "XS"},
+		{lines, config.PrSizeThresholds{}, "S"},
+		{lines, config.PrSizeThresholds{M: 20}, "M"},
+		{1000, config.PrSizeThresholds{}, "XXL"},
+	}
+	for _, tt := range tests {
+		if got := sizeOf(tt.lines, tt.thresholds); got != tt.want {
+			t.Errorf("sizeOf(%d, %+v) = %s, want %s", tt.lines, tt.thresholds, got, tt.want)
+		}
+	}
+}
+
+func TestSizeLabelDiffer(t *testing.T) {
+	add, remove := sizeLabelDiffer("M", []gitee.Label{{Name: "size/S"}, {Name: "kind/bug"}})
+	if add != "size/M" || !reflect.DeepEqual(remove, map[string]string{"size/S": "size/S"}) {
+		t.Errorf("sizeLabelDiffer = %q, %v", add, remove)
+	}
+	add, remove = sizeLabelDiffer("M", []gitee.Label{{Name: "size/M"}})
+	if add != "" || len(remove) != 0 {
+		t.Errorf("sizeLabelDiffer unchanged = %q, %v", add, remove)
+	}
+}
@@ -2,6 +2,7 @@ package cibot
 
 import (
 	"encoding/base64"
+	"errors"
 	"fmt"
 	"io/ioutil"
 	"strings"
@@ -12,13 +13,6 @@ import (
 	"github.com/golang/glog"
 )
 
-const (
-	cannotMergeMessage = `This pull request can not be merged, you can try it again when label requirement meets. :astonished:
-%s`
-	nonRequiringLabelsMessage = ` Labels [**%s**] need to be added.`
-	nonMissingLabelsMessage   = ` Labels [**%s**] need to be removed.`
-)
-
 // HandlePullRequestEvent handles pull request event
 func (s *Server) HandlePullRequestEvent(actionDesc string, event *gitee.PullRequestEvent) {
 	if event == nil {
@@ -31,6 +25,12 @@ func (s *Server) HandlePullRequestEvent(actionDesc string, event *gitee.PullRequ
 	if err := s.ValidateCommits(event); err != nil {
 		glog.Error("failed to validate pr commits ", err)
 	}
+	// check the sign-off and messages of new commits
+	if *event.Action == "open" || (*event.Action == "update" && actionDesc == s.Config.PrUpdateLabelFlag) {
+		if err := s.LintCommits(event); err != nil {
+			glog.Errorf("failed to lint pr commits: %v", err)
+		}
+	}
 
 	// handle events
 	switch *event.Action {
@@ -67,23 +67,18 @@ func (s *Server) HandlePullRequestEvent(actionDesc string, event *gitee.PullRequ
 		var committors []string
 		if len(ps) > 0 {
 			for _, p := range ps {
-				committors = append(committors, fmt.Sprintf("***@%s***", p.User))
+				committors = append(committors, p.User)
 				if limitNotice && (len(committors) >= s.Config.LimitMemberCnt) {
 					break
 				}
 			}
 		}
-		committor_list := strings.Join(committors, ", ")
-		if len(committor_list) > 0 {
-			sigPath := fmt.Sprintf(SigPath, sigName)
-			proInfo := fmt.Sprintf(DisplayCommittors, sigName, sigPath)
-			committor_list = proInfo + committor_list + "."
-		}
 
 		// add comment
 		body := gitee.PullRequestCommentPostParam{}
 		body.AccessToken = s.Config.GiteeToken
-		body.Body = fmt.Sprintf(tipBotMessage, event.Sender.Login, s.Config.CommunityName, s.Config.CommandLink, committor_list)
+		body.Body = s.message(event.Repository.Namespace, event.Repository.Path, tipBotMessage, MessageData{
+			"Author": event.Sender.Login, "Sig": sigName, "Committors": committors})
 		owner := event.Repository.Namespace
 		repo := event.Repository.Path
 		number := event.PullRequest.Number
@@ -102,6 +97,18 @@ func (s *Server) HandlePullRequestEvent(actionDesc string, event *gitee.PullRequ
 			}
 		}
 
+		// label with the state of target branch, and close the pull request to eol branch
+		err = s.HandleBranchStateByPullRequestOpen(event)
+		if err != nil {
+			glog.Errorf("unable to handle branch state: %v", err)
+		}
+
+		// record the fingerprint to detect the clean rebase later
+		_, _, err = s.refreshFingerprint(owner, repo, number, event.PullRequest.Head.Sha)
+		if err != nil {
+			glog.Errorf("unable to record pr fingerprint: %v", err)
+		}
+
 		if s.Config.AutoDetectCla {
 			err = s.CheckCLAByPullRequestEvent(event)
 			if err != nil {
@@ -109,7 +116,21 @@ func (s *Server) HandlePullRequestEvent(actionDesc string, event *gitee.PullRequ
 			}
 		}
 
-		diff := s.CheckSpecialFileHasModified(event, s.Config.AccordingFile)
+		// the changed files are listed once for the labels and the special file
+		if !s.Config.PrSize.Enable && !s.Config.PathLabels.Enable && s.Config.AccordingFile == "" {
+			return
+		}
+		files, err := s.pullRequestFiles(event)
+		if err != nil {
+			glog.Errorf("unable to get pr file list. err: %v", err)
+			return
+		}
+		err = s.LabelPullRequestByFiles(event, files)
+		if err != nil {
+			glog.Errorf("failed to label pull request by files: %v", err)
+		}
+
+		diff := specialFileDiff(files, s.Config.AccordingFile)
 		if diff == "" {
 			return
 		}
@@ -153,12 +174,29 @@ func (s *Server) HandlePullRequestEvent(actionDesc string, event *gitee.PullRequ
 		}
 		listofPrLabels := pr.Labels
 		glog.Infof("List of pr labels: %v", listofPrLabels)
-		// remove labels if action_desc is "source_branch_changed"
-		if len(pr.Labels) == 0 || actionDesc != s.Config.PrUpdateLabelFlag {
+		if actionDesc != s.Config.PrUpdateLabelFlag {
 			return
 		}
-		delLabels, updateLabels := GetChangeLabels(s.Config.DelLabels, pr.Labels)
-		if len(delLabels) == 0 {
+		// keep the review if the diff is not changed by a rebase
+		cleanRebase := s.isCleanRebase(event)
+		if cleanRebase {
+			err = carryOverVotes(owner, repo, number, event.PullRequest.Head.Sha)
+			if err != nil {
+				cleanRebase = false
+			}
+		}
+		// remove labels if action_desc is "source_branch_changed"
+		if len(pr.Labels) > 0 {
+			delLabels, updateLabels := GetChangeLabels(s.Config.DelLabels, pr.Labels)
+			if cleanRebase {
+				delLabels = withoutReviewLabels(delLabels)
+			}
+			if len(delLabels) > 0 {
+				err = s.UpdateLabelsBySourceBranchChange(delLabels, updateLabels, event)
+				if err != nil {
+					glog.Info(err)
+				}
+			}
 			// Add retest comment for update push.
 			retest_comment := "/retest"
 			cBody := gitee.PullRequestCommentPostParam{}
@@ -168,29 +206,37 @@ func (s *Server) HandlePullRequestEvent(actionDesc string, event *gitee.PullRequ
 			if err != nil{
 				glog.Info("Add retest comment failed. err: %v", err)
 			}
-			return
 		}
-		err = s.UpdateLabelsBySourceBranchChange(delLabels, updateLabels, event)
+		if cleanRebase {
+			s.commentCleanRebase(event, pr.Labels)
+		}
+		// the author may have resolved the conflicts
+		s.CheckNeedsRebaseLater(owner, repo, number)
+		// the freeze exception is not for the new commits
+		err = s.dropFreezeException(owner, repo, pr)
 		if err != nil {
-			glog.Info(err)
+			glog.Errorf("unable to drop freeze exception. err: %v", err)
 		}
-		// Add retest comment for update push.
-		retest_comment := "/retest"
-		cBody := gitee.PullRequestCommentPostParam{}
-		cBody.AccessToken = s.Config.GiteeToken
-		cBody.Body = fmt.Sprintf(retest_comment)
-		_, _, err = s.GiteeClient.PullRequestsApi.PostV5ReposOwnerRepoPullsNumberComments(s.Context, owner, repo, number, cBody)
-		if err != nil{
-			glog.Info("Add retest comment failed. err: %v", err)
+		// the new commits may come from authors without cla
+		err = s.CheckCLAByPullRequestUpdate(event, pr.Labels)
+		if err != nil {
+			glog.Errorf("failed to check cla by pull request event: %v", err)
 		}
 		// remove lgtm if changes happen
-		/*if s.hasLgtmLabel(pr.Labels) {
-			err = s.CheckLgtmByPullRequestUpdate(event)
+		err = s.CheckLgtmByPullRequestUpdate(event)
+		if err != nil {
+			glog.Errorf("check lgtm by pull request update. err: %v", err)
+		}
+		// the size and paths change with the new commits
+		if s.Config.PrSize.Enable || s.Config.PathLabels.Enable {
+			files, err := s.pullRequestFiles(event)
+			if err == nil {
+				err = s.LabelPullRequestByFiles(event, files)
+			}
 			if err != nil {
-				glog.Errorf("check lgtm by pull request update. err: %v", err)
-				return
+				glog.Errorf("failed to label pull request by files: %v", err)
 			}
-		}*/
+		}
 	case "merge":
 		glog.Info("Received a pull request merge event")
 
@@ -245,10 +291,9 @@ func (s *Server) UpdateLabelsBySourceBranchChange(delLabels, updateLabels []stri
 		}
 	}
 	// add comment for update labels
-	commentContent := `Changes detected. ***%s*** was removed from this pull request by: ***%s***. :flushed: `
 	cBody := gitee.PullRequestCommentPostParam{}
 	cBody.AccessToken = s.Config.GiteeToken
-	cBody.Body = fmt.Sprintf(commentContent, strDelLabel, s.Config.BotName)
+	cBody.Body = s.message(owner, repo, labelsRemovedByChangeMessage, MessageData{"Labels": delLabels})
 	_, _, err := s.GiteeClient.PullRequestsApi.PostV5ReposOwnerRepoPullsNumberComments(s.Context, owner, repo, prNumber, cBody)
 	if err != nil {
 		glog.Errorf("unable to add comment in pull request: %v", err)
@@ -267,7 +312,7 @@ func (s *Server) SendNote4AutomaticNewFile(event *gitee.PullRequestEvent) {
 	number := event.PullRequest.Number
 	body := gitee.PullRequestCommentPostParam{}
 	body.AccessToken = s.Config.GiteeToken
-	body.Body = AutoAddPrjMsg + s.Config.GuideURL
+	body.Body = s.message(owner, repo, autoAddProjectMessage, MessageData{"GuideURL": s.Config.GuideURL})
 	glog.Infof("Send notify info: %v.", body.Body)
 	_, _, err := s.GiteeClient.PullRequestsApi.PostV5ReposOwnerRepoPullsNumberComments(s.Context, owner, repo, number, body)
 	if err != nil {
@@ -299,17 +344,19 @@ func (s *Server) CheckSpecialFileHasModified(event *gitee.PullRequestEvent, spec
 	if len(specialfile) == 0 || event == nil {
 		return ""
 	}
-	diff = ""
 	// get pr commit file list, community repo
-	owner := event.Repository.Namespace
-	repo := event.Repository.Path
-	number := event.PullRequest.Number
-	lvos := &gitee.GetV5ReposOwnerRepoPullsNumberFilesOpts{}
-	lvos.AccessToken = optional.NewString(s.Config.GiteeToken)
-	fls, _, err := s.GiteeClient.PullRequestsApi.GetV5ReposOwnerRepoPullsNumberFiles(s.Context, owner, repo, number, lvos)
+	fls, err := s.pullRequestFiles(event)
 	if err != nil {
 		glog.Errorf("unable to get pr file list. err: %v", err)
-		return
+		return ""
+	}
+	return specialFileDiff(fls, specialfile)
+}
+
+// specialFileDiff gets the diff of special file in the changed files, empty if it is not modified
+func specialFileDiff(fls []gitee.PullRequestFiles, specialfile string) (diff string) {
+	if len(specialfile) == 0 {
+		return ""
 	}
 	// check special file has modified and get diff
 	for _, file := range fls {
@@ -475,41 +522,39 @@ func (s *Server) hasLgtmLabel(labels []gitee.Label) bool {
 	return false
 }
 
-func (s *Server) readyForMerge(labels []gitee.Label, owner, repo string) error {
-	aproveLabel := 0
-	lgtmLabel := 0
-	lgtmPrefix := ""
-	leastLgtm := 0
-	count := s.calculateLgtmLabel(owner, repo)
-	if count > 1 {
-		leastLgtm = count
-		lgtmPrefix = fmt.Sprintf(LabelLgtmWithCommenter, "")
-	} else {
-		leastLgtm = 1
-		lgtmPrefix = LabelNameLgtm
+// mergePullRequest merges the pull request with the description of reviewers
+func (s *Server) mergePullRequest(event *gitee.NoteEvent, pr gitee.PullRequest) error {
+	owner := event.Repository.Namespace
+	repo := event.Repository.Path
+	prNumber := pr.Number
+	// remove assignees
+	err := s.RemoveAssigneesInPullRequest(event)
+	if err != nil {
+		glog.Errorf("unable to remove assignees. err: %v", err)
 	}
-	for _, l := range labels {
-		if strings.HasPrefix(l.Name, lgtmPrefix) {
-			lgtmLabel++
-		} else if l.Name == LabelNameApproved {
-			aproveLabel++
-		}
+	// remove testers
+	err = s.RemoveTestersInPullRequest(event)
+	if err != nil {
+		glog.Errorf("unable to remove testers. err: %v", err)
 	}
-	glog.Infof("Pr labels have approved: %d lgtm: %d, required (%d)", aproveLabel, lgtmLabel, leastLgtm)
-	if aproveLabel == 1 && lgtmLabel >= leastLgtm {
-		return nil
-	} else {
-		return fmt.Errorf("This pull request can not be merged, please check that the number of **lgtm** labels >= %d "+
-			"and there are an **approve** labels. ", leastLgtm)
+	// merge pr
+	body := gitee.PullRequestMergePutParam{}
+	body.AccessToken = s.Config.GiteeToken
+	// generate merge body
+	description, err := s.generateMergeDescription(owner, repo, prNumber, pr.User.Login, pr.Head.Sha)
+	if err != nil {
+		glog.Errorf("unable to get merge description.err: %v", err)
+		return errors.New(s.message(owner, repo, mergeFailedMessage, nil))
 	}
-}
+	body.Description = description
+	body.MergeMethod = s.mergePolicy(owner, repo, pr.Base.Ref).MergeMethod
 
-// check with the labels constraints requiring/missing to determine if mergable
-func (s *Server) legalLabelsForMerge(labels []gitee.Label) ([]string, []string) {
-	nonRequiring, _ := s.labelDiffer(s.Config.RequiringLabels, labels)
-	_, nonMissing := s.labelDiffer(s.Config.MissingLabels, labels)
-
-	return nonRequiring, nonMissing
+	_, err = s.GiteeClient.PullRequestsApi.PutV5ReposOwnerRepoPullsNumberMerge(s.Context, owner, repo, prNumber, body)
+	if err != nil {
+		glog.Errorf("unable to merge pull request. err: %v", err)
+		return errors.New(s.message(owner, repo, mergeFailedMessage, nil))
+	}
+	return nil
 }
 
 // MergePullRequest with lgtm and approved label
@@ -529,69 +574,51 @@ func (s *Server) MergePullRequest(event *gitee.NoteEvent) error {
 	}
 	listofPrLabels := pr.Labels
 	glog.Infof("List of pr labels: %v", listofPrLabels)
-	// ready to merge
-	err = s.readyForMerge(listofPrLabels, owner, repo)
+	// ready to merge by the policy of target branch
+	policy := s.mergePolicy(owner, repo, pr.Base.Ref)
+	err = s.readyForMerge(policy, owner, repo, pr)
 	if err != nil {
 		return err
 	}
-	nonRequiringLabels, nonMissingLabels := s.legalLabelsForMerge(listofPrLabels)
-	if len(nonRequiringLabels) == 0 && len(nonMissingLabels) == 0 {
+	// the state of target branch restricts merging
+	if reason, ok := s.checkBranchStateForMerge(owner, repo, pr); !ok {
+		err = s.addCommentToPullRequest(owner, repo, reason, prNumber)
+		if err != nil {
+			glog.Errorf("unable to add comment in pull request: %v", err)
+		}
+		return nil
+	}
+	nonRequiringLabels, nonMissingLabels, nonOneOfLabels := s.legalLabelsForMerge(policy, listofPrLabels)
+	if len(nonRequiringLabels) == 0 && len(nonMissingLabels) == 0 && len(nonOneOfLabels) == 0 {
 		// current pr can be merged
-		if c, b := checkFrozenCanMerge(event.Author.Login, pr.Base.Ref, owner); !b {
+		// the approved freeze exception bypasses the freeze for current head sha
+		if c, b := checkFrozenCanMerge(event.Author.Login, pr.Base.Ref, owner); !b && !hasFreezeException(owner, repo, prNumber, pr.Head.Sha) {
 			//send comment to pr
-			comment := ""
-			if len(c) > 0 {
-				comment = fmt.Sprintf("**Merge failed** The current pull request merge target has been frozen, and only the branch owner( @%s ) can merge.",
-					strings.Join(c, " , @"))
-			} else {
-				comment = "**Merge failed** The current pull request merge target has been frozen, and only the branch owner can merge."
-			}
+			comment := s.message(owner, repo, frozenMergeFailedMessage, MessageData{"Owners": c})
 			err = s.addCommentToPullRequest(owner, repo, comment, prNumber)
 			if err != nil {
 				glog.Errorf("Cannot add comments to pull request: %v", err)
 			}
 		} else {
-			if event.PullRequest.Mergeable {
-				// remove assignees
-				err = s.RemoveAssigneesInPullRequest(event)
-				if err != nil {
-					glog.Errorf("unable to remove assignees. err: %v", err)
-				}
-				// remove testers
-				err = s.RemoveTestersInPullRequest(event)
-				if err != nil {
-					glog.Errorf("unable to remove testers. err: %v", err)
-				}
-				// merge pr
-				body := gitee.PullRequestMergePutParam{}
-				body.AccessToken = s.Config.GiteeToken
-				// generate merge body
-				description, err := s.generateMergeDescription(event)
-				if err != nil {
-					glog.Errorf("unable to get merge description.err: %v", err)
-					return fmt.Errorf(`The pull request merge failed, please use command "/check-pr" to try again. `)
-				}
-				body.Description = description
-
-				_, err = s.GiteeClient.PullRequestsApi.PutV5ReposOwnerRepoPullsNumberMerge(s.Context, owner, repo, prNumber, body)
+			// the merge queue merges pull requests serially, except for the frozen branch
+			// which only its owners can merge, the queue shows the position in its status comment
+			if _, isFrozen := IsBranchFrozen(pr.Base.Ref, owner); !isFrozen && s.mergeQueueEnabled(owner, repo) {
+				notifyMergeQueue()
+				return nil
+			}
+			// the mergeable in comment payload may be stale, use the fetched one
+			if pr.Mergeable {
+				err = s.mergePullRequest(event, pr)
 				if err != nil {
-					glog.Errorf("unable to merge pull request. err: %v", err)
-					return fmt.Errorf(`The pull request merge failed, please use command "/check-pr" to try again. `)
+					return err
 				}
 			}
 		}
 	} else {
 		// add comment to pr to show the labels reason of not mergable
-		nonRequiringMsg := ""
-		if len(nonRequiringLabels) > 0 {
-			nonRequiringMsg = fmt.Sprintf(nonRequiringLabelsMessage, strings.Join(nonRequiringLabels, ","))
-		}
-		nonMissingMsg := ""
-		if len(nonMissingLabels) > 0 {
-			nonMissingMsg = fmt.Sprintf(nonMissingLabelsMessage, strings.Join(nonMissingLabels, ","))
-		}
 		// add comment back to pr
-		comment := fmt.Sprintf(cannotMergeMessage, fmt.Sprintf("%s%s", nonRequiringMsg, nonMissingMsg))
+		comment := s.message(owner, repo, cannotMergeMessage, MessageData{"Policy": policy.Name,
+			"NonRequiringLabels": nonRequiringLabels, "NonMissingLabels": nonMissingLabels, "NonOneOfLabels": nonOneOfLabels})
 		owner := event.Repository.Namespace
 		repo := event.Repository.Path
 		number := event.PullRequest.Number
@@ -603,44 +630,13 @@ func (s *Server) MergePullRequest(event *gitee.NoteEvent) error {
 	return nil
 }
 
-func (s *Server) generateMergeDescription(event *gitee.NoteEvent) (string, error) {
-	// get basic params
-	owner := event.Repository.Namespace
-	repo := event.Repository.Path
-	prNumber := event.PullRequest.Number
-	commentCount := event.PullRequest.Comments
-	user := event.PullRequest.User.Login
-	var perPage int32 = 20
-	pageCount := commentCount / perPage
-	if commentCount%perPage > 0 {
-		pageCount++
-	}
-
-	result := ""
-	localVarOptionals := &gitee.GetV5ReposOwnerRepoPullsNumberCommentsOpts{}
-	localVarOptionals.AccessToken = optional.NewString(s.Config.GiteeToken)
-	localVarOptionals.PerPage = optional.NewInt32(perPage)
-
-	var signers = make([]string, 0)
-	var reviewers = make([]string, 0)
-	// range page and get comments
-	for page := pageCount; page > 0; page-- {
-		localVarOptionals.Page = optional.NewInt32(page)
-		comments, _, err :=
-			s.GiteeClient.PullRequestsApi.GetV5ReposOwnerRepoPullsNumberComments(s.Context, owner, repo, prNumber, localVarOptionals)
-		if err != nil {
-			glog.Errorf("unable to get pull request comments. err:%v", err)
-			return result, err
-		}
-
-		signers, reviewers, err = getSignersAndReviewers(user, comments)
-		if err != nil {
-			glog.Errorf("failed to get signers or reviewers. err:%v", err)
-		}
+func (s *Server) generateMergeDescription(owner, repo string, prNumber int32, user, sha string) (string, error) {
+	votes, err := getActiveVotes(owner, repo, prNumber, sha)
+	if err != nil {
+		return "", err
 	}
-
-	result = formatDescription(user, reviewers, signers)
-	return result, nil
+	signers, reviewers := getSignersAndReviewers(user, votes)
+	return formatDescription(user, reviewers, signers), nil
 }
 
 func formatDescription(user string, reviewers, signers []string) string {
@@ -649,29 +645,21 @@ func formatDescription(user string, reviewers, signers []string) string {
 		strings.Join(signers, ","))
 }
 
-func getSignersAndReviewers(user string, comments []gitee.PullRequestComments) ([]string, []string, error) {
+func getSignersAndReviewers(user string, votes []database.ReviewVotes) ([]string, []string) {
 	var signers = make([]string, 0)
 	var reviewers = make([]string, 0)
 
-	if len(comments) == 0 {
-		return signers, reviewers, fmt.Errorf("comment list is empty")
-	}
-
-	for _, comment := range comments {
-		m := RegAddLgtm.FindStringSubmatch(comment.Body)
-		if m != nil && comment.UpdatedAt == comment.CreatedAt && comment.User.Login != user {
-			reviewer := fmt.Sprintf("@%s", comment.User.Login)
-			reviewers = append(reviewers, reviewer)
+	for _, v := range votersOf(votes, VoteKindLgtm) {
+		if v != user && v != "" {
+			reviewers = append(reviewers, fmt.Sprintf("@%s", v))
 		}
-
-		m = RegAddApprove.FindStringSubmatch(comment.Body)
-		if m != nil && comment.UpdatedAt == comment.CreatedAt && comment.User.Login != user {
-			signer := fmt.Sprintf("@%s", comment.User.Login)
-			signers = append(signers, signer)
+	}
+	for _, v := range votersOf(votes, VoteKindApprove) {
+		if v != user && v != "" {
+			signers = append(signers, fmt.Sprintf("@%s", v))
 		}
 	}
-
-	return signers, reviewers, nil
+	return signers, reviewers
 }
 
 func checkFrozenCanMerge(commenter, branch string, community string) ([]string, bool) {
@@ -1,7 +1,10 @@
 package cibot
 
-import "testing"
+import (
+	"testing"
 
+	"gitee.com/openeuler/ci-bot/pkg/cibot/database"
+)
 
 func Test_formatDescription(t *testing.T) {
 	type args struct {
@@ -31,4 +34,18 @@ func Test_formatDescription(t *testing.T) {
 			}
 		})
 	}
-}
\ No newline at end of file
+}
+
+func Test_getSignersAndReviewers(t *testing.T) {
+	votes := []database.ReviewVotes{
+		{User: "aaa", Kind: VoteKindLgtm},
+		{User: "fakeuser", Kind: VoteKindLgtm},
+		{User: "bbb", Kind: VoteKindLgtm},
+		{User: "aaa", Kind: VoteKindLgtm},
+		{User: "ccc", Kind: VoteKindApprove},
+	}
+	signers, reviewers := getSignersAndReviewers("fakeuser", votes)
+	if got := formatDescription("fakeuser", reviewers, signers); got != "From: @fakeuser\nReviewed-by: @aaa,@bbb\nSigned-off-by: @ccc\n" {
+		t.Errorf("getSignersAndReviewers() = %v", got)
+	}
+}
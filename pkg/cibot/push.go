@@ -16,6 +16,8 @@ func (s *Server) HandlePushEvent(event *gitee.PushEvent) {
 	}
 	s.HandleWatchProjectFiles(event)
 	s.HandleWatchSigFiles(event)
+	s.HandleWatchOwnerAliasesFiles(event)
+	s.HandleNeedsRebaseByPush(event)
 }
 
 // HandleWatchProjectFiles
@@ -200,3 +202,69 @@ func (s *Server) HandleWatchSigFiles(event *gitee.PushEvent) {
 		}
 	}
 }
+
+// HandleWatchOwnerAliasesFiles
+func (s *Server) HandleWatchOwnerAliasesFiles(event *gitee.PushEvent) {
+	s.handleRepoAliasesFile(event)
+	for _, wf := range s.Config.WatchOwnerAliasesFiles {
+		if (event.Repository.Namespace != wf.WatchOwnerAliasesFileOwner) || (event.Repository.Path != wf.WatchOwnerAliasesFileRepo) {
+			continue
+		}
+		// owner and repo are matched
+		if event.Ref == nil {
+			continue
+		}
+		configRef := wf.WatchOwnerAliasesFileRef
+		if configRef == "" {
+			configRef = "master"
+		}
+		// refs/heads/master
+		if strings.Index(*event.Ref, configRef) < 0 {
+			continue
+		}
+		if pushTouchesFile(event, wf.WatchOwnerAliasesFilePath) {
+			glog.Infof("owner aliases file is changed. owner: %s repo: %s path: %s",
+				event.Repository.Namespace, event.Repository.Path, wf.WatchOwnerAliasesFilePath)
+			notifyOwnerAliasesChanged()
+			return
+		}
+	}
+}
+
+// handleRepoAliasesFile refreshes the aliases of repository when its aliases file on default branch is changed
+func (s *Server) handleRepoAliasesFile(event *gitee.PushEvent) {
+	if event.Repository == nil || event.Ref == nil {
+		return
+	}
+	defaultBranch := event.Repository.DefaultBranch
+	if defaultBranch == "" {
+		defaultBranch = "master"
+	}
+	if *event.Ref != "refs/heads/"+defaultBranch || !pushTouchesFile(event, DefaultOwnerAliasesFileName) {
+		return
+	}
+	owner := event.Repository.Namespace
+	repo := event.Repository.Path
+	aliases, err := fetchRepoAliases(s.Context, s.GiteeClient, s.Config.GiteeToken, owner, repo)
+	if err != nil {
+		glog.Errorf("unable to get owner aliases of %s/%s: %v", owner, repo, err)
+		return
+	}
+	glog.Infof("owner aliases file of %s/%s is changed", owner, repo)
+	setRepoAliases(owner+"/"+repo, aliases)
+	notifyOwnerAliasesChanged()
+}
+
+// pushTouchesFile checks whether the commits in push event add, modify or remove the file
+func pushTouchesFile(event *gitee.PushEvent, path string) bool {
+	for _, c := range event.Commits {
+		for _, files := range [][]string{c.Added, c.Modified, c.Removed} {
+			for _, f := range files {
+				if f == path {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
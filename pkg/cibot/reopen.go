@@ -1,7 +1,6 @@
 package cibot
 
 import (
-	"fmt"
 	"strings"
 
 	"github.com/antihax/optional"
@@ -10,10 +9,6 @@ import (
 	"github.com/golang/glog"
 )
 
-const (
-	reopenIssueMessage = `this issue is reopened by: ***%s***.`
-)
-
 // ReOpen reopens pr or issue
 func (s *Server) ReOpen(event *gitee.NoteEvent) error {
 	// handle PullRequest
@@ -120,7 +115,7 @@ func (s *Server) ReOpen(event *gitee.NoteEvent) error {
 				// add comment
 				bodyComment := gitee.IssueCommentPostParam{}
 				bodyComment.AccessToken = s.Config.GiteeToken
-				bodyComment.Body = fmt.Sprintf(reopenIssueMessage, commentAuthor)
+				bodyComment.Body = s.message(owner, repo, reopenIssueMessage, MessageData{"Commenter": commentAuthor})
 				_, _, err = s.GiteeClient.IssuesApi.PostV5ReposOwnerRepoIssuesNumberComments(s.Context, owner, repo, issueNumber, bodyComment)
 				if err != nil {
 					glog.Errorf("unable to add comment in issue: %v", err)
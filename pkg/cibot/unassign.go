@@ -1,19 +1,12 @@
 package cibot
 
 import (
-	"fmt"
 	"strings"
 
 	"gitee.com/openeuler/go-gitee/gitee"
 	"github.com/golang/glog"
 )
 
-const (
-	issueUnAssignMessage       = `***@%s*** is unassigned from this issue.`
-	issueCanNotUnAssignMessage = `***@%s*** can not be unassigned from this issue.
-please try to unassign the assignee from this issue.`
-)
-
 // UnAssign a collaborator for issue
 func (s *Server) UnAssign(event *gitee.NoteEvent) error {
 	if *event.NoteableType == "Issue" {
@@ -71,7 +64,7 @@ func (s *Server) UnAssign(event *gitee.NoteEvent) error {
 				// add comment
 				bodyComment := gitee.IssueCommentPostParam{}
 				bodyComment.AccessToken = s.Config.GiteeToken
-				bodyComment.Body = fmt.Sprintf(issueUnAssignMessage, unassignee)
+				bodyComment.Body = s.message(owner, repo, issueUnAssignMessage, MessageData{"Assignee": unassignee})
 				_, _, err = s.GiteeClient.IssuesApi.PostV5ReposOwnerRepoIssuesNumberComments(s.Context, owner, repo, issueNumber, bodyComment)
 				if err != nil {
 					glog.Errorf("unable to add comment in issue: %v", err)
@@ -81,7 +74,7 @@ func (s *Server) UnAssign(event *gitee.NoteEvent) error {
 				// add comment
 				body := gitee.IssueCommentPostParam{}
 				body.AccessToken = s.Config.GiteeToken
-				body.Body = fmt.Sprintf(issueCanNotUnAssignMessage, unassignee)
+				body.Body = s.message(owner, repo, issueCanNotUnAssignMessage, MessageData{"Assignee": unassignee})
 				_, _, err := s.GiteeClient.IssuesApi.PostV5ReposOwnerRepoIssuesNumberComments(s.Context, owner, repo, issueNumber, body)
 				if err != nil {
 					glog.Errorf("unable to add comment in issue: %v", err)
@@ -1,7 +1,6 @@
 package cibot
 
 import (
-	"fmt"
 	"regexp"
 	"strings"
 
@@ -17,18 +16,11 @@ const (
 	AddClaNo               = "/%s-cla no"
 	RemoveClaYes           = "/remove-%s-cla yes"
 	RemoveClaNo            = "/remove-%s-cla no"
+	LabelClaYes            = "%s-cla/yes"
+	LabelClaNo             = "%s-cla/no"
 	LabelNameLgtm          = "lgtm"
 	LabelLgtmWithCommenter = "lgtm-%s"
 	LabelNameApproved      = "approved"
-	LabelHiddenValue       = "<input type=hidden value=%s />"
-	tipBotMessage          = `Hi ***%s***, welcome to the %s Community.
-I'm the Bot here serving you. You can find the instructions on how to interact with me at
-<%s>.
-%s`
-	DisplayCommittors = `If you have any questions, please contact the SIG: [%s](%s), and any of the maintainers: `
-	SigPath           = `https://gitee.com/openeuler/community/tree/master/sig/%s`
-	AutoAddPrjMsg = `Since you have added a item to the src-openeuler.yaml file, we will automatically generate a default package in project openEuler:Factory on OBS cluster for you.
-If you need a more customized configuration, you can configure it according to the following instructions: `
 )
 
 var (
@@ -50,14 +42,18 @@ var (
 	RegClose = regexp.MustCompile(`(?mi)^/close\s*$`)
 	// RegReOpen
 	RegReOpen = regexp.MustCompile(`(?mi)^/reopen\s*$`)
-	// RegBotAddLgtm
-	RegBotAddLgtm = regexp.MustCompile(fmt.Sprintf(LabelHiddenValue, "(.*)"))
 	// RegAssign
 	RegAssign = regexp.MustCompile(`(?mi)^/assign(( @?[-\w]+?)*)\s*$`)
 	// RegUnAssign
 	RegUnAssign = regexp.MustCompile(`(?mi)^/unassign(( @?[-\w]+?)*)\s*$`)
 	// RegCheckPr
 	RegCheckPr = regexp.MustCompile(`(?mi)^/check-pr\s*$`)
+	// RegLifecycle
+	RegLifecycle = regexp.MustCompile(`(?mi)^/lifecycle\s+(stale|rotten|frozen)\s*$`)
+	// RegRemoveLifecycle
+	RegRemoveLifecycle = regexp.MustCompile(`(?mi)^/remove-lifecycle\s+(stale|rotten|frozen)\s*$`)
+	// RegFreezeException
+	RegFreezeException = regexp.MustCompile(`(?mi)^/freeze-exception\s+(.+?)\s*$`)
 )
 
 // UrlEncode replcae special chars in url
@@ -102,6 +98,7 @@ func canCommentPrIncludingSigDirectory(server *Server, owner string, repo string
 	}
 
 	glog.Infof("targetSigPath=%v", targetSigPath)
+	aliases := server.GetOwnerAliases(owner, repo)
 
 	for path, _ := range targetSigPath {
 		content, _, err := server.GiteeClient.RepositoriesApi.GetV5ReposOwnerRepoContentsPath(
@@ -115,7 +112,7 @@ func canCommentPrIncludingSigDirectory(server *Server, owner string, repo string
 			return -1, nil
 		}
 
-		owners := DecodeOwners(content.Content)
+		owners := DecodeOwners(content.Content, aliases)
 		glog.Infof("owners=%v, commenturser=%v", owners, commentUser)
 		if owners == nil {
 			return 0, nil
@@ -0,0 +1,280 @@
+package cibot
+
+import (
+	"fmt"
+	"strings"
+
+	"gitee.com/openeuler/ci-bot/pkg/cibot/database"
+	"gitee.com/openeuler/go-gitee/gitee"
+	"github.com/antihax/optional"
+	"github.com/golang/glog"
+)
+
+const (
+	VoteKindLgtm    = "lgtm"
+	VoteKindApprove = "approve"
+)
+
+// recordVote records the vote of user on the head sha, the previous vote of the same kind is replaced
+func recordVote(owner, repo string, number int32, user, kind, sha string) error {
+	tx := database.DBConnection.Begin()
+	err := tx.Model(&database.ReviewVotes{}).
+		Where("owner = ? and repo = ? and number = ? and user = ? and kind = ? and retracted = ?",
+			owner, repo, number, user, kind, false).
+		Update("retracted", true).Error
+	if err != nil {
+		glog.Errorf("unable to replace the previous vote: %v", err)
+		tx.Rollback()
+		return err
+	}
+	vote := database.ReviewVotes{
+		Owner:  owner,
+		Repo:   repo,
+		Number: int(number),
+		User:   user,
+		Kind:   kind,
+		Sha:    sha,
+	}
+	err = tx.Create(&vote).Error
+	if err != nil {
+		glog.Errorf("unable to record vote: %v", err)
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit().Error
+}
+
+// votesFromLabels gets the votes of the lgtm and approved labels added before votes were recorded,
+// the voter of lgtm and approved labels is unknown
+func votesFromLabels(owner, repo string, number int32, sha string, labels []string) []database.ReviewVotes {
+	votes := make([]database.ReviewVotes, 0)
+	lgtmPrefix := fmt.Sprintf(LabelLgtmWithCommenter, "")
+	for _, l := range labels {
+		vote := database.ReviewVotes{Owner: owner, Repo: repo, Number: int(number), Sha: sha}
+		switch {
+		case l == LabelNameLgtm:
+			vote.Kind = VoteKindLgtm
+		case strings.HasPrefix(l, lgtmPrefix) && len(l) > len(lgtmPrefix):
+			vote.Kind = VoteKindLgtm
+			vote.User = l[len(lgtmPrefix):]
+		case l == LabelNameApproved:
+			vote.Kind = VoteKindApprove
+		default:
+			continue
+		}
+		votes = append(votes, vote)
+	}
+	return votes
+}
+
+// backfillVotes records the votes of labels for the pull request which has no votes recorded,
+// so that the labels added before votes were recorded are kept
+func backfillVotes(owner, repo string, number int32, sha string, labels []string) error {
+	count := 0
+	err := database.DBConnection.Model(&database.ReviewVotes{}).
+		Where("owner = ? and repo = ? and number = ?", owner, repo, number).Count(&count).Error
+	if err != nil {
+		glog.Errorf("unable to count votes: %v", err)
+		return err
+	}
+	if count > 0 {
+		return nil
+	}
+	for _, v := range votesFromLabels(owner, repo, number, sha, labels) {
+		vote := v
+		err = database.DBConnection.Create(&vote).Error
+		if err != nil {
+			glog.Errorf("unable to backfill vote: %v", err)
+			return err
+		}
+		glog.Infof("backfill %s vote of %q on %s/%s/%d", vote.Kind, vote.User, owner, repo, number)
+	}
+	return nil
+}
+
+// backfillVotesByHook backfills the votes with the labels in payload of pull request
+func backfillVotesByHook(owner, repo string, pr *gitee.PullRequestHook, sha string) error {
+	labels := make([]string, 0, len(pr.Labels))
+	for _, l := range pr.Labels {
+		labels = append(labels, l.Name)
+	}
+	return backfillVotes(owner, repo, pr.Number, sha, labels)
+}
+
+// backfillVotesOf backfills the votes with the labels on the head of pull request
+func backfillVotesOf(owner, repo string, pr gitee.PullRequest) error {
+	labels := make([]string, 0, len(pr.Labels))
+	for _, l := range pr.Labels {
+		labels = append(labels, l.Name)
+	}
+	return backfillVotes(owner, repo, pr.Number, pr.Head.Sha, labels)
+}
+
+// latestPullRequest gets the latest head and labels of pull request, the ones in payload may be stale
+func (s *Server) latestPullRequest(owner, repo string, number int32) (gitee.PullRequest, error) {
+	lvos := &gitee.GetV5ReposOwnerRepoPullsNumberOpts{}
+	lvos.AccessToken = optional.NewString(s.Config.GiteeToken)
+	pr, _, err := s.GiteeClient.PullRequestsApi.GetV5ReposOwnerRepoPullsNumber(s.Context, owner, repo, number, lvos)
+	if err != nil {
+		glog.Errorf("unable to get pull request. err: %v", err)
+		return pr, err
+	}
+	return pr, nil
+}
+
+// backfillVotesOnHead backfills the votes with the labels on the latest head of pull request
+func (s *Server) backfillVotesOnHead(owner, repo string, number int32) error {
+	pr, err := s.latestPullRequest(owner, repo, number)
+	if err != nil {
+		return err
+	}
+	return backfillVotesOf(owner, repo, pr)
+}
+
+// recordVoteOnHead records the vote of user on the latest head of pull request
+func (s *Server) recordVoteOnHead(owner, repo string, number int32, user, kind string) error {
+	pr, err := s.latestPullRequest(owner, repo, number)
+	if err != nil {
+		return err
+	}
+	err = backfillVotesOf(owner, repo, pr)
+	if err != nil {
+		return err
+	}
+	return recordVote(owner, repo, number, user, kind, pr.Head.Sha)
+}
+
+// retractVotes retracts the votes of kind in pull request, all users' votes are retracted when user is empty
+func retractVotes(owner, repo string, number int32, user, kind string) error {
+	db := database.DBConnection.Model(&database.ReviewVotes{}).
+		Where("owner = ? and repo = ? and number = ? and kind = ? and retracted = ?",
+			owner, repo, number, kind, false)
+	if user != "" {
+		db = db.Where("user = ?", user)
+	}
+	err := db.Update("retracted", true).Error
+	if err != nil {
+		glog.Errorf("unable to retract votes: %v", err)
+	}
+	return err
+}
+
+// invalidateStaleVotes retracts the votes which are not on the head sha and returns the count of them
+func invalidateStaleVotes(owner, repo string, number int32, sha string) (int64, error) {
+	db := database.DBConnection.Model(&database.ReviewVotes{}).
+		Where("owner = ? and repo = ? and number = ? and sha <> ? and retracted = ?",
+			owner, repo, number, sha, false).
+		Update("retracted", true)
+	if db.Error != nil {
+		glog.Errorf("unable to invalidate stale votes: %v", db.Error)
+		return 0, db.Error
+	}
+	return db.RowsAffected, nil
+}
+
+// getActiveVotes gets the votes on the head sha in voting order
+func getActiveVotes(owner, repo string, number int32, sha string) ([]database.ReviewVotes, error) {
+	var votes []database.ReviewVotes
+	err := database.DBConnection.Model(&database.ReviewVotes{}).
+		Where("owner = ? and repo = ? and number = ? and sha = ? and retracted = ?",
+			owner, repo, number, sha, false).
+		Order("created_at").Find(&votes).Error
+	if err != nil {
+		glog.Errorf("unable to get votes: %v", err)
+		return nil, err
+	}
+	return votes, nil
+}
+
+// votersOf returns the distinct voters of kind in voting order
+func votersOf(votes []database.ReviewVotes, kind string) []string {
+	voters := make([]string, 0)
+	existing := make(map[string]bool)
+	for _, v := range votes {
+		if v.Kind != kind || existing[v.User] {
+			continue
+		}
+		existing[v.User] = true
+		voters = append(voters, v.User)
+	}
+	return voters
+}
+
+// expectedVoteLabels returns the lgtm and approved labels which should be on pull request
+func (s *Server) expectedVoteLabels(owner, repo string, votes []database.ReviewVotes) []string {
+	labels := make([]string, 0)
+	lgtmVoters := votersOf(votes, VoteKindLgtm)
+	if s.calculateLgtmLabel(owner, repo) > 1 {
+		for _, v := range lgtmVoters {
+			// the backfilled lgtm without voter has no label of commenter
+			if v == "" {
+				continue
+			}
+			labels = append(labels, fmt.Sprintf(LabelLgtmWithCommenter, strings.ToLower(v)))
+		}
+	} else if len(lgtmVoters) > 0 {
+		labels = append(labels, LabelNameLgtm)
+	}
+	if len(votersOf(votes, VoteKindApprove)) > 0 {
+		labels = append(labels, LabelNameApproved)
+	}
+	return truncateLabel(labels)
+}
+
+// syncVoteLabels makes the lgtm and approved labels match with the votes on the head sha,
+// and returns the removed labels
+func (s *Server) syncVoteLabels(event *gitee.NoteEvent) ([]string, error) {
+	owner := event.Repository.Namespace
+	repo := event.Repository.Path
+	number := event.PullRequest.Number
+
+	// get the latest head and labels
+	pr, err := s.latestPullRequest(owner, repo, number)
+	if err != nil {
+		return nil, err
+	}
+	votes, err := getActiveVotes(owner, repo, number, pr.Head.Sha)
+	if err != nil {
+		return nil, err
+	}
+
+	expected := make(map[string]bool)
+	for _, l := range s.expectedVoteLabels(owner, repo, votes) {
+		expected[l] = true
+	}
+	existing := make(map[string]bool)
+	mapOfRemoveLabels := map[string]string{}
+	for _, l := range pr.Labels {
+		if !s.hasLgtmLabel([]gitee.Label{l}) && l.Name != LabelNameApproved {
+			continue
+		}
+		existing[l.Name] = true
+		if !expected[l.Name] {
+			mapOfRemoveLabels[l.Name] = l.Name
+		}
+	}
+	listOfAddLabels := make([]string, 0)
+	for l := range expected {
+		if !existing[l] {
+			listOfAddLabels = append(listOfAddLabels, l)
+		}
+	}
+
+	if len(listOfAddLabels) > 0 {
+		err = s.AddSpecifyLabelsInPulRequest(event, listOfAddLabels, true)
+		if err != nil {
+			return nil, err
+		}
+	}
+	listOfRemoveLabels := make([]string, 0, len(mapOfRemoveLabels))
+	if len(mapOfRemoveLabels) > 0 {
+		err = s.RemoveSpecifyLabelsInPulRequest(event, mapOfRemoveLabels)
+		if err != nil {
+			return nil, err
+		}
+		for l := range mapOfRemoveLabels {
+			listOfRemoveLabels = append(listOfRemoveLabels, l)
+		}
+	}
+	return listOfRemoveLabels, nil
+}
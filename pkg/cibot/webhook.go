@@ -40,46 +40,28 @@ func (s *Webhook) AddFlags(fs *pflag.FlagSet) {
 	pflag.Parse()
 }
 
-func (s *Webhook) Run() {
+// Run serves the webhook and cla page, and returns the exit code when it fails to start
+func (s *Webhook) Run() int {
 	// Flush flushes all pending log I/O.
 	defer glog.Flush()
 
-	// read file
-	configContent, err := ioutil.ReadFile(s.ConfigFile)
-	if err != nil {
-		glog.Fatalf("could not read config file: %v", err)
-	}
+	config := loadConfig(s.ConfigFile)
+	ctx := context.Background()
+	giteeClient := newGiteeClient(ctx, config.GiteeToken)
 
-	// unmarshal config file
-	var config cfg.Config
-	err = yaml.Unmarshal(configContent, &config)
+	err := database.New(config)
 	if err != nil {
-		glog.Fatalf("fail to unmarshal: %v", err)
+		glog.Errorf("init back database error: %v", err)
 	}
-
-	//parse environment variables by tag
-	err = cfg.ParseEnvConf(&config, "")
+	// the personal data of cla is encrypted, the cla page is not served without the key
+	err = LoadCLACrypto()
 	if err != nil {
-		glog.Info("fail to ParseEnvConf: %v", err)
+		glog.Errorf("load cla encryption key error: %v", err)
+		return 1
 	}
-
-	// oauth
-	oauthSecret := config.GiteeToken
-	ctx := context.Background()
-	ts := oauth2.StaticTokenSource(
-		&oauth2.Token{AccessToken: string(oauthSecret)},
-	)
-
-	// configuration
-	giteeConf := gitee.NewConfiguration()
-	giteeConf.HTTPClient = oauth2.NewClient(ctx, ts)
-
-	// git client
-	giteeClient := gitee.NewAPIClient(giteeConf)
-
-	err = database.New(config)
+	err = EncryptPlainCLADetails()
 	if err != nil {
-		glog.Errorf("init back database error: %v", err)
+		glog.Errorf("encrypt cla details error: %v", err)
 	}
 	frozenHandler := FrozenHandler{
 		Config:      config,
@@ -118,6 +100,22 @@ func (s *Webhook) Run() {
 	}
 	go ownerHandler.Serve()
 
+	// setting merge queue handler
+	mergeQueueHandler := MergeQueueHandler{
+		Config:      config,
+		Context:     ctx,
+		GiteeClient: giteeClient,
+	}
+	go mergeQueueHandler.Serve()
+
+	// setting lifecycle handler
+	lifecycleHandler := LifecycleHandler{
+		Config:      config,
+		Context:     ctx,
+		GiteeClient: giteeClient,
+	}
+	go lifecycleHandler.Serve()
+
 	// return 200 for health check
 	http.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {})
 
@@ -131,13 +129,71 @@ func (s *Webhook) Run() {
 
 	// setting cla handler
 	claHandler := CLAHandler{
-		Context: ctx,
+		Config:      config,
+		Context:     ctx,
+		GiteeClient: giteeClient,
 	}
-	http.HandleFunc("/cla", claHandler.ServeHTTP)
+	http.HandleFunc("/cla", claHandler.CORS(claHandler.ServeHTTP))
+	http.HandleFunc("/cla/corporations", claHandler.CORS(claHandler.ServeCorporations))
+	http.HandleFunc("/cla/corporations/employees", claHandler.CORS(claHandler.ServeCorporationEmployees))
+	http.HandleFunc("/cla/versions", claHandler.CORS(claHandler.ServeCLAVersions))
+	http.HandleFunc("/cla/signatures/revoke", claHandler.CORS(claHandler.ServeCLARevocations))
+	http.HandleFunc("/cla/signature", claHandler.CORS(claHandler.ServeOwnCLASignature))
+	http.HandleFunc("/cla/session", claHandler.CORS(claHandler.ServeCLASession))
+	http.HandleFunc("/cla/signatures/export", claHandler.CORS(claHandler.ServeCLAExport))
+	http.HandleFunc("/cla/signatures/erase", claHandler.CORS(claHandler.ServeCLAErasure))
+	http.HandleFunc("/cla/reports/signatures", claHandler.CORS(claHandler.ServeCLAReportSignatures))
+	http.HandleFunc("/cla/reports/counts", claHandler.CORS(claHandler.ServeCLAReportCounts))
 
 	//starting server
 	address := s.Address + ":" + strconv.FormatInt(s.Port, 10)
 	if err := http.ListenAndServe(address, nil); err != nil {
 		glog.Error(err)
+		return 1
+	}
+	return 0
+}
+
+// loadConfig reads the config file, and parses the environment variables
+func loadConfig(configFile string) cfg.Config {
+	// read file
+	configContent, err := ioutil.ReadFile(configFile)
+	if err != nil {
+		glog.Fatalf("could not read config file: %v", err)
+	}
+
+	// unmarshal config file
+	var config cfg.Config
+	err = yaml.Unmarshal(configContent, &config)
+	if err != nil {
+		glog.Fatalf("fail to unmarshal: %v", err)
+	}
+
+	//parse environment variables by tag
+	err = cfg.ParseEnvConf(&config, "")
+	if err != nil {
+		glog.Info("fail to ParseEnvConf: %v", err)
 	}
+
+	// load the message templates overriding the built-in ones
+	err = LoadMessageTemplates(config.MessageTemplateDir)
+	if err != nil {
+		glog.Errorf("fail to load message templates: %v", err)
+	}
+	return config
+}
+
+// newGiteeClient creates the gitee client authorized by token
+func newGiteeClient(ctx context.Context, token string) *gitee.APIClient {
+	// oauth
+	ts := oauth2.StaticTokenSource(
+		&oauth2.Token{AccessToken: token},
+	)
+
+	// configuration
+	giteeConf := gitee.NewConfiguration()
+	giteeConf.HTTPClient = oauth2.NewClient(ctx, ts)
+
+	// git client
+	return gitee.NewAPIClient(giteeConf)
 }